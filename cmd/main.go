@@ -157,7 +157,7 @@ func main() {
 		From:                  wallet.Address,
 		To:                    "mqVKYrNJSmJNQNnQpqNk5XnxSc4iXTJmkt", // BSV testnet address
 		Amount:                1000,                                 // 1000 satoshis
-		PrivateKey:            reconstructedMnemonic,
+		PrivateKey:            types.NewSecret(reconstructedMnemonic),
 		IncludeNativeUTXOs:    true,
 		IncludeNonNativeUTXOs: false,
 		TokenTransfers:        []*types.TokenTransfer{},
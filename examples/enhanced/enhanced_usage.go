@@ -198,7 +198,7 @@ func main() {
 		To:                    "mqVKYrNJSmJNQNnQpqNk5XnxSc4iXTJmkt", // BSV testnet address
 		Amount:                1000,                                 // 1000 satoshis
 		FeeRate:               8,                                    // 8 sat/vbyte (from our config)
-		PrivateKey:            reconstructedMnemonic,
+		PrivateKey:            types.NewSecret(reconstructedMnemonic),
 		IncludeNativeUTXOs:    true,
 		IncludeNonNativeUTXOs: false,
 		TokenTransfers:        []*types.TokenTransfer{},
@@ -65,7 +65,7 @@ func main() {
 		To:         "mqVKYrNJSmJNQNnQpqNk5XnxSc4iXTJmkt", // BSV testnet address
 		Amount:     1000,                                 // 1000 satoshis
 		FeeRate:    5,                                    // 5 satoshis per vbyte
-		PrivateKey: mnemonicPhrase,
+		PrivateKey: types.NewSecret(mnemonicPhrase),
 	}
 
 	fmt.Printf("      From: %s\n", testParams1.From)
@@ -90,7 +90,7 @@ func main() {
 		To:         "mqVKYrNJSmJNQNnQpqNk5XnxSc4iXTJmkt",
 		Amount:     10000, // 10000 satoshis
 		FeeRate:    10,    // 10 satoshis per vbyte (higher fee)
-		PrivateKey: mnemonicPhrase,
+		PrivateKey: types.NewSecret(mnemonicPhrase),
 	}
 
 	fmt.Printf("      From: %s\n", testParams2.From)
@@ -112,7 +112,7 @@ func main() {
 		To:         "mqVKYrNJSmJNQNnQpqNk5XnxSc4iXTJmkt",
 		Amount:     100000, // 100000 satoshis (0.001 BSV)
 		FeeRate:    5,      // 5 satoshis per vbyte
-		PrivateKey: mnemonicPhrase,
+		PrivateKey: types.NewSecret(mnemonicPhrase),
 	}
 
 	fmt.Printf("      From: %s\n", testParams3.From)
@@ -0,0 +1,110 @@
+package bitcom
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/btcsuite/btcd/txscript"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+)
+
+// AIPAlgorithm is the AIP signing algorithm: a Bitcoin Signed Message by the
+// key of a P2PKH address
+const AIPAlgorithm = "BITCOIN_ECDSA"
+
+// AIP is an Author Identity Protocol signature. It signs the OP_RETURN opcode
+// followed by every push before the AIP prefix, pipes included, or only the
+// fields listed in Indexes, where field 0 is the OP_RETURN opcode.
+type AIP struct {
+	Algorithm string
+	Address   string // Signer's P2PKH address
+	Signature string // Base64 compact signature
+	Indexes   []int  // Signed fields; empty signs all preceding fields
+	Valid     bool   // Set by ParseScript when the signature verifies
+}
+
+// SignAIP signs the pushes that precede an AIP section, including the pipe
+// that separates it
+func SignAIP(keyPair *wallet.KeyPair, preceding [][]byte) (*AIP, error) {
+	address, err := keyPair.Address()
+	if err != nil {
+		return nil, err
+	}
+
+	aip := &AIP{Algorithm: AIPAlgorithm, Address: address}
+
+	message, err := aip.message(preceding)
+	if err != nil {
+		return nil, err
+	}
+
+	aip.Signature, err = keyPair.SignMessageBase64(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %v", err)
+	}
+
+	return aip, nil
+}
+
+// Pushes implements Protocol
+func (a *AIP) Pushes() ([][]byte, error) {
+	if a.Algorithm == "" || a.Address == "" || a.Signature == "" {
+		return nil, fmt.Errorf("AIP needs an algorithm, address and signature")
+	}
+
+	pushes := [][]byte{[]byte(AIPPrefix), []byte(a.Algorithm), []byte(a.Address), []byte(a.Signature)}
+	for _, index := range a.Indexes {
+		pushes = append(pushes, []byte(strconv.Itoa(index)))
+	}
+	return pushes, nil
+}
+
+// Verify checks the signature against the pushes that precede the AIP section
+func (a *AIP) Verify(preceding [][]byte) error {
+	if a.Algorithm != AIPAlgorithm {
+		return fmt.Errorf("unsupported AIP algorithm: %s", a.Algorithm)
+	}
+
+	message, err := a.message(preceding)
+	if err != nil {
+		return err
+	}
+
+	return wallet.VerifyMessage(a.Address, message, a.Signature)
+}
+
+// message returns the signed bytes
+func (a *AIP) message(preceding [][]byte) ([]byte, error) {
+	fields := append([][]byte{{txscript.OP_RETURN}}, preceding...)
+	if len(a.Indexes) == 0 {
+		return bytes.Join(fields, nil), nil
+	}
+
+	var message []byte
+	for _, index := range a.Indexes {
+		if index < 0 || index >= len(fields) {
+			return nil, fmt.Errorf("AIP field index %d is out of range", index)
+		}
+		message = append(message, fields[index]...)
+	}
+	return message, nil
+}
+
+func parseAIP(args [][]byte) (*AIP, error) {
+	if len(args) < 3 {
+		return nil, fmt.Errorf("AIP needs an algorithm, address and signature, got %d fields", len(args))
+	}
+
+	aip := &AIP{Algorithm: string(args[0]), Address: string(args[1]), Signature: string(args[2])}
+	for _, field := range args[3:] {
+		index, err := strconv.Atoi(string(field))
+		if err != nil {
+			return nil, fmt.Errorf("invalid AIP field index: %q", field)
+		}
+		aip.Indexes = append(aip.Indexes, index)
+	}
+
+	return aip, nil
+}
@@ -0,0 +1,147 @@
+package bitcom
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// bcatNull marks an unused BCAT field
+var bcatNull = []byte{0x00}
+
+// BCAT indexes a file too large for one transaction. Each part is stored in
+// its own transaction with a BCATPart output; the index lists them in order.
+type BCAT struct {
+	Info      string
+	MediaType string
+	Charset   string
+	Filename  string
+	Flag      string
+	Parts     []chainhash.Hash // Transactions holding the parts, in order
+}
+
+// Pushes implements Protocol. Parts are pushed as 32-byte transaction IDs in
+// display byte order.
+func (c *BCAT) Pushes() ([][]byte, error) {
+	if len(c.Parts) == 0 {
+		return nil, fmt.Errorf("BCAT needs at least one part")
+	}
+
+	pushes := [][]byte{[]byte(BCATPrefix)}
+	for _, field := range []string{c.Info, c.MediaType, c.Charset, c.Filename, c.Flag} {
+		if field == "" {
+			pushes = append(pushes, bcatNull)
+		} else {
+			pushes = append(pushes, []byte(field))
+		}
+	}
+
+	for _, part := range c.Parts {
+		pushes = append(pushes, reverseHash(part))
+	}
+	return pushes, nil
+}
+
+// BCATPart is one chunk of a BCAT file
+type BCATPart struct {
+	Data []byte
+}
+
+// Pushes implements Protocol
+func (p *BCATPart) Pushes() ([][]byte, error) {
+	return [][]byte{[]byte(BCATPartPrefix), p.Data}, nil
+}
+
+// SplitBCAT splits a file into part data outputs of at most chunkSize bytes.
+// Broadcast each in its own transaction and index their IDs with a BCAT.
+func SplitBCAT(data []byte, chunkSize int) ([]*types.DataOutput, error) {
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("chunk size must be positive")
+	}
+
+	if len(data) == 0 {
+		return nil, fmt.Errorf("file is empty")
+	}
+
+	var outputs []*types.DataOutput
+	for start := 0; start < len(data); start += chunkSize {
+		end := start + chunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		builder := NewBuilder()
+		if err := builder.Add(&BCATPart{Data: data[start:end]}); err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, builder.DataOutput())
+	}
+	return outputs, nil
+}
+
+// AssembleBCAT reassembles a BCAT file from the transactions holding its
+// parts, given in any order
+func AssembleBCAT(index *BCAT, parts []*wire.MsgTx) ([]byte, error) {
+	chunks := make(map[chainhash.Hash][]byte, len(parts))
+	for _, tx := range parts {
+		records, err := ParseTransaction(tx)
+		if err != nil {
+			return nil, fmt.Errorf("part %s: %v", tx.TxHash(), err)
+		}
+
+		for _, record := range records {
+			if record.BCATPart != nil {
+				chunks[tx.TxHash()] = record.BCATPart
+				break
+			}
+		}
+	}
+
+	var file bytes.Buffer
+	for i, part := range index.Parts {
+		chunk, ok := chunks[part]
+		if !ok {
+			return nil, fmt.Errorf("missing part %d: %s", i, part)
+		}
+		file.Write(chunk)
+	}
+	return file.Bytes(), nil
+}
+
+func parseBCAT(args [][]byte) (*BCAT, error) {
+	if len(args) < 6 {
+		return nil, fmt.Errorf("BCAT needs five fields and at least one part, got %d fields", len(args))
+	}
+
+	var fields [5]string
+	for i := range fields {
+		if !bytes.Equal(args[i], bcatNull) {
+			fields[i] = string(args[i])
+		}
+	}
+
+	index := &BCAT{Info: fields[0], MediaType: fields[1], Charset: fields[2], Filename: fields[3], Flag: fields[4]}
+	for i, part := range args[5:] {
+		if len(part) != chainhash.HashSize {
+			return nil, fmt.Errorf("BCAT part %d is %d bytes, expected a transaction ID", i, len(part))
+		}
+
+		var hash chainhash.Hash
+		copy(hash[:], reverseHash(chainhash.Hash(part)))
+		index.Parts = append(index.Parts, hash)
+	}
+	return index, nil
+}
+
+// reverseHash returns a hash's bytes in display order
+func reverseHash(hash chainhash.Hash) []byte {
+	reversed := make([]byte, chainhash.HashSize)
+	for i := range hash {
+		reversed[i] = hash[chainhash.HashSize-1-i]
+	}
+	return reversed
+}
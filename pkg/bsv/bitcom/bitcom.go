@@ -0,0 +1,188 @@
+// Package bitcom builds and parses Bitcom protocol data carried in
+// OP_FALSE OP_RETURN outputs: B:// files, MAP metadata, AIP author signatures
+// and BCAT multi-part files
+package bitcom
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// Bitcom protocol prefixes
+const (
+	BPrefix        = "19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut"
+	MAPPrefix      = "1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5"
+	AIPPrefix      = "15PciHG22SNLQJXMoSUaWVi7WSqc7hCfva"
+	BCATPrefix     = "15DHFxWZJT58f9nhyGnsRBqrgwK4W6h4Up"
+	BCATPartPrefix = "1ChDHzdd1H4wSjgGMHyndZm6qxEDGjqpJL"
+)
+
+// Pipe separates protocols within one data output
+const Pipe = "|"
+
+// Protocol is a Bitcom protocol that can be written to a data output
+type Protocol interface {
+	// Pushes returns the protocol's elements, starting with its prefix
+	Pushes() ([][]byte, error)
+}
+
+// Builder composes protocols into one data output, separated by pipes
+type Builder struct {
+	pushes [][]byte
+}
+
+// NewBuilder creates an empty Bitcom builder
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Add appends a protocol
+func (b *Builder) Add(protocol Protocol) error {
+	pushes, err := protocol.Pushes()
+	if err != nil {
+		return err
+	}
+
+	if len(b.pushes) > 0 {
+		b.pushes = append(b.pushes, []byte(Pipe))
+	}
+	b.pushes = append(b.pushes, pushes...)
+
+	return nil
+}
+
+// Sign appends an AIP signature by keyPair over everything added so far
+func (b *Builder) Sign(keyPair *wallet.KeyPair) error {
+	if len(b.pushes) == 0 {
+		return fmt.Errorf("nothing to sign")
+	}
+
+	// The signature covers the pipe that will separate it
+	preceding := append(append([][]byte{}, b.pushes...), []byte(Pipe))
+	aip, err := SignAIP(keyPair, preceding)
+	if err != nil {
+		return err
+	}
+
+	return b.Add(aip)
+}
+
+// DataOutput returns the composed data output
+func (b *Builder) DataOutput() *types.DataOutput {
+	pushes := make([]types.DataPush, len(b.pushes))
+	for i, push := range b.pushes {
+		pushes[i] = types.DataPush{Bytes: push}
+	}
+	return &types.DataOutput{Pushes: pushes}
+}
+
+// Record holds the Bitcom protocols found in one data output
+type Record struct {
+	Vout     int      // Index of the data output
+	Files    []*BFile // B:// files
+	MAP      []*MAP   // MAP commands
+	AIP      []*AIP   // Author signatures, checked when parsed
+	BCAT     *BCAT    // Multi-part file index
+	BCATPart []byte   // Multi-part file chunk
+}
+
+// ParseTransaction reads the Bitcom protocols out of every data output of tx.
+// Outputs without a known protocol are skipped.
+func ParseTransaction(tx *wire.MsgTx) ([]*Record, error) {
+	var records []*Record
+	for vout, txOut := range tx.TxOut {
+		if _, err := transaction.ParseDataScript(txOut.PkScript); err != nil {
+			continue
+		}
+
+		record, err := ParseScript(txOut.PkScript)
+		if err != nil {
+			return nil, fmt.Errorf("output %d: %v", vout, err)
+		}
+
+		if record.empty() {
+			continue
+		}
+
+		record.Vout = vout
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// ParseScript reads the protocols of one data output script
+func ParseScript(script []byte) (*Record, error) {
+	pushes, err := transaction.ParseDataScript(script)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &Record{}
+	start := 0
+	for start < len(pushes) {
+		end := start
+		for end < len(pushes) && !bytes.Equal(pushes[end], []byte(Pipe)) {
+			end++
+		}
+
+		if err := record.parseSection(pushes[start:end], pushes[:start]); err != nil {
+			return nil, err
+		}
+		start = end + 1
+	}
+
+	return record, nil
+}
+
+// parseSection reads one protocol. preceding holds every push before it,
+// which AIP signatures cover.
+func (r *Record) parseSection(section, preceding [][]byte) error {
+	if len(section) == 0 {
+		return nil
+	}
+
+	args := section[1:]
+	switch string(section[0]) {
+	case BPrefix:
+		file, err := parseBFile(args)
+		if err != nil {
+			return err
+		}
+		r.Files = append(r.Files, file)
+	case MAPPrefix:
+		command, err := parseMAP(args)
+		if err != nil {
+			return err
+		}
+		r.MAP = append(r.MAP, command)
+	case AIPPrefix:
+		aip, err := parseAIP(args)
+		if err != nil {
+			return err
+		}
+		aip.Valid = aip.Verify(preceding) == nil
+		r.AIP = append(r.AIP, aip)
+	case BCATPrefix:
+		index, err := parseBCAT(args)
+		if err != nil {
+			return err
+		}
+		r.BCAT = index
+	case BCATPartPrefix:
+		if len(args) != 1 {
+			return fmt.Errorf("BCAT part needs exactly one data push, got %d", len(args))
+		}
+		r.BCATPart = args[0]
+	}
+	return nil
+}
+
+func (r *Record) empty() bool {
+	return len(r.Files) == 0 && len(r.MAP) == 0 && len(r.AIP) == 0 && r.BCAT == nil && r.BCATPart == nil
+}
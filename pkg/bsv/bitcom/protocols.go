@@ -0,0 +1,147 @@
+package bitcom
+
+import (
+	"fmt"
+)
+
+// BFile is a file stored with the B:// protocol
+type BFile struct {
+	Data      []byte
+	MediaType string // MIME type, such as "image/png"
+	Encoding  string // "binary" or a text encoding such as "utf-8"; defaults to binary
+	Filename  string // Optional
+}
+
+// Pushes implements Protocol
+func (f *BFile) Pushes() ([][]byte, error) {
+	if f.MediaType == "" {
+		return nil, fmt.Errorf("B:// file needs a media type")
+	}
+
+	encoding := f.Encoding
+	if encoding == "" {
+		encoding = "binary"
+	}
+
+	pushes := [][]byte{[]byte(BPrefix), f.Data, []byte(f.MediaType), []byte(encoding)}
+	if f.Filename != "" {
+		pushes = append(pushes, []byte(f.Filename))
+	}
+	return pushes, nil
+}
+
+func parseBFile(args [][]byte) (*BFile, error) {
+	if len(args) < 2 || len(args) > 4 {
+		return nil, fmt.Errorf("B:// needs data, media type, encoding and filename, got %d fields", len(args))
+	}
+
+	file := &BFile{Data: args[0], MediaType: string(args[1])}
+	if len(args) > 2 {
+		file.Encoding = string(args[2])
+	}
+	if len(args) > 3 {
+		file.Filename = string(args[3])
+	}
+	return file, nil
+}
+
+// MAPCommand is a MAP protocol command
+type MAPCommand string
+
+// Supported MAP commands
+const (
+	MAPSet    MAPCommand = "SET"    // Set keys to values
+	MAPAdd    MAPCommand = "ADD"    // Add values to a list key
+	MAPDelete MAPCommand = "DELETE" // Delete key-value pairs
+)
+
+// KeyValue is one MAP key-value pair
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// MAP is a Magic Attribute Protocol command. Commands other than SET, ADD
+// and DELETE, such as REMOVE, SELECT and CLEAR, keep their fields unparsed
+// in Args.
+type MAP struct {
+	Command MAPCommand
+	Pairs   []KeyValue // SET and DELETE
+	Key     string     // ADD
+	Values  []string   // ADD
+	Args    [][]byte   // Other commands
+}
+
+// Pushes implements Protocol
+func (m *MAP) Pushes() ([][]byte, error) {
+	pushes := [][]byte{[]byte(MAPPrefix), []byte(m.Command)}
+
+	switch m.Command {
+	case MAPSet, MAPDelete:
+		if len(m.Pairs) == 0 {
+			return nil, fmt.Errorf("MAP %s needs at least one key-value pair", m.Command)
+		}
+		for _, pair := range m.Pairs {
+			if pair.Key == "" {
+				return nil, fmt.Errorf("MAP %s has an empty key", m.Command)
+			}
+			pushes = append(pushes, []byte(pair.Key), []byte(pair.Value))
+		}
+	case MAPAdd:
+		if m.Key == "" || len(m.Values) == 0 {
+			return nil, fmt.Errorf("MAP ADD needs a key and at least one value")
+		}
+		pushes = append(pushes, []byte(m.Key))
+		for _, value := range m.Values {
+			pushes = append(pushes, []byte(value))
+		}
+	default:
+		if m.Command == "" {
+			return nil, fmt.Errorf("MAP is missing its command")
+		}
+		pushes = append(pushes, m.Args...)
+	}
+
+	return pushes, nil
+}
+
+// Get returns the value a SET command gives a key
+func (m *MAP) Get(key string) (string, bool) {
+	for _, pair := range m.Pairs {
+		if pair.Key == key {
+			return pair.Value, true
+		}
+	}
+	return "", false
+}
+
+func parseMAP(args [][]byte) (*MAP, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("MAP is missing its command")
+	}
+
+	command := &MAP{Command: MAPCommand(args[0])}
+	args = args[1:]
+
+	switch command.Command {
+	case MAPSet, MAPDelete:
+		if len(args) == 0 || len(args)%2 != 0 {
+			return nil, fmt.Errorf("MAP %s needs key-value pairs, got %d fields", command.Command, len(args))
+		}
+		for i := 0; i < len(args); i += 2 {
+			command.Pairs = append(command.Pairs, KeyValue{Key: string(args[i]), Value: string(args[i+1])})
+		}
+	case MAPAdd:
+		if len(args) < 2 {
+			return nil, fmt.Errorf("MAP ADD needs a key and at least one value")
+		}
+		command.Key = string(args[0])
+		for _, value := range args[1:] {
+			command.Values = append(command.Values, string(value))
+		}
+	default:
+		command.Args = args
+	}
+
+	return command, nil
+}
@@ -1,8 +1,14 @@
 package bsv
 
 import (
+	"bytes"
+	"encoding/hex"
 	"fmt"
 
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/spv"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/token"
 	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
 	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
 	"github.com/muhammadamman/BSV-Go/pkg/config"
@@ -106,9 +112,31 @@ func (b *BSV) BuildTransaction(params *types.TransactionParams) (*types.Transact
 		return nil, err
 	}
 
+	return builtTransactionResult(tx)
+}
+
+// BuildTransactionWithSigner builds a transaction signed by signer instead of
+// params.PrivateKey
+func (b *BSV) BuildTransactionWithSigner(params *types.TransactionParams, signer wallet.Signer) (*types.TransactionResult, error) {
+	tx, err := b.txBuilder.BuildTransactionWithSigner(params, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return builtTransactionResult(tx)
+}
+
+// builtTransactionResult describes a built transaction that was not broadcast
+func builtTransactionResult(tx *wire.MsgTx) (*types.TransactionResult, error) {
 	// Convert to result format
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return nil, fmt.Errorf("failed to serialize transaction: %v", err)
+	}
+
 	return &types.TransactionResult{
-		SignedTx: fmt.Sprintf("%x", tx),
+		SignedTx: hex.EncodeToString(buf.Bytes()),
+		TxID:     tx.TxHash().String(),
 	}, nil
 }
 
@@ -117,6 +145,128 @@ func (b *BSV) SignAndSendTransaction(params *types.TransactionParams) (*types.Tr
 	return b.txBuilder.SignAndSendTransaction(params)
 }
 
+// SignAndSendTransactionWithSigner builds, signs with signer, and broadcasts
+// a transaction
+func (b *BSV) SignAndSendTransactionWithSigner(params *types.TransactionParams, signer wallet.Signer) (*types.TransactionResult, error) {
+	return b.txBuilder.SignAndSendTransactionWithSigner(params, signer)
+}
+
+// SignAndSendPayout builds, signs, and broadcasts a multi-output payout,
+// splitting it across several transactions when params.SplitMode allows
+func (b *BSV) SignAndSendPayout(params *types.PayoutParams) ([]*types.TransactionResult, error) {
+	return b.txBuilder.SignAndSendPayout(params)
+}
+
+// SignAndSendPayoutWithSigner builds, signs with signer, and broadcasts a
+// multi-output payout
+func (b *BSV) SignAndSendPayoutWithSigner(params *types.PayoutParams, signer wallet.Signer) ([]*types.TransactionResult, error) {
+	return b.txBuilder.SignAndSendPayoutWithSigner(params, signer)
+}
+
+// BuildUnsignedTransaction builds a transaction for offline signing
+func (b *BSV) BuildUnsignedTransaction(params *types.TransactionParams) (*transaction.UnsignedTransaction, error) {
+	return b.txBuilder.BuildUnsignedTransaction(params)
+}
+
+// FinalizeTransaction broadcasts an offline-signed transaction
+func (b *BSV) FinalizeTransaction(unsigned *transaction.UnsignedTransaction) (*types.TransactionResult, error) {
+	return b.txBuilder.FinalizeTransaction(unsigned)
+}
+
+// DiscardTransaction releases the change address of an unsigned transaction
+// that will not be broadcast
+func (b *BSV) DiscardTransaction(unsigned *transaction.UnsignedTransaction) error {
+	return b.txBuilder.DiscardTransaction(unsigned)
+}
+
+// SignAndSendInscription inscribes files into 1-satoshi ordinals
+func (b *BSV) SignAndSendInscription(params *types.InscriptionParams) (*types.TransactionResult, error) {
+	return b.txBuilder.SignAndSendInscription(params)
+}
+
+// SignAndSendInscriptionWithSigner inscribes files into 1-satoshi ordinals,
+// signing with signer
+func (b *BSV) SignAndSendInscriptionWithSigner(params *types.InscriptionParams, signer wallet.Signer) (*types.TransactionResult, error) {
+	return b.txBuilder.SignAndSendInscriptionWithSigner(params, signer)
+}
+
+// SignAndSendOrdinalTransfer sends 1-satoshi ordinals to new owners
+func (b *BSV) SignAndSendOrdinalTransfer(params *types.OrdinalTransferParams) (*types.TransactionResult, error) {
+	return b.txBuilder.SignAndSendOrdinalTransfer(params)
+}
+
+// SignAndSendOrdinalTransferWithSigner sends 1-satoshi ordinals to new
+// owners, signing with signer
+func (b *BSV) SignAndSendOrdinalTransferWithSigner(params *types.OrdinalTransferParams, signer wallet.Signer) (*types.TransactionResult, error) {
+	return b.txBuilder.SignAndSendOrdinalTransferWithSigner(params, signer)
+}
+
+// ParseInscriptions returns the inscriptions in a transaction's outputs
+func (b *BSV) ParseInscriptions(tx *wire.MsgTx) []*transaction.InscriptionOutput {
+	return b.txBuilder.ParseInscriptions(tx)
+}
+
+// BuildMultiSigTransaction builds a transaction spending a multisig's UTXOs for its cosigners to sign
+func (b *BSV) BuildMultiSigTransaction(multisig *transaction.MultiSigTemplate, params *types.TransactionParams) (*transaction.UnsignedTransaction, error) {
+	return b.txBuilder.BuildMultiSigTransaction(multisig, params)
+}
+
+// GetScriptUTXOs retrieves the UTXOs locked by a script hash
+func (b *BSV) GetScriptUTXOs(scriptHash string) ([]types.UTXO, error) {
+	return b.txBuilder.GetScriptUTXOs(scriptHash)
+}
+
+// AddUnlocker registers a template for spending UTXOs with non-P2PKH locking scripts
+func (b *BSV) AddUnlocker(template transaction.ScriptTemplate) {
+	b.txBuilder.AddUnlocker(template)
+}
+
+// SetTokenProtocol sets the protocol used to build and detect token outputs
+func (b *BSV) SetTokenProtocol(protocol token.Protocol) {
+	b.txBuilder.SetTokenProtocol(protocol)
+}
+
+// SetChangeIndexStore sets where the next HD change address index of each
+// account is kept
+func (b *BSV) SetChangeIndexStore(store wallet.ChangeIndexStore) {
+	b.txBuilder.SetChangeIndexStore(store)
+}
+
+// SetKeyring sets the keyring signing inputs from funding addresses
+func (b *BSV) SetKeyring(keyring *wallet.Keyring) {
+	b.txBuilder.SetKeyring(keyring)
+}
+
+// SignWithTemplates unlocks each input with the template at the same index
+func (b *BSV) SignWithTemplates(tx *wire.MsgTx, inputs []types.UTXO, unlockers []transaction.ScriptTemplate) error {
+	return b.txBuilder.SignWithTemplates(tx, inputs, unlockers)
+}
+
+// AttachBeef builds the BEEF envelope for a sent transaction and stores it on the result
+func (b *BSV) AttachBeef(result *types.TransactionResult, sourceTxs []*wire.MsgTx, bumps []*spv.MerklePath) (*transaction.Beef, error) {
+	return b.txBuilder.AttachBeef(result, sourceTxs, bumps)
+}
+
+// SetSPVVerifier enables SPV verification of fetched UTXOs
+func (b *BSV) SetSPVVerifier(tracker spv.ChainTracker, paths spv.MerklePathProvider) {
+	b.txBuilder.SetSPVVerifier(tracker, paths)
+}
+
+// SetBroadcaster sets where signed transactions are submitted
+func (b *BSV) SetBroadcaster(broadcaster transaction.Broadcaster) {
+	b.txBuilder.SetBroadcaster(broadcaster)
+}
+
+// SetFeeQuoteProvider sets the provider used to pick fee rates automatically
+func (b *BSV) SetFeeQuoteProvider(provider transaction.FeeQuoteProvider) {
+	b.txBuilder.SetFeeQuoteProvider(provider)
+}
+
+// GetFeeQuote returns the fee quote used when no fee rate is given
+func (b *BSV) GetFeeQuote() (*transaction.FeeQuote, error) {
+	return b.txBuilder.GetFeeQuote()
+}
+
 // GetNetwork returns whether this is testnet
 func (b *BSV) GetNetwork() bool {
 	networkConfig := b.configManager.GetNetworkConfig()
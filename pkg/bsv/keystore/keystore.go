@@ -0,0 +1,712 @@
+// Package keystore keeps mnemonics, extended private keys and WIF keys in a
+// password-encrypted JSON file.
+//
+// Secrets are sealed with XChaCha20-Poly1305 under a key derived from the
+// password with scrypt. Labels, key types, networks and addresses are stored
+// in the clear so a locked keystore can still be listed.
+package keystore
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+	"github.com/muhammadamman/BSV-Go/pkg/mnemonic"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// fileVersion is the version of the keystore file format
+const fileVersion = 1
+
+// Default scrypt parameters for new keystores
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// Upper bounds on the scrypt parameters of a keystore file, so a crafted
+// file cannot make Unlock use gigabytes of memory or hours of CPU
+const (
+	maxScryptN = 1 << 20
+	maxScryptR = 32
+	maxScryptP = 16
+)
+
+// checkLabel is the associated data of the sealed value that checks a
+// password
+const checkLabel = "keystore-check"
+
+// Keystore errors
+var (
+	ErrLocked        = errors.New("keystore is locked")
+	ErrWrongPassword = errors.New("wrong keystore password")
+	ErrNotFound      = errors.New("no keystore entry with that label")
+	ErrExists        = errors.New("a keystore entry with that label already exists")
+)
+
+// KeyType is the kind of secret an entry holds
+type KeyType string
+
+// Key types
+const (
+	KeyTypeMnemonic    KeyType = "mnemonic"
+	KeyTypeExtendedKey KeyType = "xprv"
+	KeyTypeWIF         KeyType = "wif"
+)
+
+// EntryInfo describes an entry without its secret
+type EntryInfo struct {
+	Label     string    `json:"label"`
+	Type      KeyType   `json:"type"`
+	Testnet   bool      `json:"testnet"`
+	Address   string    `json:"address"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// entry is an entry as stored, with its secret sealed
+type entry struct {
+	EntryInfo
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// kdfParams are the scrypt parameters of a keystore
+type kdfParams struct {
+	Name string `json:"name"`
+	Salt string `json:"salt"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+}
+
+// file is the portable JSON form of a keystore
+type file struct {
+	Version int       `json:"version"`
+	KDF     kdfParams `json:"kdf"`
+	Check   struct {
+		Nonce      string `json:"nonce"`
+		Ciphertext string `json:"ciphertext"`
+	} `json:"check"`
+	Entries []*entry `json:"entries"`
+}
+
+// Keystore is an encrypted keystore file. It starts locked; Unlock derives
+// the encryption key, which Lock, or the unlock timeout, wipes again.
+type Keystore struct {
+	mutex sync.Mutex
+	path  string
+	data  *file
+	key   []byte
+	timer *time.Timer
+
+	// generation counts unlocks, so a timeout of an earlier unlock that
+	// fires late cannot lock a later one
+	generation uint64
+}
+
+// Create creates a keystore file at path, encrypted with password. The
+// keystore is returned locked.
+func Create(path, password string) (*Keystore, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("keystore file %s already exists", path)
+	}
+
+	data, key, err := newFile(password)
+	if err != nil {
+		return nil, err
+	}
+	types.Wipe(key)
+
+	ks := &Keystore{path: path, data: data}
+	if err := ks.save(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Open reads a keystore file. The keystore is returned locked.
+func Open(path string) (*Keystore, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore: %v", err)
+	}
+
+	data, err := parseFile(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &Keystore{path: path, data: data}, nil
+}
+
+// Unlock derives the encryption key from password. With a positive timeout
+// the keystore locks itself again after that long.
+func (ks *Keystore) Unlock(password string, timeout time.Duration) error {
+	key, err := ks.data.deriveKey(password)
+	if err != nil {
+		return err
+	}
+
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	ks.lock()
+	ks.key = key
+	ks.generation++
+	if timeout > 0 {
+		generation := ks.generation
+		ks.timer = time.AfterFunc(timeout, func() { ks.expire(generation) })
+	}
+	return nil
+}
+
+// expire locks the keystore when the unlock timeout of generation fires,
+// unless it has been unlocked again since
+func (ks *Keystore) expire(generation uint64) {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	if ks.generation == generation {
+		ks.lock()
+	}
+}
+
+// Lock wipes the encryption key from memory
+func (ks *Keystore) Lock() {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	ks.lock()
+}
+
+// lock wipes the key; the caller holds the mutex
+func (ks *Keystore) lock() {
+	if ks.timer != nil {
+		ks.timer.Stop()
+		ks.timer = nil
+	}
+	types.Wipe(ks.key)
+	ks.key = nil
+}
+
+// IsUnlocked reports whether the keystore is unlocked
+func (ks *Keystore) IsUnlocked() bool {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	return ks.key != nil
+}
+
+// List returns the entries sorted by label. It works while locked.
+func (ks *Keystore) List() []EntryInfo {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	infos := make([]EntryInfo, 0, len(ks.data.Entries))
+	for _, e := range ks.data.Entries {
+		infos = append(infos, e.EntryInfo)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Label < infos[j].Label })
+	return infos
+}
+
+// Labels returns the entry labels, sorted
+func (ks *Keystore) Labels() []string {
+	var labels []string
+	for _, info := range ks.List() {
+		labels = append(labels, info.Label)
+	}
+	return labels
+}
+
+// Entry returns the description of an entry
+func (ks *Keystore) Entry(label string) (EntryInfo, error) {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	e, err := ks.data.find(label)
+	if err != nil {
+		return EntryInfo{}, err
+	}
+	return e.EntryInfo, nil
+}
+
+// AddMnemonic stores a mnemonic phrase. Its address is the first receiving
+// address of account 0.
+func (ks *Keystore) AddMnemonic(label, mnemonicPhrase string, isTestnet bool) error {
+	if err := mnemonic.Validate(mnemonicPhrase); err != nil {
+		return fmt.Errorf("invalid mnemonic: %v", err)
+	}
+	return ks.add(label, KeyTypeMnemonic, mnemonicPhrase, isTestnet)
+}
+
+// AddExtendedKey stores an extended private key (xprv or tprv) of a master
+// or account key
+func (ks *Keystore) AddExtendedKey(label, extendedKey string, isTestnet bool) error {
+	if _, err := wallet.ParseExtendedKey(extendedKey, isTestnet); err != nil {
+		return err
+	}
+	return ks.add(label, KeyTypeExtendedKey, extendedKey, isTestnet)
+}
+
+// AddWIF stores a WIF private key of a network
+func (ks *Keystore) AddWIF(label, wif string, isTestnet bool) error {
+	if _, err := wallet.NewWIFSigner(wif, networkFor(isTestnet)); err != nil {
+		return err
+	}
+	return ks.add(label, KeyTypeWIF, wif, isTestnet)
+}
+
+// add seals and stores a validated secret
+func (ks *Keystore) add(label string, keyType KeyType, secret string, isTestnet bool) error {
+	if label == "" {
+		return fmt.Errorf("keystore entries need a label")
+	}
+
+	info := EntryInfo{Label: label, Type: keyType, Testnet: isTestnet, CreatedAt: time.Now().UTC()}
+	signer, err := newSigner(keyType, secret, isTestnet)
+	if err != nil {
+		return err
+	}
+	if info.Address, err = wallet.SignerAddress(signer, networkFor(isTestnet)); err != nil {
+		return err
+	}
+
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	if ks.key == nil {
+		return ErrLocked
+	}
+	if _, err := ks.data.find(label); err == nil {
+		return fmt.Errorf("%w: %s", ErrExists, label)
+	}
+
+	e, err := seal(ks.key, info, []byte(secret))
+	if err != nil {
+		return err
+	}
+	ks.data.Entries = append(ks.data.Entries, e)
+	if err := ks.save(); err != nil {
+		ks.data.Entries = ks.data.Entries[:len(ks.data.Entries)-1]
+		return err
+	}
+	return nil
+}
+
+// Remove deletes an entry
+func (ks *Keystore) Remove(label string) error {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	if ks.key == nil {
+		return ErrLocked
+	}
+
+	entries := ks.data.Entries
+	for i, e := range entries {
+		if e.Label == label {
+			ks.data.Entries = append(append([]*entry(nil), entries[:i]...), entries[i+1:]...)
+			if err := ks.save(); err != nil {
+				ks.data.Entries = entries
+				return err
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrNotFound, label)
+}
+
+// Secret returns the decrypted secret of an entry. Zero it once used.
+func (ks *Keystore) Secret(label string) (types.Secret, error) {
+	plaintext, _, err := ks.open(label)
+	if err != nil {
+		return types.Secret{}, err
+	}
+
+	return types.NewSecretFromBytes(plaintext), nil
+}
+
+// Signer returns a signer for an entry. Mnemonics and extended keys sign with
+// the first receiving key of their account, as an HD signer.
+func (ks *Keystore) Signer(label string) (wallet.Signer, error) {
+	plaintext, info, err := ks.open(label)
+	if err != nil {
+		return nil, err
+	}
+	defer types.Wipe(plaintext)
+
+	return newSigner(info.Type, string(plaintext), info.Testnet)
+}
+
+// HDAccount returns the BIP44 account of a mnemonic or extended key entry
+func (ks *Keystore) HDAccount(label string, account uint32) (*wallet.HDAccount, error) {
+	plaintext, info, err := ks.open(label)
+	if err != nil {
+		return nil, err
+	}
+	defer types.Wipe(plaintext)
+
+	switch info.Type {
+	case KeyTypeMnemonic:
+		return wallet.NewHDAccount(string(plaintext), account, info.Testnet)
+	case KeyTypeExtendedKey:
+		hdAccount, err := wallet.ParseExtendedKey(string(plaintext), info.Testnet)
+		if err == nil && account != 0 {
+			return nil, fmt.Errorf("extended key entries only have account 0")
+		}
+		return hdAccount, err
+	default:
+		return nil, fmt.Errorf("entry %s is a %s key, not an HD key", label, info.Type)
+	}
+}
+
+// open decrypts the secret of an entry
+func (ks *Keystore) open(label string) ([]byte, EntryInfo, error) {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	if ks.key == nil {
+		return nil, EntryInfo{}, ErrLocked
+	}
+	e, err := ks.data.find(label)
+	if err != nil {
+		return nil, EntryInfo{}, err
+	}
+
+	plaintext, err := unseal(ks.key, e)
+	if err != nil {
+		return nil, EntryInfo{}, err
+	}
+	return plaintext, e.EntryInfo, nil
+}
+
+// ChangePassword re-encrypts every entry under a new password with a fresh
+// salt. The keystore is left locked.
+func (ks *Keystore) ChangePassword(oldPassword, newPassword string) error {
+	oldKey, err := ks.data.deriveKey(oldPassword)
+	if err != nil {
+		return err
+	}
+	defer types.Wipe(oldKey)
+
+	data, newKey, err := newFile(newPassword)
+	if err != nil {
+		return err
+	}
+	defer types.Wipe(newKey)
+
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	if data.Entries, err = reseal(ks.data.Entries, oldKey, newKey); err != nil {
+		return err
+	}
+
+	previous := ks.data
+	ks.data = data
+	if err := ks.save(); err != nil {
+		ks.data = previous
+		return err
+	}
+	ks.lock()
+	return nil
+}
+
+// Export writes the keystore as portable JSON. Secrets stay encrypted under
+// the keystore password.
+func (ks *Keystore) Export(w io.Writer) error {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	raw, err := json.MarshalIndent(ks.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode keystore: %v", err)
+	}
+	if _, err := w.Write(raw); err != nil {
+		return fmt.Errorf("failed to write keystore: %v", err)
+	}
+	return nil
+}
+
+// Import adds the entries of an exported keystore, encrypted with password,
+// re-encrypting them under this keystore's key. Nothing is imported if a
+// label is already taken. It returns the number of entries imported.
+func (ks *Keystore) Import(r io.Reader, password string) (int, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read keystore: %v", err)
+	}
+	imported, err := parseFile(raw)
+	if err != nil {
+		return 0, err
+	}
+
+	importKey, err := imported.deriveKey(password)
+	if err != nil {
+		return 0, err
+	}
+	defer types.Wipe(importKey)
+
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	if ks.key == nil {
+		return 0, ErrLocked
+	}
+	for _, e := range imported.Entries {
+		if _, err := ks.data.find(e.Label); err == nil {
+			return 0, fmt.Errorf("%w: %s", ErrExists, e.Label)
+		}
+	}
+
+	entries, err := reseal(imported.Entries, importKey, ks.key)
+	if err != nil {
+		return 0, err
+	}
+
+	previous := ks.data.Entries
+	ks.data.Entries = append(append([]*entry(nil), previous...), entries...)
+	if err := ks.save(); err != nil {
+		ks.data.Entries = previous
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// save writes the keystore file atomically; the caller holds the mutex
+func (ks *Keystore) save() error {
+	raw, err := json.MarshalIndent(ks.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode keystore: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(ks.path), ".keystore-*")
+	if err != nil {
+		return fmt.Errorf("failed to save keystore: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save keystore: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save keystore: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), ks.path); err != nil {
+		return fmt.Errorf("failed to save keystore: %v", err)
+	}
+	return nil
+}
+
+// newFile creates an empty keystore for a password with a fresh salt, and
+// returns its key
+func newFile(password string) (*file, []byte, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate salt: %v", err)
+	}
+
+	data := &file{
+		Version: fileVersion,
+		KDF:     kdfParams{Name: "scrypt", Salt: hex.EncodeToString(salt), N: scryptN, R: scryptR, P: scryptP},
+		Entries: []*entry{},
+	}
+	key, err := data.KDF.derive(password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce, ciphertext, err := encrypt(key, nil, []byte(checkLabel))
+	if err != nil {
+		types.Wipe(key)
+		return nil, nil, err
+	}
+	data.Check.Nonce, data.Check.Ciphertext = nonce, ciphertext
+	return data, key, nil
+}
+
+// parseFile decodes and checks a keystore file
+func parseFile(raw []byte) (*file, error) {
+	var data file
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("invalid keystore file: %v", err)
+	}
+	if data.Version != fileVersion {
+		return nil, fmt.Errorf("unsupported keystore version %d", data.Version)
+	}
+	if data.KDF.Name != "scrypt" {
+		return nil, fmt.Errorf("unsupported key derivation function %q", data.KDF.Name)
+	}
+	if kdf := data.KDF; kdf.N < 2 || kdf.N > maxScryptN || kdf.N&(kdf.N-1) != 0 ||
+		kdf.R < 1 || kdf.R > maxScryptR || kdf.P < 1 || kdf.P > maxScryptP {
+		return nil, fmt.Errorf("unsupported scrypt parameters N=%d r=%d p=%d", kdf.N, kdf.R, kdf.P)
+	}
+
+	seen := make(map[string]bool)
+	for _, e := range data.Entries {
+		if e == nil || e.Label == "" || seen[e.Label] {
+			return nil, fmt.Errorf("invalid keystore file: missing or duplicate entry label")
+		}
+		seen[e.Label] = true
+	}
+	if data.Entries == nil {
+		data.Entries = []*entry{}
+	}
+	return &data, nil
+}
+
+// deriveKey derives the key of a password and checks it against the file
+func (f *file) deriveKey(password string) ([]byte, error) {
+	key, err := f.KDF.derive(password)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := decrypt(key, f.Check.Nonce, f.Check.Ciphertext, []byte(checkLabel)); err != nil {
+		types.Wipe(key)
+		return nil, ErrWrongPassword
+	}
+	return key, nil
+}
+
+// find returns the entry with a label
+func (f *file) find(label string) (*entry, error) {
+	for _, e := range f.Entries {
+		if e.Label == label {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, label)
+}
+
+// derive runs scrypt over a password
+func (p kdfParams) derive(password string) ([]byte, error) {
+	salt, err := hex.DecodeString(p.Salt)
+	if err != nil || len(salt) == 0 {
+		return nil, fmt.Errorf("invalid keystore salt")
+	}
+
+	key, err := scrypt.Key([]byte(password), salt, p.N, p.R, p.P, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive keystore key: %v", err)
+	}
+	return key, nil
+}
+
+// seal encrypts a secret into an entry, binding it to the entry's metadata
+func seal(key []byte, info EntryInfo, secret []byte) (*entry, error) {
+	nonce, ciphertext, err := encrypt(key, secret, additionalData(info))
+	if err != nil {
+		return nil, err
+	}
+	return &entry{EntryInfo: info, Nonce: nonce, Ciphertext: ciphertext}, nil
+}
+
+// unseal decrypts the secret of an entry
+func unseal(key []byte, e *entry) ([]byte, error) {
+	plaintext, err := decrypt(key, e.Nonce, e.Ciphertext, additionalData(e.EntryInfo))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt entry %s: %v", e.Label, err)
+	}
+	return plaintext, nil
+}
+
+// reseal decrypts entries with one key and encrypts them with another
+func reseal(entries []*entry, oldKey, newKey []byte) ([]*entry, error) {
+	resealed := make([]*entry, 0, len(entries))
+	for _, e := range entries {
+		plaintext, err := unseal(oldKey, e)
+		if err != nil {
+			return nil, err
+		}
+
+		sealed, err := seal(newKey, e.EntryInfo, plaintext)
+		types.Wipe(plaintext)
+		if err != nil {
+			return nil, err
+		}
+		resealed = append(resealed, sealed)
+	}
+	return resealed, nil
+}
+
+// additionalData is the metadata an entry's ciphertext is bound to, so it
+// cannot be moved to another label or network
+func additionalData(info EntryInfo) []byte {
+	return []byte(strings.Join([]string{string(info.Type), info.Label, fmt.Sprint(info.Testnet), info.Address}, "\x00"))
+}
+
+// encrypt seals plaintext with XChaCha20-Poly1305, returning the hex nonce
+// and ciphertext
+func encrypt(key, plaintext, additional []byte) (string, string, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create cipher: %v", err)
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", "", fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	return hex.EncodeToString(nonce), hex.EncodeToString(aead.Seal(nil, nonce, plaintext, additional)), nil
+}
+
+// decrypt opens a hex nonce and ciphertext sealed by encrypt
+func decrypt(key []byte, nonceHex, ciphertextHex string, additional []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
+	}
+
+	nonce, err := hex.DecodeString(nonceHex)
+	if err != nil || len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce")
+	}
+	ciphertext, err := hex.DecodeString(ciphertextHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext")
+	}
+	return aead.Open(nil, nonce, ciphertext, additional)
+}
+
+// newSigner creates the signer of a secret
+func newSigner(keyType KeyType, secret string, isTestnet bool) (wallet.Signer, error) {
+	switch keyType {
+	case KeyTypeMnemonic:
+		return wallet.NewMnemonicSigner(secret, isTestnet)
+	case KeyTypeExtendedKey:
+		account, err := wallet.ParseExtendedKey(secret, isTestnet)
+		if err != nil {
+			return nil, err
+		}
+		return wallet.NewHDSigner(account, wallet.ExternalChain, 0)
+	case KeyTypeWIF:
+		return wallet.NewWIFSigner(secret, networkFor(isTestnet))
+	default:
+		return nil, fmt.Errorf("unknown key type %q", keyType)
+	}
+}
+
+// networkFor returns the chain parameters of a network
+func networkFor(isTestnet bool) *chaincfg.Params {
+	if isTestnet {
+		return &chaincfg.TestNet3Params
+	}
+	return &chaincfg.MainNetParams
+}
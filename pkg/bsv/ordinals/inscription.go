@@ -0,0 +1,159 @@
+// Package ordinals reads and writes 1Sat Ordinals inscriptions
+package ordinals
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// envelopeTag marks an ordinals envelope
+const envelopeTag = "ord"
+
+// Envelope field tags
+const (
+	fieldBody        = 0
+	fieldContentType = 1
+)
+
+// Inscription is content inscribed into a 1-satoshi output
+type Inscription struct {
+	ContentType string // MIME type of Body
+	Body        []byte
+}
+
+// Envelope returns OP_FALSE OP_IF "ord" OP_1 <content type> OP_0 <body> OP_ENDIF
+func (i *Inscription) Envelope() ([]byte, error) {
+	if i.ContentType == "" {
+		return nil, fmt.Errorf("inscription needs a content type")
+	}
+
+	script := []byte{txscript.OP_FALSE, txscript.OP_IF}
+	script = append(script, push([]byte(envelopeTag))...)
+	script = append(script, txscript.OP_1)
+	script = append(script, push([]byte(i.ContentType))...)
+	script = append(script, txscript.OP_0)
+	script = append(script, push(i.Body)...)
+	return append(script, txscript.OP_ENDIF), nil
+}
+
+// LockingScript returns the envelope followed by the owner's locking script,
+// usually P2PKH
+func (i *Inscription) LockingScript(owner []byte) ([]byte, error) {
+	envelope, err := i.Envelope()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(owner) == 0 {
+		return nil, fmt.Errorf("inscription needs an owner script")
+	}
+
+	return append(envelope, owner...), nil
+}
+
+// Parse finds an inscription in a locking script. It returns the inscription
+// and the script without its envelope, which decides who can spend the
+// output, or false when the script holds no inscription.
+func Parse(script []byte) (*Inscription, []byte, bool) {
+	tokenizer := txscript.MakeScriptTokenizer(0, script)
+
+	// Look for OP_FALSE OP_IF "ord" at any opcode boundary, remembering where
+	// the two opcodes before the current one started
+	prev := [2]byte{0xff, 0xff}
+	prevStart := [2]int{}
+	for start := 0; tokenizer.Next(); start = int(tokenizer.ByteIndex()) {
+		if prev[0] == txscript.OP_FALSE && prev[1] == txscript.OP_IF && bytes.Equal(tokenizer.Data(), []byte(envelopeTag)) {
+			inscription, end, ok := parseFields(script, int(tokenizer.ByteIndex()))
+			if !ok {
+				return nil, nil, false
+			}
+
+			owner := append(append([]byte{}, script[:prevStart[0]]...), script[end:]...)
+			return inscription, owner, true
+		}
+
+		prev[0], prevStart[0] = prev[1], prevStart[1]
+		prev[1], prevStart[1] = tokenizer.Opcode(), start
+	}
+
+	return nil, nil, false
+}
+
+// parseFields reads the envelope fields from offset to OP_ENDIF, returning the
+// inscription and the offset after the envelope
+func parseFields(script []byte, offset int) (*Inscription, int, bool) {
+	tokenizer := txscript.MakeScriptTokenizer(0, script[offset:])
+	inscription := &Inscription{}
+
+	for tokenizer.Next() {
+		if tokenizer.Opcode() == txscript.OP_ENDIF {
+			return inscription, offset + int(tokenizer.ByteIndex()), true
+		}
+
+		tag, ok := fieldTag(tokenizer.Opcode(), tokenizer.Data())
+		if !ok {
+			return nil, 0, false
+		}
+
+		// The body runs to the end of the envelope
+		if tag == fieldBody {
+			for tokenizer.Next() && tokenizer.Opcode() != txscript.OP_ENDIF {
+				if tokenizer.Opcode() > txscript.OP_PUSHDATA4 {
+					return nil, 0, false
+				}
+				inscription.Body = append(inscription.Body, tokenizer.Data()...)
+			}
+			if tokenizer.Err() != nil || tokenizer.Opcode() != txscript.OP_ENDIF {
+				return nil, 0, false
+			}
+			return inscription, offset + int(tokenizer.ByteIndex()), true
+		}
+
+		if !tokenizer.Next() || tokenizer.Opcode() > txscript.OP_PUSHDATA4 {
+			return nil, 0, false
+		}
+		if tag == fieldContentType {
+			inscription.ContentType = string(tokenizer.Data())
+		}
+	}
+
+	return nil, 0, false
+}
+
+// fieldTag reads an envelope field tag, pushed as OP_0, OP_1..OP_16 or a one-byte push
+func fieldTag(opcode byte, data []byte) (int, bool) {
+	switch {
+	case opcode == txscript.OP_0:
+		return fieldBody, true
+	case opcode >= txscript.OP_1 && opcode <= txscript.OP_16:
+		return int(opcode-txscript.OP_1) + 1, true
+	case len(data) == 1:
+		return int(data[0]), true
+	}
+	return 0, false
+}
+
+// push returns the smallest push of data
+func push(data []byte) []byte {
+	var buf bytes.Buffer
+	switch {
+	case len(data) == 0:
+		buf.WriteByte(txscript.OP_0)
+	case len(data) <= 75:
+		buf.WriteByte(byte(len(data)))
+	case len(data) <= 0xff:
+		buf.WriteByte(txscript.OP_PUSHDATA1)
+		buf.WriteByte(byte(len(data)))
+	case len(data) <= 0xffff:
+		buf.WriteByte(txscript.OP_PUSHDATA2)
+		binary.Write(&buf, binary.LittleEndian, uint16(len(data)))
+	default:
+		buf.WriteByte(txscript.OP_PUSHDATA4)
+		binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	}
+	buf.Write(data)
+	return buf.Bytes()
+}
@@ -0,0 +1,116 @@
+package signing
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+)
+
+// Client talks to a signing server over mutual TLS
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for a signing server base URL. The TLS config
+// should present a client certificate, see ClientTLSConfig.
+func NewClient(baseURL string, tlsConfig *tls.Config) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{
+			Timeout:   5 * time.Minute,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}
+}
+
+// PublicKey returns the public key at a key path
+func (c *Client) PublicKey(keyPath string) (*btcec.PublicKey, error) {
+	var response publicKeyResponse
+	if err := c.post(PublicKeyPath, publicKeyRequest{KeyPath: keyPath}, &response); err != nil {
+		return nil, err
+	}
+
+	data, err := hex.DecodeString(response.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key hex: %v", err)
+	}
+	publicKey, err := btcec.ParsePubKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %v", err)
+	}
+	return publicKey, nil
+}
+
+// Signer returns a signer for the key at a key path
+func (c *Client) Signer(keyPath string) (*wallet.RemoteSigner, error) {
+	publicKey, err := c.PublicKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.NewRemoteSigner(c, keyPath, publicKey), nil
+}
+
+// SignHash implements wallet.SigningService by sending a bare sighash, which
+// servers refuse unless configured with AllowSighashOnly
+func (c *Client) SignHash(keyPath string, sighash []byte) ([]byte, error) {
+	return c.sign(signRequest{KeyPath: keyPath, Sighash: hex.EncodeToString(sighash)})
+}
+
+// SignPreimage implements wallet.PreimageSigningService
+func (c *Client) SignPreimage(keyPath string, preimage, outputs []byte) ([]byte, error) {
+	return c.sign(signRequest{KeyPath: keyPath, Preimage: hex.EncodeToString(preimage), Outputs: hex.EncodeToString(outputs)})
+}
+
+// sign sends a sign request and returns the DER signature. The caller checks
+// it against the hash and public key.
+func (c *Client) sign(request signRequest) ([]byte, error) {
+	var response signResponse
+	if err := c.post(SignPath, request, &response); err != nil {
+		return nil, err
+	}
+
+	der, err := hex.DecodeString(response.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature hex: %v", err)
+	}
+	return der, nil
+}
+
+// post sends a request to an endpoint and decodes the response
+func (c *Client) post(endpoint string, request, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %v", err)
+	}
+
+	resp, err := c.httpClient.Post(c.baseURL+endpoint, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var failure errorResponse
+		respBody, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(respBody, &failure) == nil && failure.Error != "" {
+			return fmt.Errorf("signing server returned status %d: %s", resp.StatusCode, failure.Error)
+		}
+		return fmt.Errorf("signing server returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
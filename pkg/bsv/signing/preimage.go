@@ -0,0 +1,136 @@
+package signing
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Preimage is a decoded FORKID sighash preimage, the data a signature
+// commits to
+type Preimage struct {
+	Version      int32
+	HashPrevouts [32]byte
+	HashSequence [32]byte
+	OutPoint     wire.OutPoint
+	ScriptCode   []byte
+	Amount       int64
+	Sequence     uint32
+	HashOutputs  [32]byte
+	LockTime     uint32
+	SighashType  uint32
+}
+
+// ParsePreimage decodes a sighash preimage as returned by
+// transaction.SignaturePreimage
+func ParsePreimage(data []byte) (*Preimage, error) {
+	r := bytes.NewReader(data)
+	p := &Preimage{}
+
+	fields := []interface{}{&p.Version, &p.HashPrevouts, &p.HashSequence, &p.OutPoint.Hash, &p.OutPoint.Index}
+	for _, field := range fields {
+		if err := binary.Read(r, binary.LittleEndian, field); err != nil {
+			return nil, fmt.Errorf("preimage is truncated: %v", err)
+		}
+	}
+
+	scriptCode, err := wire.ReadVarBytes(r, 0, uint32(len(data)), "scriptCode")
+	if err != nil {
+		return nil, fmt.Errorf("invalid preimage script code: %v", err)
+	}
+	p.ScriptCode = scriptCode
+
+	fields = []interface{}{&p.Amount, &p.Sequence, &p.HashOutputs, &p.LockTime, &p.SighashType}
+	for _, field := range fields {
+		if err := binary.Read(r, binary.LittleEndian, field); err != nil {
+			return nil, fmt.Errorf("preimage is truncated: %v", err)
+		}
+	}
+
+	if _, err := r.ReadByte(); err != io.EOF {
+		return nil, fmt.Errorf("preimage has %d trailing bytes", r.Len()+1)
+	}
+	if p.SighashType&0x40 == 0 {
+		return nil, fmt.Errorf("preimage sighash type %#x has no FORKID", p.SighashType)
+	}
+
+	return p, nil
+}
+
+// String formats the preimage for display to whoever approves a signature
+func (p *Preimage) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "version:       %d\n", p.Version)
+	fmt.Fprintf(&b, "outpoint:      %s\n", p.OutPoint.String())
+	fmt.Fprintf(&b, "amount:        %d satoshis\n", p.Amount)
+	fmt.Fprintf(&b, "scriptCode:    %s\n", hex.EncodeToString(p.ScriptCode))
+	fmt.Fprintf(&b, "sequence:      %#08x\n", p.Sequence)
+	fmt.Fprintf(&b, "locktime:      %d\n", p.LockTime)
+	fmt.Fprintf(&b, "sighash type:  %#02x\n", p.SighashType)
+	fmt.Fprintf(&b, "hashPrevouts:  %s\n", hex.EncodeToString(p.HashPrevouts[:]))
+	fmt.Fprintf(&b, "hashSequence:  %s\n", hex.EncodeToString(p.HashSequence[:]))
+	fmt.Fprintf(&b, "hashOutputs:   %s\n", hex.EncodeToString(p.HashOutputs[:]))
+	return b.String()
+}
+
+// Output is a transaction output a signature commits to
+type Output struct {
+	Value  int64
+	Script []byte
+
+	// Address is the output's address, or empty for scripts that do not pay
+	// exactly one address
+	Address string
+}
+
+// ParseOutputs decodes serialized outputs, as returned by
+// transaction.SignatureOutputs, and checks that their double SHA-256 is the
+// preimage's hashOutputs. Addresses are encoded for network.
+func ParseOutputs(data []byte, preimage *Preimage, network *chaincfg.Params) ([]Output, error) {
+	if len(data) == 0 {
+		if preimage.HashOutputs != [32]byte{} {
+			return nil, fmt.Errorf("the outputs the preimage commits to are required")
+		}
+		return nil, nil
+	}
+	if chainhash.DoubleHashH(data) != chainhash.Hash(preimage.HashOutputs) {
+		return nil, fmt.Errorf("outputs do not match the preimage's hashOutputs")
+	}
+
+	r := bytes.NewReader(data)
+	var outputs []Output
+	for r.Len() > 0 {
+		var output Output
+		if err := binary.Read(r, binary.LittleEndian, &output.Value); err != nil {
+			return nil, fmt.Errorf("outputs are truncated: %v", err)
+		}
+		script, err := wire.ReadVarBytes(r, 0, uint32(len(data)), "pkScript")
+		if err != nil {
+			return nil, fmt.Errorf("invalid output script: %v", err)
+		}
+		output.Script = script
+
+		if _, addresses, _, err := txscript.ExtractPkScriptAddrs(script, network); err == nil && len(addresses) == 1 {
+			output.Address = addresses[0].EncodeAddress()
+		}
+		outputs = append(outputs, output)
+	}
+
+	return outputs, nil
+}
+
+// String formats the output for display to whoever approves a signature
+func (o Output) String() string {
+	if o.Address != "" {
+		return fmt.Sprintf("%d satoshis to %s", o.Value, o.Address)
+	}
+	return fmt.Sprintf("%d satoshis to script %s", o.Value, hex.EncodeToString(o.Script))
+}
@@ -0,0 +1,54 @@
+// Package signing is a small JSON-over-HTTPS protocol for signing with keys
+// held in another process, such as one fronting an HSM.
+//
+// The client POSTs to two endpoints:
+//
+//	/v1/publickey  {"keyPath"}                          -> {"publicKey"}
+//	/v1/sign       {"keyPath", "preimage", "outputs"}   -> {"signature"}
+//	/v1/sign       {"keyPath", "sighash"}               -> {"signature"}
+//
+// Binary values are hex. The signature is DER-encoded without a sighash type
+// byte. Failures return a non-200 status with {"error"}. Clients send the
+// sighash preimage, and the serialized outputs its hashOutputs commits to, so
+// the server can decode and approve what it signs.
+package signing
+
+import "errors"
+
+// Protocol endpoints
+const (
+	PublicKeyPath = "/v1/publickey"
+	SignPath      = "/v1/sign"
+)
+
+// ErrUnknownKey is returned by a KeyResolver for a path it has no key for
+var ErrUnknownKey = errors.New("unknown key path")
+
+// publicKeyRequest asks for the public key at a path
+type publicKeyRequest struct {
+	KeyPath string `json:"keyPath"`
+}
+
+// publicKeyResponse is a compressed public key
+type publicKeyResponse struct {
+	PublicKey string `json:"publicKey"`
+}
+
+// signRequest asks for a signature over a preimage and its outputs, or a
+// bare sighash
+type signRequest struct {
+	KeyPath  string `json:"keyPath"`
+	Preimage string `json:"preimage,omitempty"`
+	Outputs  string `json:"outputs,omitempty"`
+	Sighash  string `json:"sighash,omitempty"`
+}
+
+// signResponse is a DER-encoded signature
+type signResponse struct {
+	Signature string `json:"signature"`
+}
+
+// errorResponse describes a failed request
+type errorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,332 @@
+package signing
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+)
+
+// maxRequestSize bounds request bodies; preimages carry one script code
+const maxRequestSize = 1 << 20
+
+// KeyResolver finds the signer for a key path
+type KeyResolver interface {
+	// Signer returns the signer at keyPath, or an error wrapping
+	// ErrUnknownKey
+	Signer(keyPath string) (wallet.Signer, error)
+}
+
+// StaticKeys resolves key paths from a fixed map
+type StaticKeys map[string]wallet.Signer
+
+// Signer implements KeyResolver
+func (k StaticKeys) Signer(keyPath string) (wallet.Signer, error) {
+	signer, ok := k[keyPath]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyPath)
+	}
+	return signer, nil
+}
+
+// HDKeys resolves derivation paths, such as m/44'/236'/0'/0/5, of an HD
+// account
+type HDKeys struct {
+	Account *wallet.HDAccount
+}
+
+// Signer implements KeyResolver
+func (k HDKeys) Signer(keyPath string) (wallet.Signer, error) {
+	chain, index, err := k.Account.ParsePath(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownKey, err)
+	}
+	return wallet.NewHDSigner(k.Account, chain, index)
+}
+
+// SignRequest is a signature awaiting approval
+type SignRequest struct {
+	KeyPath string
+
+	// Preimage is the decoded preimage, or nil for a bare sighash
+	Preimage *Preimage
+
+	// Outputs are the outputs the preimage commits to, checked against its
+	// hashOutputs
+	Outputs []Output
+
+	// Sighash is the hash to be signed
+	Sighash []byte
+}
+
+// Approver decides whether a signature may be made
+type Approver interface {
+	// Approve returns nil to allow the signature, or the reason to refuse it
+	Approve(request *SignRequest) error
+}
+
+// ApproverFunc adapts a function to an Approver
+type ApproverFunc func(request *SignRequest) error
+
+// Approve implements Approver
+func (f ApproverFunc) Approve(request *SignRequest) error {
+	return f(request)
+}
+
+// PromptApprover shows each request on out and waits for an operator to
+// answer y or n on in. Requests are prompted one at a time.
+func PromptApprover(in io.Reader, out io.Writer) Approver {
+	reader := bufio.NewReader(in)
+	var mutex sync.Mutex
+
+	return ApproverFunc(func(request *SignRequest) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		fmt.Fprintf(out, "Signature requested for %s\n", request.KeyPath)
+		if request.Preimage != nil {
+			fmt.Fprint(out, request.Preimage.String())
+			if len(request.Outputs) == 0 {
+				fmt.Fprintln(out, "outputs:       none signed")
+			}
+			for i, output := range request.Outputs {
+				fmt.Fprintf(out, "%-15s%s\n", fmt.Sprintf("output %d:", i), output)
+			}
+		} else {
+			fmt.Fprintln(out, "WARNING: bare sighash, the transaction cannot be shown")
+		}
+		fmt.Fprintf(out, "sighash:       %s\n", hex.EncodeToString(request.Sighash))
+		fmt.Fprint(out, "Approve? [y/N] ")
+
+		answer, err := reader.ReadString('\n')
+		if err != nil && answer == "" {
+			return fmt.Errorf("no answer from operator: %v", err)
+		}
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			return fmt.Errorf("operator refused")
+		}
+		return nil
+	})
+}
+
+// ServerConfig configures a signing server
+type ServerConfig struct {
+	// Keys resolves key paths to signers
+	Keys KeyResolver
+
+	// AllowedPaths are path.Match patterns, such as m/44'/236'/0'/*/*, of the
+	// key paths clients may use. No patterns allow no paths.
+	AllowedPaths []string
+
+	// Approver, when set, must approve every signature
+	Approver Approver
+
+	// AllowSighashOnly accepts bare sighashes, which cannot be displayed or
+	// checked. Preimages are required by default.
+	AllowSighashOnly bool
+
+	// Network encodes the output addresses shown for approval. Defaults to
+	// mainnet.
+	Network *chaincfg.Params
+}
+
+// Server serves the signing protocol for the keys of a KeyResolver. It only
+// answers requests made over TLS with a verified client certificate.
+type Server struct {
+	config ServerConfig
+	mux    *http.ServeMux
+}
+
+// NewServer creates a signing server
+func NewServer(config ServerConfig) (*Server, error) {
+	if config.Keys == nil {
+		return nil, fmt.Errorf("signing server needs a key resolver")
+	}
+	for _, pattern := range config.AllowedPaths {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid allowed path %q: %v", pattern, err)
+		}
+	}
+
+	if config.Network == nil {
+		config.Network = &chaincfg.MainNetParams
+	}
+
+	s := &Server{config: config, mux: http.NewServeMux()}
+	s.mux.HandleFunc(PublicKeyPath, s.handlePublicKey)
+	s.mux.HandleFunc(SignPath, s.handleSign)
+	return s, nil
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		writeError(w, http.StatusUnauthorized, "a verified client certificate is required")
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize)
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServeTLS serves on addr. The TLS config must require and verify
+// client certificates, see ServerTLSConfig.
+func (s *Server) ListenAndServeTLS(addr string, tlsConfig *tls.Config) error {
+	if tlsConfig == nil || tlsConfig.ClientAuth != tls.RequireAndVerifyClientCert {
+		return fmt.Errorf("signing server requires mutual TLS")
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return server.ListenAndServeTLS("", "")
+}
+
+// allowed reports whether a key path matches an allowed pattern
+func (s *Server) allowed(keyPath string) bool {
+	for _, pattern := range s.config.AllowedPaths {
+		if matched, _ := path.Match(pattern, keyPath); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the signer for an allowed key path, writing the error
+// response if there is none
+func (s *Server) resolve(w http.ResponseWriter, keyPath string) (wallet.Signer, bool) {
+	if !s.allowed(keyPath) {
+		writeError(w, http.StatusForbidden, fmt.Sprintf("key path %s is not allowed", keyPath))
+		return nil, false
+	}
+
+	signer, err := s.config.Keys.Signer(keyPath)
+	if errors.Is(err, ErrUnknownKey) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	return signer, true
+}
+
+func (s *Server) handlePublicKey(w http.ResponseWriter, r *http.Request) {
+	var request publicKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+
+	signer, ok := s.resolve(w, request.KeyPath)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, publicKeyResponse{PublicKey: hex.EncodeToString(signer.PublicKey().SerializeCompressed())})
+}
+
+func (s *Server) handleSign(w http.ResponseWriter, r *http.Request) {
+	var request signRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+
+	approval, err := s.signRequest(&request)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	signer, ok := s.resolve(w, request.KeyPath)
+	if !ok {
+		return
+	}
+
+	if s.config.Approver != nil {
+		if err := s.config.Approver.Approve(approval); err != nil {
+			writeError(w, http.StatusForbidden, fmt.Sprintf("signature not approved: %v", err))
+			return
+		}
+	}
+
+	signature, err := signer.Sign(approval.Sighash)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to sign: %v", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, signResponse{Signature: hex.EncodeToString(signature)})
+}
+
+// signRequest decodes the preimage or sighash of a request
+func (s *Server) signRequest(request *signRequest) (*SignRequest, error) {
+	switch {
+	case request.Sighash != "" && (request.Preimage != "" || request.Outputs != ""):
+		return nil, fmt.Errorf("send either a preimage or a sighash, not both")
+
+	case request.Preimage != "":
+		data, err := hex.DecodeString(request.Preimage)
+		if err != nil {
+			return nil, fmt.Errorf("invalid preimage hex: %v", err)
+		}
+		preimage, err := ParsePreimage(data)
+		if err != nil {
+			return nil, err
+		}
+		outputData, err := hex.DecodeString(request.Outputs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid outputs hex: %v", err)
+		}
+		outputs, err := ParseOutputs(outputData, preimage, s.config.Network)
+		if err != nil {
+			return nil, err
+		}
+		return &SignRequest{KeyPath: request.KeyPath, Preimage: preimage, Outputs: outputs, Sighash: chainhash.DoubleHashB(data)}, nil
+
+	case request.Sighash != "":
+		if !s.config.AllowSighashOnly {
+			return nil, fmt.Errorf("a preimage is required")
+		}
+		sighash, err := hex.DecodeString(request.Sighash)
+		if err != nil || len(sighash) != 32 {
+			return nil, fmt.Errorf("sighash must be 32 hex-encoded bytes")
+		}
+		return &SignRequest{KeyPath: request.KeyPath, Sighash: sighash}, nil
+
+	default:
+		return nil, fmt.Errorf("a preimage is required")
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
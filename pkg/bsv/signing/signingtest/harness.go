@@ -0,0 +1,149 @@
+// Package signingtest runs a signing server in-process over mutual TLS, with
+// throwaway certificates, so signing clients can be tested without an HSM
+package signingtest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"time"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/signing"
+)
+
+// Harness is a signing server listening on 127.0.0.1
+type Harness struct {
+	// URL is the server's base URL
+	URL string
+
+	// Client is a signing client with a trusted client certificate
+	Client *signing.Client
+
+	server    *httptest.Server
+	serverCAs *x509.CertPool
+}
+
+// NewHarness starts a signing server with a config. Call Close when done.
+func NewHarness(config signing.ServerConfig) (*Harness, error) {
+	handler, err := signing.NewServer(config)
+	if err != nil {
+		return nil, err
+	}
+
+	ca, err := newCA("signing test CA")
+	if err != nil {
+		return nil, err
+	}
+	serverCert, err := ca.issue("signing server", x509.ExtKeyUsageServerAuth)
+	if err != nil {
+		return nil, err
+	}
+	clientCert, err := ca.issue("signing client", x509.ExtKeyUsageClientAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	server := httptest.NewUnstartedServer(handler)
+	server.TLS = signing.ServerTLSConfig(serverCert, ca.pool)
+	server.StartTLS()
+
+	return &Harness{
+		URL:       server.URL,
+		Client:    signing.NewClient(server.URL, signing.ClientTLSConfig(clientCert, ca.pool)),
+		server:    server,
+		serverCAs: ca.pool,
+	}, nil
+}
+
+// UntrustedClient returns a client whose certificate is issued by a CA the
+// server does not trust
+func (h *Harness) UntrustedClient() (*signing.Client, error) {
+	ca, err := newCA("untrusted CA")
+	if err != nil {
+		return nil, err
+	}
+	clientCert, err := ca.issue("untrusted client", x509.ExtKeyUsageClientAuth)
+	if err != nil {
+		return nil, err
+	}
+	return signing.NewClient(h.URL, signing.ClientTLSConfig(clientCert, h.serverCAs)), nil
+}
+
+// AnonymousClient returns a client that trusts the server but presents no
+// certificate
+func (h *Harness) AnonymousClient() *signing.Client {
+	return signing.NewClient(h.URL, &tls.Config{RootCAs: h.serverCAs, MinVersion: tls.VersionTLS12})
+}
+
+// Close stops the server
+func (h *Harness) Close() {
+	h.server.Close()
+}
+
+// certificateAuthority issues certificates for the harness
+type certificateAuthority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newCA(name string) (*certificateAuthority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CA key: %v", err)
+	}
+
+	template := certificateTemplate(name)
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CA certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA certificate: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &certificateAuthority{cert: cert, key: key, pool: pool}, nil
+}
+
+// issue creates a certificate for 127.0.0.1 with an extended key usage
+func (ca *certificateAuthority) issue(name string, usage x509.ExtKeyUsage) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to generate key: %v", err)
+	}
+
+	template := certificateTemplate(name)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+	template.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1)}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to create certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+func certificateTemplate(name string) *x509.Certificate {
+	serial, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 62))
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+}
@@ -0,0 +1,66 @@
+package signing
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// ServerTLSConfig returns a TLS config for a signing server that requires
+// client certificates issued by clientCAs
+func ServerTLSConfig(certificate tls.Certificate, clientCAs *x509.CertPool) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+// ClientTLSConfig returns a TLS config for a signing client that presents
+// certificate and trusts servers issued by rootCAs
+func ClientTLSConfig(certificate tls.Certificate, rootCAs *x509.CertPool) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		RootCAs:      rootCAs,
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+// LoadServerTLSConfig loads a server TLS config from PEM files
+func LoadServerTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	certificate, pool, err := loadTLSFiles(certFile, keyFile, clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	return ServerTLSConfig(certificate, pool), nil
+}
+
+// LoadClientTLSConfig loads a client TLS config from PEM files
+func LoadClientTLSConfig(certFile, keyFile, rootCAFile string) (*tls.Config, error) {
+	certificate, pool, err := loadTLSFiles(certFile, keyFile, rootCAFile)
+	if err != nil {
+		return nil, err
+	}
+	return ClientTLSConfig(certificate, pool), nil
+}
+
+// loadTLSFiles loads a key pair and a CA bundle from PEM files
+func loadTLSFiles(certFile, keyFile, caFile string) (tls.Certificate, *x509.CertPool, error) {
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("failed to load certificate: %v", err)
+	}
+
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("failed to read CA bundle: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return tls.Certificate{}, nil, fmt.Errorf("no certificates in CA bundle %s", caFile)
+	}
+
+	return certificate, pool, nil
+}
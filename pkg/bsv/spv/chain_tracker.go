@@ -0,0 +1,17 @@
+package spv
+
+import (
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// ChainTracker confirms merkle roots against trusted block headers
+type ChainTracker interface {
+	// IsValidRootForHeight reports whether root is the merkle root of the
+	// block header at height
+	IsValidRootForHeight(root *chainhash.Hash, height uint32) (bool, error)
+}
+
+// MerklePathProvider looks up the merkle path proving a mined transaction
+type MerklePathProvider interface {
+	GetMerklePath(txid string) (*MerklePath, error)
+}
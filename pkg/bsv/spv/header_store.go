@@ -0,0 +1,422 @@
+package spv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// CheckProofOfWork checks that a header's hash meets the target in its bits
+// and that the target is within the network's proof-of-work limit. It does not
+// check that the bits follow the difficulty adjustment rules; header stores
+// do that.
+func CheckProofOfWork(header *wire.BlockHeader, params *chaincfg.Params) error {
+	target := blockchain.CompactToBig(header.Bits)
+	if target.Sign() <= 0 {
+		return fmt.Errorf("block target %064x is not positive", target)
+	}
+
+	if target.Cmp(params.PowLimit) > 0 {
+		return fmt.Errorf("block target %064x is above the proof-of-work limit", target)
+	}
+
+	hash := header.BlockHash()
+	if blockchain.HashToBig(&hash).Cmp(target) > 0 {
+		return fmt.Errorf("block hash %s is above target %064x", hash, target)
+	}
+
+	return nil
+}
+
+// daaWindow is the number of blocks the difficulty adjustment algorithm
+// averages work and time over
+const daaWindow = 144
+
+// daaActivationHeights are the heights from which the difficulty adjustment
+// algorithm sets the bits of the next header. Networks not listed use it from
+// their checkpoint.
+var daaActivationHeights = map[wire.BitcoinNet]uint32{
+	wire.MainNet:  504031,
+	wire.TestNet3: 1188697,
+}
+
+// headerNode is a header with its height and the work of the chain ending in it
+type headerNode struct {
+	header wire.BlockHeader
+	hash   chainhash.Hash
+	height uint32
+	work   *big.Int
+	parent *headerNode
+}
+
+// ancestor returns the node's ancestor at a height
+func (n *headerNode) ancestor(height uint32) *headerNode {
+	for n != nil && n.height > height {
+		n = n.parent
+	}
+	return n
+}
+
+// MemoryHeaderStore is a ChainTracker backed by block headers held in memory.
+// It starts from a trusted checkpoint and accepts headers with valid
+// proof-of-work and difficulty that link to any known header, following the
+// chain with the most cumulative work.
+type MemoryHeaderStore struct {
+	params           *chaincfg.Params
+	baseHeight       uint32
+	checkpointTarget *big.Int
+	nodes            map[chainhash.Hash]*headerNode
+	best             []*headerNode
+	mutex            sync.RWMutex
+}
+
+// NewMemoryHeaderStore creates a header store starting at a checkpoint header
+func NewMemoryHeaderStore(params *chaincfg.Params, checkpointHeight uint32, checkpoint *wire.BlockHeader) (*MemoryHeaderStore, error) {
+	if err := CheckProofOfWork(checkpoint, params); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint: %v", err)
+	}
+
+	node := &headerNode{
+		header: *checkpoint,
+		hash:   checkpoint.BlockHash(),
+		height: checkpointHeight,
+		work:   blockchain.CalcWork(checkpoint.Bits),
+	}
+	return &MemoryHeaderStore{
+		params:           params,
+		baseHeight:       checkpointHeight,
+		checkpointTarget: blockchain.CompactToBig(checkpoint.Bits),
+		nodes:            map[chainhash.Hash]*headerNode{node.hash: node},
+		best:             []*headerNode{node},
+	}, nil
+}
+
+// AddHeader adds a header that links to a known header
+func (s *MemoryHeaderStore) AddHeader(header *wire.BlockHeader) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, err := s.addHeader(header)
+	return err
+}
+
+// AddHeaders adds several headers in order, stopping at the first invalid one
+func (s *MemoryHeaderStore) AddHeaders(headers []*wire.BlockHeader) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, header := range headers {
+		if _, err := s.addHeader(header); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addHeader validates and stores a header, switching the best chain when the
+// header's branch has more work. It reports whether the header was new.
+func (s *MemoryHeaderStore) addHeader(header *wire.BlockHeader) (bool, error) {
+	hash := header.BlockHash()
+	if _, ok := s.nodes[hash]; ok {
+		return false, nil
+	}
+
+	parent, ok := s.nodes[header.PrevBlock]
+	if !ok {
+		return false, fmt.Errorf("header %s does not link to a known header", hash)
+	}
+	height := parent.height + 1
+
+	if err := CheckProofOfWork(header, s.params); err != nil {
+		return false, fmt.Errorf("header at height %d: %v", height, err)
+	}
+	if err := s.checkDifficulty(parent, header); err != nil {
+		return false, fmt.Errorf("header at height %d: %v", height, err)
+	}
+
+	node := &headerNode{
+		header: *header,
+		hash:   hash,
+		height: height,
+		work:   new(big.Int).Add(parent.work, blockchain.CalcWork(header.Bits)),
+		parent: parent,
+	}
+	s.nodes[hash] = node
+
+	if node.work.Cmp(s.best[len(s.best)-1].work) > 0 {
+		s.setTip(node)
+	}
+	return true, nil
+}
+
+// setTip makes the chain ending in a node the best chain. An extension of the
+// tip is appended; on a reorg the best chain is replaced from the fork point.
+func (s *MemoryHeaderStore) setTip(node *headerNode) {
+	if node.parent != nil && node.parent == s.best[len(s.best)-1] {
+		s.best = append(s.best, node)
+		return
+	}
+
+	var branch []*headerNode
+	n := node
+	for !s.onBestChain(n) {
+		branch = append(branch, n)
+		n = n.parent
+	}
+
+	s.best = s.best[:n.height-s.baseHeight+1]
+	for i := len(branch) - 1; i >= 0; i-- {
+		s.best = append(s.best, branch[i])
+	}
+}
+
+// onBestChain reports whether a node is on the best chain
+func (s *MemoryHeaderStore) onBestChain(node *headerNode) bool {
+	index := node.height - s.baseHeight
+	return index < uint32(len(s.best)) && s.best[index] == node
+}
+
+// rollback restores the best chain ending in a previous tip and forgets
+// headers added since
+func (s *MemoryHeaderStore) rollback(tip *headerNode, hashes []chainhash.Hash) {
+	s.setTip(tip)
+	for _, hash := range hashes {
+		delete(s.nodes, hash)
+	}
+}
+
+// checkDifficulty checks a header's bits against the difficulty the network
+// requires after its parent. Until the store holds enough headers to run the
+// difficulty adjustment algorithm, a header may not be easier than the
+// checkpoint.
+func (s *MemoryHeaderStore) checkDifficulty(parent *headerNode, header *wire.BlockHeader) error {
+	spacing := int64(s.params.TargetTimePerBlock / time.Second)
+
+	// Test networks allow a minimum difficulty block after a long gap
+	if s.params.ReduceMinDifficulty && header.Timestamp.Unix() > parent.header.Timestamp.Unix()+2*spacing {
+		return expectBits(header, s.params.PowLimitBits)
+	}
+
+	// Regression test networks never retarget
+	if s.params.Net == wire.TestNet {
+		return expectBits(header, parent.header.Bits)
+	}
+
+	if parent.height >= daaActivationHeights[s.params.Net] && parent.height >= s.baseHeight+daaWindow+2 {
+		return expectBits(header, s.nextBits(parent, spacing))
+	}
+
+	if blockchain.CompactToBig(header.Bits).Cmp(s.checkpointTarget) > 0 {
+		return fmt.Errorf("bits %08x are easier than the checkpoint's", header.Bits)
+	}
+	return nil
+}
+
+// nextBits runs the difficulty adjustment algorithm: the target follows the
+// work done over the last 144 blocks, measured between the median-time headers
+// of the first and last three.
+func (s *MemoryHeaderStore) nextBits(parent *headerNode, spacing int64) uint32 {
+	last := suitableNode(parent)
+	first := suitableNode(parent.ancestor(parent.height - daaWindow))
+
+	timespan := last.header.Timestamp.Unix() - first.header.Timestamp.Unix()
+	if timespan > 288*spacing {
+		timespan = 288 * spacing
+	} else if timespan < 72*spacing {
+		timespan = 72 * spacing
+	}
+
+	work := new(big.Int).Sub(last.work, first.work)
+	work.Mul(work, big.NewInt(spacing))
+	work.Div(work, big.NewInt(timespan))
+
+	// target = (2^256 - work) / work
+	target := new(big.Int).Lsh(big.NewInt(1), 256)
+	target.Sub(target, work)
+	target.Div(target, work)
+	if target.Cmp(s.params.PowLimit) > 0 {
+		target = s.params.PowLimit
+	}
+	return blockchain.BigToCompact(target)
+}
+
+// suitableNode returns the node with the median timestamp of a node and its
+// two parents
+func suitableNode(node *headerNode) *headerNode {
+	nodes := []*headerNode{node.parent.parent, node.parent, node}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].header.Timestamp.Before(nodes[j].header.Timestamp)
+	})
+	return nodes[1]
+}
+
+// expectBits checks a header's bits against the required bits
+func expectBits(header *wire.BlockHeader, bits uint32) error {
+	if header.Bits != bits {
+		return fmt.Errorf("bits %08x do not match the required %08x", header.Bits, bits)
+	}
+	return nil
+}
+
+// Header returns the header at a height on the best chain
+func (s *MemoryHeaderStore) Header(height uint32) (*wire.BlockHeader, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if height < s.baseHeight || height-s.baseHeight >= uint32(len(s.best)) {
+		return nil, fmt.Errorf("no header at height %d", height)
+	}
+
+	header := s.best[height-s.baseHeight].header
+	return &header, nil
+}
+
+// TipHeight returns the height of the tip of the best chain
+func (s *MemoryHeaderStore) TipHeight() uint32 {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.baseHeight + uint32(len(s.best)) - 1
+}
+
+// IsValidRootForHeight implements ChainTracker
+func (s *MemoryHeaderStore) IsValidRootForHeight(root *chainhash.Hash, height uint32) (bool, error) {
+	header, err := s.Header(height)
+	if err != nil {
+		return false, err
+	}
+	return header.MerkleRoot.IsEqual(root), nil
+}
+
+// FileHeaderStore is a MemoryHeaderStore persisted to a file. The file holds
+// the checkpoint height as a 4-byte little-endian integer followed by 80-byte
+// headers, starting with the checkpoint, in the order they were accepted.
+// Headers of competing branches are kept so that a reorg survives a restart.
+type FileHeaderStore struct {
+	*MemoryHeaderStore
+	path string
+}
+
+// NewFileHeaderStore opens a header file, creating it from the checkpoint when
+// it does not exist. Headers loaded from an existing file are revalidated.
+func NewFileHeaderStore(path string, params *chaincfg.Params, checkpointHeight uint32, checkpoint *wire.BlockHeader) (*FileHeaderStore, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		store, err := NewMemoryHeaderStore(params, checkpointHeight, checkpoint)
+		if err != nil {
+			return nil, err
+		}
+
+		var buf bytes.Buffer
+		binary.Write(&buf, binary.LittleEndian, checkpointHeight)
+		checkpoint.Serialize(&buf)
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			return nil, fmt.Errorf("failed to create header file: %v", err)
+		}
+
+		return &FileHeaderStore{MemoryHeaderStore: store, path: path}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header file: %v", err)
+	}
+
+	r := bytes.NewReader(data)
+	var height uint32
+	if err := binary.Read(r, binary.LittleEndian, &height); err != nil {
+		return nil, fmt.Errorf("failed to read header file: %v", err)
+	}
+
+	var first wire.BlockHeader
+	if err := first.Deserialize(r); err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint from header file: %v", err)
+	}
+
+	if height != checkpointHeight || first.BlockHash() != checkpoint.BlockHash() {
+		return nil, fmt.Errorf("header file starts at a different checkpoint")
+	}
+
+	store, err := NewMemoryHeaderStore(params, height, &first)
+	if err != nil {
+		return nil, err
+	}
+
+	for r.Len() > 0 {
+		var header wire.BlockHeader
+		if err := header.Deserialize(r); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				return nil, fmt.Errorf("header file ends with a partial header")
+			}
+			return nil, fmt.Errorf("failed to read header file: %v", err)
+		}
+
+		if err := store.AddHeader(&header); err != nil {
+			return nil, fmt.Errorf("invalid header in file: %v", err)
+		}
+	}
+
+	return &FileHeaderStore{MemoryHeaderStore: store, path: path}, nil
+}
+
+// AddHeader adds a header and appends it to the file
+func (s *FileHeaderStore) AddHeader(header *wire.BlockHeader) error {
+	return s.AddHeaders([]*wire.BlockHeader{header})
+}
+
+// AddHeaders adds several headers and appends the new, valid ones to the file
+func (s *FileHeaderStore) AddHeaders(headers []*wire.BlockHeader) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tip := s.best[len(s.best)-1]
+	var buf bytes.Buffer
+	var added []chainhash.Hash
+	var addErr error
+	for _, header := range headers {
+		isNew, err := s.addHeader(header)
+		if err != nil {
+			addErr = err
+			break
+		}
+		if isNew {
+			header.Serialize(&buf)
+			added = append(added, header.BlockHash())
+		}
+	}
+
+	// Headers that could not be persisted are forgotten so that adding them
+	// again writes them
+	if buf.Len() > 0 {
+		if err := appendFile(s.path, buf.Bytes()); err != nil {
+			s.rollback(tip, added)
+			return err
+		}
+	}
+
+	return addErr
+}
+
+// appendFile appends data to the header file
+func appendFile(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open header file: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write header file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package spv
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// Confirmation states returned by a block headers service
+const (
+	confirmationConfirmed = "CONFIRMED"
+	confirmationInvalid   = "INVALID"
+)
+
+// HeadersClient is a ChainTracker that asks a block headers service
+// (POST /api/v1/chain/merkleroot/verify) to confirm merkle roots
+type HeadersClient struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// merkleRootVerifyRequest is one root to confirm
+type merkleRootVerifyRequest struct {
+	MerkleRoot  string `json:"merkleRoot"`
+	BlockHeight uint32 `json:"blockHeight"`
+}
+
+// merkleRootVerifyResponse is the result of confirming merkle roots
+type merkleRootVerifyResponse struct {
+	ConfirmationState string `json:"confirmationState"`
+}
+
+// NewHeadersClient creates a chain tracker for a block headers service base URL
+func NewHeadersClient(baseURL string) *HeadersClient {
+	return &HeadersClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// SetAPIKey sets the bearer token sent with requests
+func (c *HeadersClient) SetAPIKey(apiKey string) {
+	c.apiKey = apiKey
+}
+
+// IsValidRootForHeight implements ChainTracker
+func (c *HeadersClient) IsValidRootForHeight(root *chainhash.Hash, height uint32) (bool, error) {
+	body, err := json.Marshal([]merkleRootVerifyRequest{{MerkleRoot: root.String(), BlockHeight: height}})
+	if err != nil {
+		return false, fmt.Errorf("failed to encode request: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", c.baseURL+"/api/v1/chain/merkleroot/verify", bytes.NewBuffer(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "BSV-Enhanced-SDK/1.0.0")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return false, fmt.Errorf("headers service returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result merkleRootVerifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("failed to decode response: %v", err)
+	}
+
+	switch result.ConfirmationState {
+	case confirmationConfirmed:
+		return true, nil
+	case confirmationInvalid:
+		return false, nil
+	default:
+		return false, fmt.Errorf("headers service could not verify root: %s", result.ConfirmationState)
+	}
+}
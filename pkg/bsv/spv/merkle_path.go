@@ -0,0 +1,397 @@
+package spv
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Path element flags in the BUMP (BRC-74) binary format
+const (
+	flagHash      byte = 0x00 // Element is a hash needed to compute the root
+	flagDuplicate byte = 0x01 // Element duplicates its sibling; no hash follows
+	flagTxID      byte = 0x02 // Element is a transaction the path proves
+)
+
+// PathElement is a leaf at one level of a merkle path
+type PathElement struct {
+	Offset    uint64          // Position of the leaf within its level
+	Hash      *chainhash.Hash // Leaf hash; nil when Duplicate is set
+	TxID      bool            // Whether this leaf is a transaction proven by the path
+	Duplicate bool            // Whether this leaf duplicates its sibling
+}
+
+// MerklePath is a BSV Unified Merkle Path (BUMP, BRC-74). Path[0] holds the
+// transactions and their siblings; each following level holds the leaves
+// needed to compute the level above.
+type MerklePath struct {
+	BlockHeight uint32
+	Path        [][]*PathElement
+}
+
+// ParseMerklePath decodes a merkle path from the BUMP binary format
+func ParseMerklePath(data []byte) (*MerklePath, error) {
+	r := bytes.NewReader(data)
+
+	path, err := ReadMerklePath(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%d trailing bytes after merkle path", r.Len())
+	}
+
+	return path, nil
+}
+
+// ParseMerklePathHex decodes a merkle path from hex
+func ParseMerklePathHex(pathHex string) (*MerklePath, error) {
+	data, err := hex.DecodeString(pathHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid merkle path hex: %v", err)
+	}
+	return ParseMerklePath(data)
+}
+
+// NewMerklePath builds the merkle path proving the transaction at index among
+// the ordered txids of a block
+func NewMerklePath(blockHeight uint32, txids []chainhash.Hash, index int) (*MerklePath, error) {
+	if index < 0 || index >= len(txids) {
+		return nil, fmt.Errorf("transaction index %d out of range for %d transactions", index, len(txids))
+	}
+
+	txid := txids[index]
+	path := &MerklePath{BlockHeight: blockHeight}
+
+	// A single-transaction block is proven by the txid alone
+	if len(txids) == 1 {
+		path.Path = [][]*PathElement{{{Offset: 0, Hash: &txid, TxID: true}}}
+		return path, nil
+	}
+
+	level := txids
+	offset := uint64(index)
+	for len(level) > 1 {
+		var elements []*PathElement
+		if len(path.Path) == 0 {
+			elements = append(elements, &PathElement{Offset: offset, Hash: &txid, TxID: true})
+		}
+
+		sibling := &PathElement{Offset: offset ^ 1}
+		if offset^1 < uint64(len(level)) {
+			hash := level[offset^1]
+			sibling.Hash = &hash
+		} else {
+			sibling.Duplicate = true
+		}
+
+		if sibling.Offset < offset {
+			elements = append([]*PathElement{sibling}, elements...)
+		} else {
+			elements = append(elements, sibling)
+		}
+		path.Path = append(path.Path, elements)
+
+		// Hash the level up, duplicating the last hash of odd levels
+		next := make([]chainhash.Hash, (len(level)+1)/2)
+		for i := range next {
+			left := &level[2*i]
+			right := left
+			if 2*i+1 < len(level) {
+				right = &level[2*i+1]
+			}
+			next[i] = hashPair(left, right)
+		}
+
+		level = next
+		offset >>= 1
+	}
+
+	return path, nil
+}
+
+// ReadMerklePath decodes one merkle path from a reader, as embedded in BEEF
+func ReadMerklePath(r io.Reader) (*MerklePath, error) {
+	blockHeight, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read block height: %v", err)
+	}
+
+	var treeHeight [1]byte
+	if _, err := io.ReadFull(r, treeHeight[:]); err != nil {
+		return nil, fmt.Errorf("failed to read tree height: %v", err)
+	}
+
+	if treeHeight[0] == 0 || treeHeight[0] > 64 {
+		return nil, fmt.Errorf("invalid tree height: %d", treeHeight[0])
+	}
+
+	path := &MerklePath{
+		BlockHeight: uint32(blockHeight),
+		Path:        make([][]*PathElement, treeHeight[0]),
+	}
+
+	for level := range path.Path {
+		count, err := wire.ReadVarInt(r, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read leaf count at level %d: %v", level, err)
+		}
+
+		for i := uint64(0); i < count; i++ {
+			offset, err := wire.ReadVarInt(r, 0)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read leaf offset at level %d: %v", level, err)
+			}
+
+			var flags [1]byte
+			if _, err := io.ReadFull(r, flags[:]); err != nil {
+				return nil, fmt.Errorf("failed to read leaf flags at level %d: %v", level, err)
+			}
+
+			element := &PathElement{Offset: offset}
+			switch flags[0] {
+			case flagDuplicate:
+				element.Duplicate = true
+			case flagHash, flagTxID:
+				element.TxID = flags[0] == flagTxID
+				element.Hash = new(chainhash.Hash)
+				if _, err := io.ReadFull(r, element.Hash[:]); err != nil {
+					return nil, fmt.Errorf("failed to read leaf hash at level %d: %v", level, err)
+				}
+			default:
+				return nil, fmt.Errorf("invalid leaf flags %#x at level %d", flags[0], level)
+			}
+
+			path.Path[level] = append(path.Path[level], element)
+		}
+	}
+
+	return path, nil
+}
+
+// Bytes encodes the merkle path in the BUMP binary format
+func (m *MerklePath) Bytes() []byte {
+	var buf bytes.Buffer
+
+	wire.WriteVarInt(&buf, 0, uint64(m.BlockHeight))
+	buf.WriteByte(byte(len(m.Path)))
+
+	for _, level := range m.Path {
+		wire.WriteVarInt(&buf, 0, uint64(len(level)))
+		for _, element := range level {
+			wire.WriteVarInt(&buf, 0, element.Offset)
+			switch {
+			case element.Duplicate:
+				buf.WriteByte(flagDuplicate)
+			case element.TxID:
+				buf.WriteByte(flagTxID)
+				buf.Write(element.Hash[:])
+			default:
+				buf.WriteByte(flagHash)
+				buf.Write(element.Hash[:])
+			}
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// Hex encodes the merkle path in the BUMP binary format as hex
+func (m *MerklePath) Hex() string {
+	return hex.EncodeToString(m.Bytes())
+}
+
+// Contains reports whether the path proves the given transaction
+func (m *MerklePath) Contains(txid *chainhash.Hash) bool {
+	_, ok := m.txOffset(txid)
+	return ok
+}
+
+// ComputeRoot computes the merkle root the path commits the transaction to
+func (m *MerklePath) ComputeRoot(txid *chainhash.Hash) (*chainhash.Hash, error) {
+	offset, ok := m.txOffset(txid)
+	if !ok {
+		return nil, fmt.Errorf("transaction %s is not in the merkle path", txid)
+	}
+
+	// A block with a single transaction has the txid as its root
+	if len(m.Path) == 1 && len(m.Path[0]) == 1 {
+		root := *txid
+		return &root, nil
+	}
+
+	working := *txid
+	for level := range m.Path {
+		sibling, err := m.leafHash(level, offset^1)
+		if err != nil {
+			return nil, err
+		}
+
+		// A missing sibling duplicates the working hash
+		if sibling == nil {
+			sibling = &working
+		}
+
+		if offset%2 == 0 {
+			working = hashPair(&working, sibling)
+		} else {
+			working = hashPair(sibling, &working)
+		}
+		offset >>= 1
+	}
+
+	return &working, nil
+}
+
+// txOffset returns the level 0 offset of a transaction
+func (m *MerklePath) txOffset(txid *chainhash.Hash) (uint64, bool) {
+	if len(m.Path) == 0 {
+		return 0, false
+	}
+
+	for _, element := range m.Path[0] {
+		if element.Hash != nil && element.Hash.IsEqual(txid) {
+			return element.Offset, true
+		}
+	}
+
+	return 0, false
+}
+
+// leafHash returns the hash at a level and offset, computing it from the level
+// below when the path omits it. A nil hash means the leaf is a duplicate.
+func (m *MerklePath) leafHash(level int, offset uint64) (*chainhash.Hash, error) {
+	for _, element := range m.Path[level] {
+		if element.Offset == offset {
+			if element.Duplicate {
+				return nil, nil
+			}
+			return element.Hash, nil
+		}
+	}
+
+	if level == 0 {
+		return nil, fmt.Errorf("merkle path is missing leaf %d at level 0", offset)
+	}
+
+	left, err := m.leafHash(level-1, offset*2)
+	if err != nil {
+		return nil, fmt.Errorf("merkle path is missing leaf %d at level %d", offset, level)
+	}
+	if left == nil {
+		return nil, fmt.Errorf("merkle path is missing leaf %d at level %d", offset, level)
+	}
+
+	right, err := m.leafHash(level-1, offset*2+1)
+	if err != nil {
+		return nil, fmt.Errorf("merkle path is missing leaf %d at level %d", offset, level)
+	}
+	if right == nil {
+		right = left
+	}
+
+	hash := hashPair(left, right)
+	return &hash, nil
+}
+
+// hashPair returns the double SHA-256 of two concatenated hashes
+func hashPair(left, right *chainhash.Hash) chainhash.Hash {
+	var buf [chainhash.HashSize * 2]byte
+	copy(buf[:chainhash.HashSize], left[:])
+	copy(buf[chainhash.HashSize:], right[:])
+	return chainhash.DoubleHashH(buf[:])
+}
+
+// Verify reports whether the path proves the transaction is in a block whose
+// merkle root the chain tracker accepts
+func (m *MerklePath) Verify(txid *chainhash.Hash, tracker ChainTracker) (bool, error) {
+	root, err := m.ComputeRoot(txid)
+	if err != nil {
+		return false, err
+	}
+	return tracker.IsValidRootForHeight(root, m.BlockHeight)
+}
+
+// VerifyHeader reports whether the path proves the transaction is in the block
+// with the given header
+func (m *MerklePath) VerifyHeader(txid *chainhash.Hash, header *wire.BlockHeader) (bool, error) {
+	root, err := m.ComputeRoot(txid)
+	if err != nil {
+		return false, err
+	}
+	return root.IsEqual(&header.MerkleRoot), nil
+}
+
+// Combine merges another path for the same block into this one, so that a
+// single path proves the transactions of both
+func (m *MerklePath) Combine(other *MerklePath) error {
+	if m.BlockHeight != other.BlockHeight {
+		return fmt.Errorf("cannot combine merkle paths for blocks %d and %d", m.BlockHeight, other.BlockHeight)
+	}
+
+	if len(m.Path) != len(other.Path) {
+		return fmt.Errorf("cannot combine merkle paths with tree heights %d and %d", len(m.Path), len(other.Path))
+	}
+
+	root, err := m.anyRoot()
+	if err != nil {
+		return err
+	}
+
+	otherRoot, err := other.anyRoot()
+	if err != nil {
+		return err
+	}
+
+	if !root.IsEqual(otherRoot) {
+		return fmt.Errorf("cannot combine merkle paths with roots %s and %s", root, otherRoot)
+	}
+
+	for level := range m.Path {
+		elements := make(map[uint64]*PathElement, len(m.Path[level])+len(other.Path[level]))
+		for _, element := range m.Path[level] {
+			elements[element.Offset] = element
+		}
+
+		for _, element := range other.Path[level] {
+			existing, exists := elements[element.Offset]
+			if !exists {
+				copied := *element
+				elements[element.Offset] = &copied
+				continue
+			}
+			if element.TxID {
+				existing.TxID = true
+			}
+		}
+
+		merged := make([]*PathElement, 0, len(elements))
+		for _, element := range elements {
+			merged = append(merged, element)
+		}
+		sort.Slice(merged, func(i, j int) bool {
+			return merged[i].Offset < merged[j].Offset
+		})
+		m.Path[level] = merged
+	}
+
+	return nil
+}
+
+// anyRoot computes the root from the first transaction the path proves
+func (m *MerklePath) anyRoot() (*chainhash.Hash, error) {
+	if len(m.Path) > 0 {
+		for _, element := range m.Path[0] {
+			if element.TxID {
+				return m.ComputeRoot(element.Hash)
+			}
+		}
+	}
+	return nil, fmt.Errorf("merkle path proves no transactions")
+}
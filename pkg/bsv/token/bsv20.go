@@ -0,0 +1,52 @@
+package token
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// BSV20 is the original, ticker based BSV-20 protocol. A token is deployed
+// once under a ticker and minted by anyone up to its limit; the ticker is
+// its ID.
+type BSV20 struct{}
+
+// NewBSV20 creates the BSV-20 protocol
+func NewBSV20() *BSV20 {
+	return &BSV20{}
+}
+
+// Name implements Protocol
+func (p *BSV20) Name() string {
+	return "bsv-20"
+}
+
+// OutputValue implements Protocol
+func (p *BSV20) OutputValue() int64 {
+	return 1
+}
+
+// TransferScript implements Protocol
+func (p *BSV20) TransferScript(tokenID string, amount int64, address string, network *chaincfg.Params) ([]byte, error) {
+	if tokenID == "" || strings.TrimSpace(tokenID) != tokenID {
+		return nil, fmt.Errorf("invalid BSV-20 ticker: %q", tokenID)
+	}
+
+	return inscribe(&bsv20Operation{Op: opTransfer, Tick: tokenID}, amount, address, network)
+}
+
+// Detect implements Protocol. Deploy inscriptions hold no tokens.
+func (p *BSV20) Detect(script []byte, txID string, vout uint32) (string, int64, bool) {
+	operation, amount, ok := parseOperation(script)
+	if !ok || operation.Tick == "" || operation.ID != "" {
+		return "", 0, false
+	}
+
+	switch operation.Op {
+	case opTransfer, opMint:
+		return operation.Tick, amount, true
+	}
+
+	return "", 0, false
+}
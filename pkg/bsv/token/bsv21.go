@@ -0,0 +1,167 @@
+package token
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/ordinals"
+)
+
+// BSV20ContentType is the inscription content type of BSV-20 and BSV-21 operations
+const BSV20ContentType = "application/bsv-20"
+
+// BSV-20 and BSV-21 operations
+const (
+	opMint       = "mint"
+	opDeployMint = "deploy+mint"
+	opTransfer   = "transfer"
+)
+
+// bsv20Operation is the JSON inscribed by a BSV-20 or BSV-21 operation
+type bsv20Operation struct {
+	P    string `json:"p"`
+	Op   string `json:"op"`
+	ID   string `json:"id,omitempty"`
+	Tick string `json:"tick,omitempty"`
+	Sym  string `json:"sym,omitempty"`
+	Amt  string `json:"amt"`
+	Dec  string `json:"dec,omitempty"`
+}
+
+// BSV21 is the 1Sat Ordinals BSV-21 fungible token protocol. Token amounts
+// are inscribed as JSON into 1-satoshi P2PKH outputs, and a token is
+// identified by the outpoint that deployed it, "<txid>_<vout>".
+type BSV21 struct{}
+
+// NewBSV21 creates the BSV-21 protocol
+func NewBSV21() *BSV21 {
+	return &BSV21{}
+}
+
+// Name implements Protocol
+func (p *BSV21) Name() string {
+	return "bsv-21"
+}
+
+// OutputValue implements Protocol
+func (p *BSV21) OutputValue() int64 {
+	return 1
+}
+
+// TransferScript implements Protocol
+func (p *BSV21) TransferScript(tokenID string, amount int64, address string, network *chaincfg.Params) ([]byte, error) {
+	if !isTokenID(tokenID) {
+		return nil, fmt.Errorf("invalid BSV-21 token ID: %s", tokenID)
+	}
+
+	return inscribe(&bsv20Operation{Op: opTransfer, ID: tokenID}, amount, address, network)
+}
+
+// DeployMintScript returns the locking script deploying a new token and
+// minting its whole supply to address. The token's ID is the outpoint of the
+// output this script is placed in.
+func (p *BSV21) DeployMintScript(symbol string, amount int64, decimals int, address string, network *chaincfg.Params) ([]byte, error) {
+	if decimals < 0 || decimals > 18 {
+		return nil, fmt.Errorf("decimals must be between 0 and 18, got %d", decimals)
+	}
+
+	return inscribe(&bsv20Operation{Op: opDeployMint, Sym: symbol, Dec: strconv.Itoa(decimals)}, amount, address, network)
+}
+
+// Detect implements Protocol
+func (p *BSV21) Detect(script []byte, txID string, vout uint32) (string, int64, bool) {
+	operation, amount, ok := parseOperation(script)
+	if !ok || operation.Tick != "" {
+		return "", 0, false
+	}
+
+	switch operation.Op {
+	case opTransfer:
+		if !isTokenID(operation.ID) {
+			return "", 0, false
+		}
+		return operation.ID, amount, true
+	case opDeployMint:
+		return fmt.Sprintf("%s_%d", txID, vout), amount, true
+	}
+
+	return "", 0, false
+}
+
+// parseOperation returns the BSV-20 operation inscribed in a locking script
+// and the amount it holds
+func parseOperation(script []byte) (*bsv20Operation, int64, bool) {
+	inscription, _, ok := ordinals.Parse(script)
+	if !ok || inscription.ContentType != BSV20ContentType {
+		return nil, 0, false
+	}
+
+	var operation bsv20Operation
+	if err := json.Unmarshal(inscription.Body, &operation); err != nil || operation.P != "bsv-20" {
+		return nil, 0, false
+	}
+
+	amount, err := strconv.ParseInt(operation.Amt, 10, 64)
+	if err != nil || amount <= 0 {
+		return nil, 0, false
+	}
+
+	return &operation, amount, true
+}
+
+// inscribe returns a 1Sat inscription of a BSV-20 operation owned by address
+func inscribe(operation *bsv20Operation, amount int64, address string, network *chaincfg.Params) ([]byte, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("token amount must be positive")
+	}
+
+	operation.P = "bsv-20"
+	operation.Amt = strconv.FormatInt(amount, 10)
+	body, err := json.Marshal(operation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode BSV-20 operation: %v", err)
+	}
+
+	owner, err := payToAddress(address, network)
+	if err != nil {
+		return nil, err
+	}
+
+	return (&ordinals.Inscription{ContentType: BSV20ContentType, Body: body}).LockingScript(owner)
+}
+
+// isTokenID reports whether id has the form "<txid>_<vout>"
+func isTokenID(id string) bool {
+	txID, vout, found := strings.Cut(id, "_")
+	if !found || len(txID) != 64 {
+		return false
+	}
+
+	if _, err := hex.DecodeString(txID); err != nil {
+		return false
+	}
+
+	_, err := strconv.ParseUint(vout, 10, 32)
+	return err == nil
+}
+
+// payToAddress returns the P2PKH locking script of an address
+func payToAddress(address string, network *chaincfg.Params) ([]byte, error) {
+	decoded, err := btcutil.DecodeAddress(address, network)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %s: %v", address, err)
+	}
+
+	if !decoded.IsForNet(network) {
+		return nil, fmt.Errorf("address %s is not for %s", address, network.Name)
+	}
+
+	return txscript.PayToAddrScript(decoded)
+}
@@ -0,0 +1,23 @@
+// Package token implements the token protocols the SDK sends and detects
+package token
+
+import (
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// Protocol builds and recognises the outputs of a token protocol
+type Protocol interface {
+	// Name identifies the protocol
+	Name() string
+
+	// OutputValue returns the satoshi value of each token output
+	OutputValue() int64
+
+	// TransferScript returns the locking script of an output holding amount
+	// of tokenID for address
+	TransferScript(tokenID string, amount int64, address string, network *chaincfg.Params) ([]byte, error)
+
+	// Detect returns the token ID and amount held by the output txID:vout
+	// with the given locking script, or false when it holds no token
+	Detect(script []byte, txID string, vout uint32) (string, int64, bool)
+}
@@ -0,0 +1,44 @@
+package token
+
+import (
+	"bytes"
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// stasPrefix is the start of the STAS script following its owner's P2PKH
+// script. It splits the signed transaction preimage into its fields.
+var stasPrefix, _ = hex.DecodeString("6976aa607f5f7f7c5e7f7c5d7f7c5c7f7c5b7f7c5a7f7c597f7c587f7c577f7c567f7c557f7c547f7c537f7c527f7c517f7c7e")
+
+// STASTokenID returns the ID of the STAS token held by a locking script: the
+// hex redemption public key hash pushed after its OP_RETURN. STAS tokens are
+// backed one to one by the output's satoshis, so the amount is its value.
+func STASTokenID(script []byte) (string, bool) {
+	if len(script) < 25+len(stasPrefix) || !isP2PKH(script[:25]) || !bytes.HasPrefix(script[25:], stasPrefix) {
+		return "", false
+	}
+
+	tokenizer := txscript.MakeScriptTokenizer(0, script)
+	for tokenizer.Next() {
+		if tokenizer.Opcode() != txscript.OP_RETURN {
+			continue
+		}
+		if !tokenizer.Next() || len(tokenizer.Data()) != 20 {
+			return "", false
+		}
+		return hex.EncodeToString(tokenizer.Data()), true
+	}
+
+	return "", false
+}
+
+// isP2PKH reports whether script is a P2PKH locking script
+func isP2PKH(script []byte) bool {
+	return len(script) == 25 &&
+		script[0] == txscript.OP_DUP &&
+		script[1] == txscript.OP_HASH160 &&
+		script[2] == txscript.OP_DATA_20 &&
+		script[23] == txscript.OP_EQUALVERIFY &&
+		script[24] == txscript.OP_CHECKSIG
+}
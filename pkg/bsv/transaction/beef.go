@@ -0,0 +1,345 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/spv"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// BeefVersion is the BEEF (BRC-62) version marker, serialized as 0100BEEF
+const BeefVersion uint32 = 0xEFBE0001
+
+// Beef is a transaction together with the unconfirmed ancestors and merkle
+// proofs needed to verify it by SPV (BRC-62). Transactions are ordered so that
+// every parent comes before its children; the last one is the subject.
+type Beef struct {
+	BUMPs        []*spv.MerklePath
+	Transactions []*BeefTx
+}
+
+// BeefTx is a transaction in a BEEF
+type BeefTx struct {
+	Tx        *wire.MsgTx
+	BumpIndex int // Index of the proof in BUMPs, or -1 if the transaction is unmined
+}
+
+// NewBeef builds a BEEF for a signed transaction. sourceTxs must include the
+// transaction spent by every input of each unmined transaction, back to mined
+// ancestors, which are proven by one of bumps.
+func NewBeef(tx *wire.MsgTx, sourceTxs []*wire.MsgTx, bumps []*spv.MerklePath) (*Beef, error) {
+	sources := make(map[chainhash.Hash]*wire.MsgTx, len(sourceTxs))
+	for _, source := range sourceTxs {
+		sources[source.TxHash()] = source
+	}
+
+	beef := &Beef{}
+	bumpIndexes := make(map[*spv.MerklePath]int)
+	added := make(map[chainhash.Hash]bool)
+
+	// Add parents before children, stopping at mined transactions
+	var add func(tx *wire.MsgTx) error
+	add = func(tx *wire.MsgTx) error {
+		txid := tx.TxHash()
+		if added[txid] {
+			return nil
+		}
+
+		bumpIndex := -1
+		for _, bump := range bumps {
+			if !bump.Contains(&txid) {
+				continue
+			}
+			index, exists := bumpIndexes[bump]
+			if !exists {
+				index = len(beef.BUMPs)
+				bumpIndexes[bump] = index
+				beef.BUMPs = append(beef.BUMPs, bump)
+			}
+			bumpIndex = index
+			break
+		}
+
+		if bumpIndex < 0 {
+			for i, txIn := range tx.TxIn {
+				source, ok := sources[txIn.PreviousOutPoint.Hash]
+				if !ok {
+					return fmt.Errorf("source transaction %s for input %d of %s is missing", txIn.PreviousOutPoint.Hash, i, txid)
+				}
+				if err := add(source); err != nil {
+					return err
+				}
+			}
+		}
+
+		added[txid] = true
+		beef.Transactions = append(beef.Transactions, &BeefTx{Tx: tx, BumpIndex: bumpIndex})
+		return nil
+	}
+
+	if err := add(tx); err != nil {
+		return nil, err
+	}
+
+	return beef, nil
+}
+
+// ParseBeef decodes a BEEF from its binary form
+func ParseBeef(data []byte) (*Beef, error) {
+	r := bytes.NewReader(data)
+
+	var version uint32
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return nil, fmt.Errorf("failed to read BEEF version: %v", err)
+	}
+
+	if version != BeefVersion {
+		return nil, fmt.Errorf("unsupported BEEF version: %08x", version)
+	}
+
+	bumpCount, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read BUMP count: %v", err)
+	}
+	if bumpCount > uint64(r.Len()) {
+		return nil, fmt.Errorf("BUMP count %d exceeds BEEF size", bumpCount)
+	}
+
+	beef := &Beef{}
+	for i := uint64(0); i < bumpCount; i++ {
+		bump, err := spv.ReadMerklePath(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read BUMP %d: %v", i, err)
+		}
+		beef.BUMPs = append(beef.BUMPs, bump)
+	}
+
+	txCount, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction count: %v", err)
+	}
+	if txCount > uint64(r.Len()) {
+		return nil, fmt.Errorf("transaction count %d exceeds BEEF size", txCount)
+	}
+
+	for i := uint64(0); i < txCount; i++ {
+		tx := wire.NewMsgTx(wire.TxVersion)
+		if err := tx.Deserialize(r); err != nil {
+			return nil, fmt.Errorf("failed to read transaction %d: %v", i, err)
+		}
+
+		var hasBump [1]byte
+		if _, err := io.ReadFull(r, hasBump[:]); err != nil {
+			return nil, fmt.Errorf("failed to read transaction %d: %v", i, err)
+		}
+
+		beefTx := &BeefTx{Tx: tx, BumpIndex: -1}
+		switch hasBump[0] {
+		case 0:
+		case 1:
+			index, err := wire.ReadVarInt(r, 0)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read BUMP index of transaction %d: %v", i, err)
+			}
+			if index >= uint64(len(beef.BUMPs)) {
+				return nil, fmt.Errorf("transaction %d references missing BUMP %d", i, index)
+			}
+			beefTx.BumpIndex = int(index)
+		default:
+			return nil, fmt.Errorf("invalid BUMP flag %#x for transaction %d", hasBump[0], i)
+		}
+
+		beef.Transactions = append(beef.Transactions, beefTx)
+	}
+
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%d trailing bytes after BEEF", r.Len())
+	}
+
+	if len(beef.Transactions) == 0 {
+		return nil, fmt.Errorf("BEEF contains no transactions")
+	}
+
+	return beef, nil
+}
+
+// ParseBeefHex decodes a hex-encoded BEEF
+func ParseBeefHex(beefHex string) (*Beef, error) {
+	data, err := hex.DecodeString(beefHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid BEEF hex: %v", err)
+	}
+	return ParseBeef(data)
+}
+
+// Bytes encodes the BEEF in its binary form
+func (b *Beef) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+
+	binary.Write(&buf, binary.LittleEndian, BeefVersion)
+
+	wire.WriteVarInt(&buf, 0, uint64(len(b.BUMPs)))
+	for _, bump := range b.BUMPs {
+		buf.Write(bump.Bytes())
+	}
+
+	wire.WriteVarInt(&buf, 0, uint64(len(b.Transactions)))
+	for i, beefTx := range b.Transactions {
+		if err := beefTx.Tx.Serialize(&buf); err != nil {
+			return nil, fmt.Errorf("failed to serialize transaction %d: %v", i, err)
+		}
+
+		if beefTx.BumpIndex < 0 {
+			buf.WriteByte(0)
+			continue
+		}
+
+		if beefTx.BumpIndex >= len(b.BUMPs) {
+			return nil, fmt.Errorf("transaction %d references missing BUMP %d", i, beefTx.BumpIndex)
+		}
+
+		buf.WriteByte(1)
+		wire.WriteVarInt(&buf, 0, uint64(beefTx.BumpIndex))
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Hex encodes the BEEF as hex
+func (b *Beef) Hex() (string, error) {
+	data, err := b.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// SubjectTransaction returns the transaction the BEEF was built for
+func (b *Beef) SubjectTransaction() *wire.MsgTx {
+	if len(b.Transactions) == 0 {
+		return nil
+	}
+	return b.Transactions[len(b.Transactions)-1].Tx
+}
+
+// Verify checks the BEEF by SPV: mined transactions must have a merkle proof
+// for a block header known to the chain tracker, and every unmined transaction
+// must spend outputs of earlier transactions with valid scripts and without
+// creating more satoshis than it spends.
+func (b *Beef) Verify(tracker spv.ChainTracker) error {
+	if len(b.Transactions) == 0 {
+		return fmt.Errorf("BEEF contains no transactions")
+	}
+
+	known := make(map[chainhash.Hash]*wire.MsgTx, len(b.Transactions))
+	for i, beefTx := range b.Transactions {
+		tx := beefTx.Tx
+		txid := tx.TxHash()
+
+		if beefTx.BumpIndex >= 0 {
+			if beefTx.BumpIndex >= len(b.BUMPs) {
+				return fmt.Errorf("transaction %s references missing BUMP %d", txid, beefTx.BumpIndex)
+			}
+
+			bump := b.BUMPs[beefTx.BumpIndex]
+			root, err := bump.ComputeRoot(&txid)
+			if err != nil {
+				return fmt.Errorf("transaction %s: %v", txid, err)
+			}
+
+			valid, err := tracker.IsValidRootForHeight(root, bump.BlockHeight)
+			if err != nil {
+				return fmt.Errorf("failed to check merkle root for transaction %s: %v", txid, err)
+			}
+			if !valid {
+				return fmt.Errorf("transaction %s: merkle root %s is not valid for block %d", txid, root, bump.BlockHeight)
+			}
+		} else {
+			if err := verifySpends(tx, known); err != nil {
+				return fmt.Errorf("transaction %d (%s): %v", i, txid, err)
+			}
+		}
+
+		known[txid] = tx
+	}
+
+	return nil
+}
+
+// verifySpends checks the scripts and amounts of a transaction against the
+// outputs it spends
+func verifySpends(tx *wire.MsgTx, known map[chainhash.Hash]*wire.MsgTx) error {
+	if len(tx.TxIn) == 0 {
+		return fmt.Errorf("transaction has no inputs")
+	}
+
+	var totalIn, totalOut int64
+	for i, txIn := range tx.TxIn {
+		source, ok := known[txIn.PreviousOutPoint.Hash]
+		if !ok {
+			return fmt.Errorf("input %d spends unknown transaction %s", i, txIn.PreviousOutPoint.Hash)
+		}
+
+		if int(txIn.PreviousOutPoint.Index) >= len(source.TxOut) {
+			return fmt.Errorf("input %d spends missing output %s", i, txIn.PreviousOutPoint)
+		}
+
+		prevOut := source.TxOut[txIn.PreviousOutPoint.Index]
+		if err := verifyInputScript(tx, i, prevOut); err != nil {
+			return fmt.Errorf("input %d: %v", i, err)
+		}
+
+		totalIn += prevOut.Value
+	}
+
+	for _, txOut := range tx.TxOut {
+		totalOut += txOut.Value
+	}
+
+	if totalOut > totalIn {
+		return fmt.Errorf("outputs %d exceed inputs %d", totalOut, totalIn)
+	}
+
+	return nil
+}
+
+// verifyInputScript runs an input's unlocking script against the locking script it spends
+func verifyInputScript(tx *wire.MsgTx, index int, prevOut *wire.TxOut) error {
+	if err := VerifyScript(tx.TxIn[index].SignatureScript, prevOut.PkScript, tx, index, prevOut.Value, StandardScriptFlags); err != nil {
+		return fmt.Errorf("script verification failed: %v", err)
+	}
+
+	return nil
+}
+
+// AttachBeef builds the BEEF for a sent transaction and stores its hex on the result
+func (b *Builder) AttachBeef(result *types.TransactionResult, sourceTxs []*wire.MsgTx, bumps []*spv.MerklePath) (*Beef, error) {
+	txBytes, err := hex.DecodeString(result.SignedTx)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signed transaction hex: %v", err)
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(txBytes)); err != nil {
+		return nil, fmt.Errorf("failed to deserialize transaction: %v", err)
+	}
+
+	beef, err := NewBeef(tx, sourceTxs, bumps)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Beef, err = beef.Hex()
+	if err != nil {
+		return nil, err
+	}
+
+	return beef, nil
+}
@@ -0,0 +1,152 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Broadcaster submits serialized transactions to the network
+type Broadcaster interface {
+	// Broadcast submits a raw or Extended Format transaction
+	Broadcast(txBytes []byte) error
+	// SupportsExtendedFormat reports whether Broadcast accepts Extended Format
+	SupportsExtendedFormat() bool
+}
+
+// WhatsOnChainBroadcaster broadcasts raw transactions through POST /tx/raw
+type WhatsOnChainBroadcaster struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewWhatsOnChainBroadcaster creates a broadcaster for a What's On Chain style API
+func NewWhatsOnChainBroadcaster(baseURL string) *WhatsOnChainBroadcaster {
+	return &WhatsOnChainBroadcaster{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// SupportsExtendedFormat implements Broadcaster
+func (w *WhatsOnChainBroadcaster) SupportsExtendedFormat() bool {
+	return false
+}
+
+// Broadcast implements Broadcaster
+func (w *WhatsOnChainBroadcaster) Broadcast(txBytes []byte) error {
+	// Create request
+	req, err := http.NewRequest("POST", w.baseURL+"/tx/raw", bytes.NewBuffer(txBytes))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("User-Agent", "BSV-Enhanced-SDK/1.0.0")
+
+	// Send request
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("broadcast failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// ARCBroadcaster broadcasts through an ARC endpoint (POST /v1/tx), which
+// accepts Extended Format transactions
+type ARCBroadcaster struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// arcTxResponse represents the response from ARC POST /v1/tx
+type arcTxResponse struct {
+	TxID      string `json:"txid"`
+	TxStatus  string `json:"txStatus"`
+	ExtraInfo string `json:"extraInfo"`
+	Title     string `json:"title"`
+	Detail    string `json:"detail"`
+}
+
+// arcRejectedStatuses are the ARC transaction statuses that mean the
+// transaction will not be mined
+var arcRejectedStatuses = map[string]bool{
+	"REJECTED":               true,
+	"DOUBLE_SPEND_ATTEMPTED": true,
+}
+
+// NewARCBroadcaster creates a broadcaster for an ARC base URL
+func NewARCBroadcaster(baseURL string) *ARCBroadcaster {
+	return &ARCBroadcaster{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// SetAPIKey sets the bearer token sent with broadcast requests
+func (a *ARCBroadcaster) SetAPIKey(apiKey string) {
+	a.apiKey = apiKey
+}
+
+// SupportsExtendedFormat implements Broadcaster
+func (a *ARCBroadcaster) SupportsExtendedFormat() bool {
+	return true
+}
+
+// Broadcast implements Broadcaster
+func (a *ARCBroadcaster) Broadcast(txBytes []byte) error {
+	req, err := http.NewRequest("POST", a.baseURL+"/v1/tx", bytes.NewBuffer(txBytes))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "BSV-Enhanced-SDK/1.0.0")
+	if a.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+a.apiKey)
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %v", err)
+	}
+
+	var result arcTxResponse
+	json.Unmarshal(body, &result)
+
+	if resp.StatusCode != http.StatusOK {
+		if result.Detail != "" {
+			return fmt.Errorf("broadcast failed with status %d: %s: %s", resp.StatusCode, result.Title, result.Detail)
+		}
+		return fmt.Errorf("broadcast failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if arcRejectedStatuses[result.TxStatus] {
+		return fmt.Errorf("broadcast %s: %s", strings.ToLower(result.TxStatus), result.ExtraInfo)
+	}
+
+	return nil
+}
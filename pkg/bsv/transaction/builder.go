@@ -3,8 +3,8 @@ package transaction
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
 	"time"
@@ -12,9 +12,10 @@ import (
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
-	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/spv"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/token"
 	"github.com/muhammadamman/BSV-Go/pkg/bsv/utxo"
 	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
 	"github.com/muhammadamman/BSV-Go/pkg/config"
@@ -24,9 +25,15 @@ import (
 
 // Builder handles BSV transaction building with dynamic configuration
 type Builder struct {
-	configManager *config.Manager
-	utxoManager   *utxo.Manager
-	httpClient    *http.Client
+	configManager    *config.Manager
+	utxoManager      *utxo.Manager
+	httpClient       *http.Client
+	feeQuoteProvider FeeQuoteProvider
+	broadcaster      Broadcaster
+	unlockers        []ScriptTemplate
+	tokenProtocol    token.Protocol
+	changeIndexes    wallet.ChangeIndexStore
+	keyring          *wallet.Keyring
 }
 
 // NewBuilder creates a new transaction builder
@@ -37,18 +44,48 @@ func NewBuilder(configManager *config.Manager) *Builder {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		tokenProtocol: token.NewBSV21(),
+		changeIndexes: wallet.NewMemoryChangeIndexStore(),
+		keyring:       wallet.NewKeyring(),
 	}
 }
 
-// BuildTransaction builds a BSV transaction with enhanced native/non-native support
+// BuildTransaction builds a BSV transaction with enhanced native/non-native
+// support. An HD change index stays reserved, since the caller broadcasts the
+// transaction.
 func (b *Builder) BuildTransaction(params *types.TransactionParams) (*wire.MsgTx, error) {
-	// Validate inputs
-	if err := b.validateParams(params); err != nil {
+	signer, account, err := b.paramsSigner(params)
+	if err != nil {
+		return nil, err
+	}
+
+	unsigned, err := b.buildTransaction(params, signer, account)
+	if err != nil {
+		return nil, err
+	}
+	return unsigned.Tx, nil
+}
+
+// BuildTransactionWithSigner builds a transaction signed by signer rather
+// than params.PrivateKey, which must be empty. The key never has to be in
+// the params, so they can be logged or serialized safely.
+func (b *Builder) BuildTransactionWithSigner(params *types.TransactionParams, signer wallet.Signer) (*wire.MsgTx, error) {
+	account, err := signerAccount(params.PrivateKey, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	unsigned, err := b.buildTransaction(params, signer, account)
+	if err != nil {
 		return nil, err
 	}
+	return unsigned.Tx, nil
+}
 
-	// Get sender address and keypair
-	senderAddress, keyPair, err := b.getSenderInfo(params.PrivateKey)
+// buildTransaction builds and signs a transaction, returning the UTXOs it
+// spends. account is the sender's HD account, or nil if it has none.
+func (b *Builder) buildTransaction(params *types.TransactionParams, signer wallet.Signer, account *wallet.HDAccount) (*UnsignedTransaction, error) {
+	senderAddress, err := wallet.SignerAddress(signer, b.getNetwork())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get sender info: %v", err)
 	}
@@ -58,69 +95,222 @@ func (b *Builder) BuildTransaction(params *types.TransactionParams) (*wire.MsgTx
 		return nil, fmt.Errorf("sender address mismatch: expected %s, got %s", params.From, senderAddress)
 	}
 
+	// Inputs from funding addresses are signed with their own keys
+	signers, err := b.fundingSigners(params, account)
+	if err != nil {
+		return nil, err
+	}
+
+	unsigned, err := b.buildUnsignedTransaction(params, account)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sign the transaction
+	if err := b.signTransaction(unsigned.Tx, unsigned.Inputs, append([]wallet.Signer{signer}, signers...)...); err != nil {
+		b.releaseChangeIndex(unsigned.ChangeAccount, unsigned.ChangePath)
+		return nil, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+
+	// Enforce the maximum transaction size on the final serialization
+	if err := b.checkTransactionSize(unsigned.Tx); err != nil {
+		b.releaseChangeIndex(unsigned.ChangeAccount, unsigned.ChangePath)
+		return nil, err
+	}
+
+	return unsigned, nil
+}
+
+// buildUnsignedTransaction selects inputs and adds outputs without signing
+func (b *Builder) buildUnsignedTransaction(params *types.TransactionParams, account *wallet.HDAccount) (*UnsignedTransaction, error) {
+	// Validate inputs
+	if err := b.validateParams(params); err != nil {
+		return nil, err
+	}
+
+	// Price the transaction at the caller's fee rate or the fee quote
+	quote, err := b.feeQuote(params.FeeRate)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := b.fundingAddresses(params, account)
+	if err != nil {
+		return nil, err
+	}
+
 	// Select UTXOs based on transaction type
 	var selectedUTXOs []types.UTXO
 	var fee int64
 
-	txConfig := b.configManager.GetTransactionConfig()
-
 	if len(params.TokenTransfers) > 0 {
 		// Token transfer transaction
-		selectedUTXOs, fee, err = b.selectUTXOsForTokenTransfer(params)
+		selectedUTXOs, fee, err = b.selectUTXOsForTokenTransfer(params, addresses, quote)
 		if err != nil {
-			return nil, fmt.Errorf("failed to select UTXOs for token transfer: %v", err)
+			return nil, fmt.Errorf("failed to select UTXOs for token transfer: %w", err)
 		}
 	} else {
 		// Regular BSV transaction
-		if params.FeeRate <= 0 {
-			params.FeeRate = txConfig.DefaultFeeRate
-		}
-		selectedUTXOs, fee, err = b.utxoManager.SelectUTXOs(params.From, params.Amount, params.FeeRate)
+		selectedUTXOs, fee, err = b.selectUTXOs(params, addresses, quote)
 		if err != nil {
-			return nil, fmt.Errorf("failed to select UTXOs: %v", err)
+			return nil, fmt.Errorf("failed to select UTXOs: %w", err)
 		}
 	}
 
 	// Create new transaction
 	tx := wire.NewMsgTx(wire.TxVersion)
 
-	// Add inputs
-	for _, utxo := range selectedUTXOs {
+	// Add inputs, recording the locking script each one spends
+	network := b.getNetwork()
+	inputs := make([]types.UTXO, len(selectedUTXOs))
+	for i, utxo := range selectedUTXOs {
 		txHash, err := chainhash.NewHashFromStr(utxo.TxID)
 		if err != nil {
 			return nil, fmt.Errorf("invalid UTXO transaction hash: %v", err)
 		}
 
+		if utxo.ScriptPubKey == "" {
+			script, err := payToAddressScript(utxo.Address, network)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create script for input %d: %v", i, err)
+			}
+			utxo.ScriptPubKey = hex.EncodeToString(script)
+		}
+		inputs[i] = utxo
+
 		prevOut := wire.NewOutPoint(txHash, utxo.Vout)
 		txIn := wire.NewTxIn(prevOut, nil, nil)
 		tx.AddTxIn(txIn)
 	}
 
 	// Add outputs
-	err = b.addOutputs(tx, params, selectedUTXOs, fee)
+	changePath, err := b.addOutputs(tx, params, inputs, fee, nil, account)
 	if err != nil {
 		return nil, fmt.Errorf("failed to add outputs: %v", err)
 	}
 
-	// Sign the transaction
-	if err := b.signTransaction(tx, selectedUTXOs, keyPair); err != nil {
-		return nil, fmt.Errorf("failed to sign transaction: %v", err)
+	var changeAccount string
+	if changePath != "" {
+		changeAccount = account.ID()
+	}
+
+	// Never hand out a transaction that burns or mints tokens for signing
+	if err := b.checkTokenConservation(tx, inputs); err != nil {
+		b.releaseChangeIndex(changeAccount, changePath)
+		return nil, err
+	}
+
+	inputPaths, err := b.inputPaths(params, account, inputs)
+	if err != nil {
+		b.releaseChangeIndex(changeAccount, changePath)
+		return nil, err
+	}
+
+	return &UnsignedTransaction{
+		Version:       UnsignedTransactionVersion,
+		IsTestnet:     b.configManager.GetNetworkConfig().IsTestnet,
+		Tx:            tx,
+		Inputs:        inputs,
+		ChangePath:    changePath,
+		ChangeAccount: changeAccount,
+		InputPaths:    inputPaths,
+	}, nil
+}
+
+// commitChangeIndex marks the HD change address of a broadcast transaction
+// as used. The builder reserved it already; committing also covers a
+// transaction built against another store.
+func (b *Builder) commitChangeIndex(accountID, changePath string, tx *wire.MsgTx) error {
+	if changePath == "" {
+		return nil
+	}
+
+	index, err := changePathIndex(changePath)
+	if err != nil {
+		return err
+	}
+
+	if err := b.changeIndexes.CommitChangeIndex(accountID, index); err != nil {
+		return fmt.Errorf("transaction %s was broadcast but its change index was not saved: %v", tx.TxHash(), err)
+	}
+	return nil
+}
+
+// releaseChangeIndex returns the reserved HD change index of a transaction
+// that will not be broadcast. It runs on paths that already fail, so an
+// error only leaves a gap in the internal chain and is dropped.
+func (b *Builder) releaseChangeIndex(accountID, changePath string) {
+	if changePath == "" {
+		return
+	}
+
+	if index, err := changePathIndex(changePath); err == nil {
+		b.changeIndexes.ReleaseChangeIndex(accountID, index)
+	}
+}
+
+// inputPaths returns the derivation path of each input spent from the
+// sender's HD account: its first receiving address and params.FundingPaths.
+// It returns nil when there is no account.
+func (b *Builder) inputPaths(params *types.TransactionParams, account *wallet.HDAccount, inputs []types.UTXO) ([]string, error) {
+	if account == nil {
+		return nil, nil
+	}
+
+	hdPaths := append([]*types.HDPath{{Chain: wallet.ExternalChain, Index: 0}}, params.FundingPaths...)
+	paths := make(map[string]string, len(hdPaths))
+	for _, path := range hdPaths {
+		keyPair, err := account.KeyPair(path.Chain, path.Index)
+		if err != nil {
+			return nil, err
+		}
+		address, err := keyPair.Address()
+		if err != nil {
+			return nil, err
+		}
+		paths[address] = account.Path(path.Chain, path.Index)
 	}
 
-	return tx, nil
+	inputPaths := make([]string, len(inputs))
+	for i, input := range inputs {
+		inputPaths[i] = paths[input.Address]
+	}
+	return inputPaths, nil
 }
 
 // SignAndSendTransaction builds, signs, and broadcasts a transaction
 func (b *Builder) SignAndSendTransaction(params *types.TransactionParams) (*types.TransactionResult, error) {
+	signer, account, err := b.paramsSigner(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build transaction: %w", err)
+	}
+
+	return b.signAndSendTransaction(params, signer, account)
+}
+
+// SignAndSendTransactionWithSigner builds, signs with signer, and broadcasts
+// a transaction. params.PrivateKey must be empty.
+func (b *Builder) SignAndSendTransactionWithSigner(params *types.TransactionParams, signer wallet.Signer) (*types.TransactionResult, error) {
+	account, err := signerAccount(params.PrivateKey, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build transaction: %w", err)
+	}
+
+	return b.signAndSendTransaction(params, signer, account)
+}
+
+func (b *Builder) signAndSendTransaction(params *types.TransactionParams, signer wallet.Signer, account *wallet.HDAccount) (*types.TransactionResult, error) {
 	// Build the transaction
-	tx, err := b.BuildTransaction(params)
+	unsigned, err := b.buildTransaction(params, signer, account)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build transaction: %v", err)
+		return nil, fmt.Errorf("failed to build transaction: %w", err)
 	}
+	tx, selectedUTXOs := unsigned.Tx, unsigned.Inputs
 
 	// Serialize the transaction
 	var buf bytes.Buffer
 	if err := tx.Serialize(&buf); err != nil {
+		b.releaseChangeIndex(unsigned.ChangeAccount, unsigned.ChangePath)
 		return nil, fmt.Errorf("failed to serialize transaction: %v", err)
 	}
 
@@ -128,12 +318,13 @@ func (b *Builder) SignAndSendTransaction(params *types.TransactionParams) (*type
 	txID := tx.TxHash().String()
 
 	// Broadcast the transaction
-	if err := b.broadcastTransaction(buf.Bytes()); err != nil {
+	if err := b.broadcastTransaction(tx, selectedUTXOs); err != nil {
+		b.releaseChangeIndex(unsigned.ChangeAccount, unsigned.ChangePath)
 		return nil, fmt.Errorf("failed to broadcast transaction: %v", err)
 	}
 
 	// Calculate detailed transaction information
-	result, err := b.calculateTransactionResult(tx, params, txID, buf.Bytes())
+	result, err := b.calculateTransactionResult(tx, params, selectedUTXOs, unsigned.ChangePath, txID, buf.Bytes())
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate transaction result: %v", err)
 	}
@@ -166,11 +357,90 @@ func (b *Builder) GetUTXOs(address string) ([]types.UTXO, error) {
 	return b.utxoManager.GetUTXOs(address)
 }
 
+// SetFeeQuoteProvider sets the provider used to pick fee rates automatically.
+// Passing nil restores the static rate from the transaction configuration.
+func (b *Builder) SetFeeQuoteProvider(provider FeeQuoteProvider) {
+	b.feeQuoteProvider = provider
+}
+
+// GetFeeQuote returns the fee quote the builder currently uses
+func (b *Builder) GetFeeQuote() (*FeeQuote, error) {
+	if b.feeQuoteProvider == nil {
+		txConfig := b.configManager.GetTransactionConfig()
+		return NewStaticFeeQuoteProvider(txConfig.DefaultFeeRate).GetFeeQuote()
+	}
+	return b.feeQuoteProvider.GetFeeQuote()
+}
+
 // Helper methods
 
-func (b *Builder) validateParams(params *types.TransactionParams) error {
-	txConfig := b.configManager.GetTransactionConfig()
+// SetSPVVerifier enables SPV verification of the UTXOs the builder fetches
+func (b *Builder) SetSPVVerifier(tracker spv.ChainTracker, paths spv.MerklePathProvider) {
+	b.utxoManager.SetSPVVerifier(tracker, paths)
+}
+
+// SetBroadcaster sets where signed transactions are submitted. By default they
+// are posted to the configured RPCURL.
+func (b *Builder) SetBroadcaster(broadcaster Broadcaster) {
+	b.broadcaster = broadcaster
+}
+
+// SetTokenProtocol sets the protocol token transfers are sent with, and
+// detects it in fetched UTXOs. The default is BSV-21.
+func (b *Builder) SetTokenProtocol(protocol token.Protocol) {
+	b.tokenProtocol = protocol
+	b.utxoManager.AddTokenProtocol(protocol)
+}
+
+// SetChangeIndexStore sets the store tracking the change indexes of HD
+// senders. The default keeps them in memory.
+func (b *Builder) SetChangeIndexStore(store wallet.ChangeIndexStore) {
+	b.changeIndexes = store
+}
+
+// SetKeyring sets the keyring signing inputs from params.FundingAddresses
+func (b *Builder) SetKeyring(keyring *wallet.Keyring) {
+	b.keyring = keyring
+}
+
+// AddUnlocker registers a template for spending UTXOs with non-P2PKH locking
+// scripts. The signing key's own P2PKH and P2PK outputs need no registration.
+func (b *Builder) AddUnlocker(template ScriptTemplate) {
+	b.unlockers = append(b.unlockers, template)
+}
+
+// getBroadcaster returns the broadcaster, falling back to the configured RPCURL
+func (b *Builder) getBroadcaster() Broadcaster {
+	if b.broadcaster != nil {
+		return b.broadcaster
+	}
+	return NewWhatsOnChainBroadcaster(b.configManager.GetNetworkConfig().RPCURL)
+}
+
+// getNetwork returns the chain parameters for the configured network
+func (b *Builder) getNetwork() *chaincfg.Params {
+	return networkParams(b.configManager.GetNetworkConfig().IsTestnet)
+}
+
+// networkParams returns the chain parameters for mainnet or testnet
+func networkParams(isTestnet bool) *chaincfg.Params {
+	if isTestnet {
+		return &chaincfg.TestNet3Params
+	}
+	return &chaincfg.MainNetParams
+}
+
+// checkTransactionSize fails with a TransactionSizeError when tx exceeds the
+// configured maximum transaction size
+func (b *Builder) checkTransactionSize(tx *wire.MsgTx) error {
+	maxSize := b.configManager.GetTransactionConfig().MaxTransactionSize
+	if size := tx.SerializeSize(); size > maxSize {
+		return &TransactionSizeError{Size: size, MaxSize: maxSize}
+	}
+	return nil
+}
 
+func (b *Builder) validateParams(params *types.TransactionParams) error {
 	if params.From == "" {
 		return fmt.Errorf("sender address is required")
 	}
@@ -180,17 +450,10 @@ func (b *Builder) validateParams(params *types.TransactionParams) error {
 	if params.Amount <= 0 {
 		return fmt.Errorf("amount must be positive")
 	}
-	if params.PrivateKey == "" {
-		return fmt.Errorf("private key is required")
-	}
 
-	// Validate fee rate
-	if params.FeeRate <= 0 {
-		params.FeeRate = txConfig.DefaultFeeRate
-	} else if params.FeeRate < txConfig.MinFeeRate {
-		return fmt.Errorf("fee rate %d is below minimum %d", params.FeeRate, txConfig.MinFeeRate)
-	} else if params.FeeRate > txConfig.MaxFeeRate {
-		return fmt.Errorf("fee rate %d exceeds maximum %d", params.FeeRate, txConfig.MaxFeeRate)
+	// Validate fee rate (zero means pick it from the fee quote)
+	if err := b.validateFeeRate(params.FeeRate); err != nil {
+		return err
 	}
 
 	// Validate token transfers
@@ -209,47 +472,127 @@ func (b *Builder) validateParams(params *types.TransactionParams) error {
 	return nil
 }
 
-func (b *Builder) getSenderInfo(privateKey string) (string, *wallet.KeyPair, error) {
-	networkConfig := b.configManager.GetNetworkConfig()
+// validateFeeRate checks a requested fee rate against the configured bounds.
+// Zero is allowed and means the rate comes from the fee quote.
+func (b *Builder) validateFeeRate(feeRate int64) error {
+	txConfig := b.configManager.GetTransactionConfig()
+
+	if feeRate < 0 {
+		return fmt.Errorf("fee rate cannot be negative")
+	} else if feeRate > 0 && feeRate < txConfig.MinFeeRate {
+		return fmt.Errorf("fee rate %d is below minimum %d", feeRate, txConfig.MinFeeRate)
+	} else if feeRate > txConfig.MaxFeeRate {
+		return fmt.Errorf("fee rate %d exceeds maximum %d", feeRate, txConfig.MaxFeeRate)
+	}
+
+	return nil
+}
+
+// feeQuote returns the rates a transaction is priced at. A fee rate set by the
+// caller applies to every byte; otherwise the fee quote's standard and data
+// rates apply, capped at the configured maximum.
+func (b *Builder) feeQuote(feeRate int64) (*FeeQuote, error) {
+	if feeRate > 0 {
+		return NewStaticFeeQuoteProvider(feeRate).GetFeeQuote()
+	}
+
+	quote, err := b.GetFeeQuote()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee quote: %v", err)
+	}
+	if !quote.Standard.IsSet() {
+		return nil, fmt.Errorf("fee quote has no standard rate")
+	}
+
+	maxFeeRate := b.configManager.GetTransactionConfig().MaxFeeRate
+	quote.Standard = capFeeRate(quote.Standard, maxFeeRate)
+	quote.Data = capFeeRate(quote.Data, maxFeeRate)
+	return quote, nil
+}
+
+// capFeeRate returns rate, or maxFeeRate satoshis per byte when rate is higher
+func capFeeRate(rate FeeRate, maxFeeRate int64) FeeRate {
+	if rate.IsSet() && rate.Satoshis > maxFeeRate*rate.Bytes {
+		return types.SatoshisPerByte(maxFeeRate)
+	}
+	return rate
+}
+
+// newSelectionTarget creates a selection target priced by a fee quote
+func newSelectionTarget(quote *FeeQuote, amount, dustLimit int64) *utxo.SelectionTarget {
+	target := utxo.NewSelectionTarget(amount, quote.Standard, dustLimit)
+	target.DataFeeRate = quote.DataRate()
+	return target
+}
+
+func (b *Builder) getSenderInfo(privateKey types.Secret) (string, *wallet.KeyPair, *wallet.HDAccount, error) {
+	return getSenderInfo(privateKey, b.configManager.GetNetworkConfig().IsTestnet)
+}
+
+// getSenderInfo resolves a WIF private key, mnemonic or extended private key
+// into its address and keypair, and the HD account of a mnemonic or extended
+// key. It is the only place a private key secret is revealed.
+func getSenderInfo(privateKey types.Secret, isTestnet bool) (string, *wallet.KeyPair, *wallet.HDAccount, error) {
+	value := privateKey.Reveal()
+
+	// An extended key sends from the first receiving address of its account
+	if wallet.IsExtendedKey(value) {
+		account, err := wallet.ParseExtendedKey(value, isTestnet)
+		if err != nil {
+			return "", nil, nil, err
+		}
+
+		keyPair, err := account.KeyPair(wallet.ExternalChain, 0)
+		if err != nil {
+			return "", nil, nil, err
+		}
+
+		address, err := keyPair.Address()
+		if err != nil {
+			return "", nil, nil, err
+		}
+
+		return address, keyPair, account, nil
+	}
 
 	// Check if it's a mnemonic (12 or more words)
-	words := strings.Fields(strings.TrimSpace(privateKey))
+	words := strings.Fields(strings.TrimSpace(value))
 	if len(words) >= 12 {
 		// It's a mnemonic - validate and generate wallet
-		if err := mnemonic.Validate(privateKey); err != nil {
-			return "", nil, fmt.Errorf("invalid mnemonic: %v", err)
+		if err := mnemonic.Validate(value); err != nil {
+			return "", nil, nil, fmt.Errorf("invalid mnemonic: %v", err)
+		}
+
+		walletResult, keyPair, err := wallet.GenerateWalletWithKeypair(value, isTestnet)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("failed to generate wallet from mnemonic: %v", err)
 		}
 
-		walletResult, keyPair, err := wallet.GenerateWalletWithKeypair(privateKey, networkConfig.IsTestnet)
+		account, err := wallet.NewHDAccount(strings.TrimSpace(value), 0, isTestnet)
 		if err != nil {
-			return "", nil, fmt.Errorf("failed to generate wallet from mnemonic: %v", err)
+			return "", nil, nil, fmt.Errorf("failed to derive sender account: %v", err)
 		}
 
-		return walletResult.Address, keyPair, nil
+		return walletResult.Address, keyPair, account, nil
 	} else {
 		// It's a WIF private key
-		var network *chaincfg.Params
-		if networkConfig.IsTestnet {
-			network = &chaincfg.TestNet3Params
-		} else {
-			network = &chaincfg.MainNetParams
-		}
+		network := networkParams(isTestnet)
 
-		wif, err := btcutil.DecodeWIF(privateKey)
+		wif, err := btcutil.DecodeWIF(value)
 		if err != nil {
-			return "", nil, fmt.Errorf("invalid WIF private key: %v", err)
+			return "", nil, nil, fmt.Errorf("invalid WIF private key: %v", err)
 		}
 
 		// Validate network
 		if !wif.IsForNet(network) {
-			return "", nil, fmt.Errorf("WIF private key is not for the correct network")
+			return "", nil, nil, fmt.Errorf("WIF private key is not for the correct network")
 		}
 
 		// Get address from public key
 		pubKey := wif.PrivKey.PubKey()
 		address, err := btcutil.NewAddressPubKey(pubKey.SerializeCompressed(), network)
 		if err != nil {
-			return "", nil, fmt.Errorf("failed to create address from public key: %v", err)
+			return "", nil, nil, fmt.Errorf("failed to create address from public key: %v", err)
 		}
 
 		// Create keypair
@@ -259,165 +602,444 @@ func (b *Builder) getSenderInfo(privateKey string) (string, *wallet.KeyPair, err
 			Network:    network,
 		}
 
-		return address.EncodeAddress(), keyPair, nil
+		return address.EncodeAddress(), keyPair, nil, nil
+	}
+}
+
+// paramsSigner resolves params.PrivateKey into the sender's signer and, for a
+// mnemonic or extended private key, its HD account
+func (b *Builder) paramsSigner(params *types.TransactionParams) (wallet.Signer, *wallet.HDAccount, error) {
+	return b.keySigner(params.PrivateKey)
+}
+
+// accountSigner is implemented by signers whose key belongs to an HD account
+type accountSigner interface {
+	Account() *wallet.HDAccount
+}
+
+// signerAccount checks that a signer replaces privateKey and returns its HD
+// account, if it has one
+func signerAccount(privateKey types.Secret, signer wallet.Signer) (*wallet.HDAccount, error) {
+	if err := checkSigner(privateKey, signer); err != nil {
+		return nil, err
+	}
+
+	if hd, ok := signer.(accountSigner); ok {
+		return hd.Account(), nil
+	}
+	return nil, nil
+}
+
+// checkSigner checks that a signer is given in place of a private key
+func checkSigner(privateKey types.Secret, signer wallet.Signer) error {
+	if signer == nil {
+		return fmt.Errorf("signer is required")
+	}
+	if !privateKey.IsEmpty() {
+		return fmt.Errorf("private key must be empty when signing with a signer")
+	}
+	return nil
+}
+
+// keySigner returns a signer for a WIF private key, mnemonic or extended
+// private key and, for the latter two, its HD account
+func (b *Builder) keySigner(privateKey types.Secret) (wallet.Signer, *wallet.HDAccount, error) {
+	if privateKey.IsEmpty() {
+		return nil, nil, fmt.Errorf("private key is required")
+	}
+
+	_, keyPair, account, err := b.getSenderInfo(privateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get sender info: %v", err)
+	}
+	return wallet.NewKeySigner(keyPair), account, nil
+}
+
+// changeOutputScript returns the script change is paid to, with its
+// derivation path. An HD sender's change goes to the next unused address on
+// its account's internal chain; any other sender's goes back to from.
+func (b *Builder) changeOutputScript(from string, account *wallet.HDAccount) ([]byte, string, error) {
+	network := b.getNetwork()
+
+	// Only derive change for the account that owns params.From
+	if account != nil {
+		receiving, err := account.KeyPair(wallet.ExternalChain, 0)
+		if err != nil {
+			return nil, "", err
+		}
+		if address, err := receiving.Address(); err != nil || address != from {
+			account = nil
+		}
+	}
+
+	if account == nil {
+		script, err := payToAddressScript(from, network)
+		return script, "", err
+	}
+
+	// The index is reserved so concurrent builds never share it, and released
+	// again if the transaction is never broadcast
+	index, err := b.changeIndexes.ReserveChangeIndex(account.ID())
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to reserve change index: %v", err)
+	}
+	path := account.Path(wallet.InternalChain, index)
+
+	keyPair, err := account.KeyPair(wallet.InternalChain, index)
+	if err != nil {
+		b.releaseChangeIndex(account.ID(), path)
+		return nil, "", err
+	}
+
+	address, err := keyPair.Address()
+	if err != nil {
+		b.releaseChangeIndex(account.ID(), path)
+		return nil, "", err
+	}
+
+	script, err := payToAddressScript(address, network)
+	if err != nil {
+		b.releaseChangeIndex(account.ID(), path)
+		return nil, "", err
+	}
+
+	return script, path, nil
+}
+
+// changeSigner returns a signer for the HD change output at changePath of
+// account, for spending it in a chained transaction
+func (b *Builder) changeSigner(account *wallet.HDAccount, changePath string) (wallet.Signer, error) {
+	index, err := changePathIndex(changePath)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.NewHDSigner(account, wallet.InternalChain, index)
+}
+
+// selectUTXOs selects inputs with the per-transaction strategy, falling back to the configured default
+func (b *Builder) selectUTXOs(params *types.TransactionParams, addresses []string, quote *FeeQuote) ([]types.UTXO, int64, error) {
+	strategy := config.CoinSelectionStrategy(params.CoinSelectionStrategy)
+	if strategy == "" {
+		strategy = b.configManager.GetTransactionConfig().CoinSelectionStrategy
+	}
+
+	// Data outputs can be megabytes, so their size counts towards the fee
+	dataSize, err := dataOutputsSize(params.DataOutputs)
+	if err != nil {
+		return nil, 0, err
 	}
+
+	target := newSelectionTarget(quote, params.Amount, b.configManager.GetTransactionConfig().DustLimit)
+	target.BaseSize += dataSize
+	target.DataSize = dataSize
+
+	return b.utxoManager.SelectUTXOsFromAddresses(addresses, target, strategy)
 }
 
-func (b *Builder) selectUTXOsForTokenTransfer(params *types.TransactionParams) ([]types.UTXO, int64, error) {
-	// For now, we'll select UTXOs for the first token transfer
-	// In a more sophisticated implementation, you might want to handle multiple token transfers
+// selectUTXOsForTokenTransfer selects token inputs covering every transfer of
+// every token, then native inputs paying for the rest. If any token balance
+// is short nothing is selected, and each shortfall is reported.
+func (b *Builder) selectUTXOsForTokenTransfer(params *types.TransactionParams, addresses []string, quote *FeeQuote) ([]types.UTXO, int64, error) {
 	if len(params.TokenTransfers) == 0 {
 		return nil, 0, fmt.Errorf("no token transfers specified")
 	}
 
-	firstTransfer := params.TokenTransfers[0]
-	return b.utxoManager.SelectUTXOsForTokenTransfer(params.From, firstTransfer.TokenID, firstTransfer.Amount, params.FeeRate)
+	// Total the transfers of each token, in the order the tokens first appear
+	var tokenIDs []string
+	amounts := make(map[string]int64)
+	for _, transfer := range params.TokenTransfers {
+		if _, seen := amounts[transfer.TokenID]; !seen {
+			tokenIDs = append(tokenIDs, transfer.TokenID)
+		}
+		amounts[transfer.TokenID] += transfer.Amount
+	}
+
+	var tokenUTXOs []types.UTXO
+	var shortfalls []error
+	for _, tokenID := range tokenIDs {
+		selected, err := b.utxoManager.SelectTokenUTXOs(params.From, tokenID, amounts[tokenID])
+		if errors.Is(err, types.ErrInsufficientTokens) {
+			shortfalls = append(shortfalls, err)
+			continue
+		} else if err != nil {
+			return nil, 0, err
+		}
+		tokenUTXOs = append(tokenUTXOs, selected...)
+	}
+
+	if len(shortfalls) > 0 {
+		return nil, 0, errors.Join(shortfalls...)
+	}
+
+	return b.fundTokenTransfer(params, tokenUTXOs, addresses, quote)
 }
 
-func (b *Builder) addOutputs(tx *wire.MsgTx, params *types.TransactionParams, selectedUTXOs []types.UTXO, fee int64) error {
-	networkConfig := b.configManager.GetNetworkConfig()
+// fundTokenTransfer adds native inputs paying for the BSV amount, the token
+// outputs and the fee of a transaction spending tokenUTXOs
+func (b *Builder) fundTokenTransfer(params *types.TransactionParams, tokenUTXOs []types.UTXO, addresses []string, quote *FeeQuote) ([]types.UTXO, int64, error) {
+	network := b.getNetwork()
 
-	var network *chaincfg.Params
-	if networkConfig.IsTestnet {
-		network = &chaincfg.TestNet3Params
-	} else {
-		network = &chaincfg.MainNetParams
+	// Size the token outputs, counting one token change output per token
+	var tokenOutputsSize int
+	var tokenOutputsValue int64
+	for _, output := range b.tokenOutputs(params, tokenUTXOs, true) {
+		script, err := b.tokenProtocol.TransferScript(output.TokenID, output.Amount, output.To, network)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid token transfer: %v", err)
+		}
+		tokenOutputsSize += wire.NewTxOut(0, script).SerializeSize()
+		tokenOutputsValue += b.tokenProtocol.OutputValue()
 	}
 
-	// Add recipient output for BSV
-	recipientAddr, err := btcutil.DecodeAddress(params.To, network)
+	dataSize, err := dataOutputsSize(params.DataOutputs)
 	if err != nil {
-		return fmt.Errorf("invalid recipient address: %v", err)
+		return nil, 0, err
 	}
 
-	recipientScript, err := txscript.PayToAddrScript(recipientAddr)
+	outputsSize := utxo.P2PKHOutputSize + tokenOutputsSize + dataSize
+	inputs, fee, err := b.fundInputs(addresses, params.CoinSelectionStrategy, quote, params.Amount+tokenOutputsValue, outputsSize, dataSize, tokenUTXOs)
 	if err != nil {
-		return fmt.Errorf("failed to create recipient script: %v", err)
+		return nil, 0, fmt.Errorf("insufficient native balance for token transfer: %w", err)
 	}
 
-	tx.AddTxOut(wire.NewTxOut(params.Amount, recipientScript))
+	return inputs, fee, nil
+}
+
+// fundInputs adds native UTXOs of addresses to inputs that are already chosen, so
+// that together they pay outputs worth amount taking outputsSize bytes, of
+// which dataSize are data carrier bytes, and the fee. The chosen inputs come
+// first in the returned inputs.
+func (b *Builder) fundInputs(addresses []string, strategyName string, quote *FeeQuote, amount int64, outputsSize, dataSize int, chosen []types.UTXO) ([]types.UTXO, int64, error) {
+	txConfig := b.configManager.GetTransactionConfig()
 
-	// Add token transfer outputs
+	// The chosen inputs count towards the amount and the size up front
+	for _, input := range chosen {
+		amount -= input.Value
+	}
+	if amount < 0 {
+		amount = 0
+	}
+
+	target := newSelectionTarget(quote, amount, txConfig.DustLimit)
+	target.BaseSize = utxo.BaseTxSize + outputsSize + len(chosen)*utxo.P2PKHInputSize
+	target.DataSize = dataSize
+
+	available, truncated, err := b.utxoManager.GetSpendableUTXOsFromAddresses(addresses)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var native []types.UTXO
+	for _, u := range excludeUTXOs(available, chosen) {
+		if u.IsNative {
+			native = append(native, u)
+		}
+	}
+
+	strategy := config.CoinSelectionStrategy(strategyName)
+	if strategy == "" {
+		strategy = txConfig.CoinSelectionStrategy
+	}
+
+	selector, err := utxo.NewCoinSelector(strategy)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	selected, fee, err := selector.Select(native, target)
+	if err != nil {
+		return nil, 0, utxo.TruncationError(err, truncated)
+	}
+
+	return append(append([]types.UTXO{}, chosen...), selected...), fee, nil
+}
+
+// tokenOutputs returns the token outputs of a transaction: the transfers,
+// then change of every token whose inputs exceed its transfers, to
+// params.TokenChangeAddress or the sender. With estimate set, change is
+// counted for every token.
+func (b *Builder) tokenOutputs(params *types.TransactionParams, inputs []types.UTXO, estimate bool) []*types.TokenTransfer {
+	outputs := append([]*types.TokenTransfer{}, params.TokenTransfers...)
+
+	changeAddress := params.TokenChangeAddress
+	if changeAddress == "" {
+		changeAddress = params.From
+	}
+
+	var tokenIDs []string
+	surplus := make(map[string]int64)
+	for _, input := range inputs {
+		if input.IsNative || input.TokenID == "" {
+			continue
+		}
+		if _, seen := surplus[input.TokenID]; !seen {
+			tokenIDs = append(tokenIDs, input.TokenID)
+		}
+		surplus[input.TokenID] += input.TokenAmount
+	}
 	for _, transfer := range params.TokenTransfers {
-		// For token transfers, we would typically add special outputs
-		// This is a simplified implementation - in reality, you'd need to implement
-		// the specific token protocol (e.g., SLP, STAS, etc.)
-
-		// For now, we'll create a data output with token transfer information
-		tokenData := fmt.Sprintf("TOKEN_TRANSFER:%s:%s:%d", transfer.TokenID, transfer.To, transfer.Amount)
-		tokenDataHex := hex.EncodeToString([]byte(tokenData))
-
-		// Create OP_RETURN script
-		opReturnScript, err := txscript.NewScriptBuilder().
-			AddOp(txscript.OP_RETURN).
-			AddData([]byte(tokenDataHex)).
-			Script()
-		if err != nil {
-			return fmt.Errorf("failed to create token transfer script: %v", err)
+		surplus[transfer.TokenID] -= transfer.Amount
+	}
+
+	for _, tokenID := range tokenIDs {
+		if surplus[tokenID] > 0 {
+			outputs = append(outputs, &types.TokenTransfer{TokenID: tokenID, To: changeAddress, Amount: surplus[tokenID]})
+		} else if estimate {
+			outputs = append(outputs, &types.TokenTransfer{TokenID: tokenID, To: changeAddress, Amount: 1})
 		}
+	}
 
-		tx.AddTxOut(wire.NewTxOut(0, opReturnScript)) // 0 value for OP_RETURN
+	return outputs
+}
+
+// addOutputs adds the payment, token, data and change outputs. Change goes to
+// changeScript, or to the sender's change address when it is nil, whose
+// derivation path is returned for HD senders.
+func (b *Builder) addOutputs(tx *wire.MsgTx, params *types.TransactionParams, selectedUTXOs []types.UTXO, fee int64, changeScript []byte, account *wallet.HDAccount) (string, error) {
+	network := b.getNetwork()
+
+	// Add recipient output for BSV
+	recipientScript, err := payToAddressScript(params.To, network)
+	if err != nil {
+		return "", fmt.Errorf("invalid recipient address: %v", err)
 	}
 
-	// Add data outputs
-	for _, dataOutput := range params.DataOutputs {
-		data, err := hex.DecodeString(dataOutput.Data)
+	tx.AddTxOut(wire.NewTxOut(params.Amount, recipientScript))
+
+	// Add token transfer outputs and token change with the token protocol
+	var tokenValue int64
+	for _, output := range b.tokenOutputs(params, selectedUTXOs, false) {
+		script, err := b.tokenProtocol.TransferScript(output.TokenID, output.Amount, output.To, network)
 		if err != nil {
-			return fmt.Errorf("invalid data output hex: %v", err)
+			return "", fmt.Errorf("failed to create %s token output: %v", b.tokenProtocol.Name(), err)
 		}
 
-		opReturnScript, err := txscript.NewScriptBuilder().
-			AddOp(txscript.OP_RETURN).
-			AddData(data).
-			Script()
+		tx.AddTxOut(wire.NewTxOut(b.tokenProtocol.OutputValue(), script))
+		tokenValue += b.tokenProtocol.OutputValue()
+	}
+
+	// Add data outputs
+	for i, dataOutput := range params.DataOutputs {
+		opReturnScript, err := DataOutputScript(dataOutput)
 		if err != nil {
-			return fmt.Errorf("failed to create data output script: %v", err)
+			return "", fmt.Errorf("data output %d: %v", i, err)
 		}
 
 		tx.AddTxOut(wire.NewTxOut(0, opReturnScript)) // 0 value for OP_RETURN
 	}
 
 	// Add change output if necessary
-	change, hasChange := b.utxoManager.CalculateChange(selectedUTXOs, params.Amount, fee)
+	var changePath string
+	change, hasChange := b.utxoManager.CalculateChange(selectedUTXOs, params.Amount+tokenValue, fee)
 	if hasChange {
-		senderAddr, err := btcutil.DecodeAddress(params.From, network)
-		if err != nil {
-			return fmt.Errorf("invalid sender address: %v", err)
-		}
-
-		changeScript, err := txscript.PayToAddrScript(senderAddr)
-		if err != nil {
-			return fmt.Errorf("failed to create change script: %v", err)
+		if changeScript == nil {
+			changeScript, changePath, err = b.changeOutputScript(params.From, account)
+			if err != nil {
+				return "", fmt.Errorf("invalid change address: %v", err)
+			}
 		}
 
 		tx.AddTxOut(wire.NewTxOut(change, changeScript))
 	}
 
-	return nil
+	return changePath, nil
 }
 
-func (b *Builder) signTransaction(tx *wire.MsgTx, utxos []types.UTXO, keyPair *wallet.KeyPair) error {
-	networkConfig := b.configManager.GetNetworkConfig()
+// signTransaction unlocks each input with the template matching the locking
+// script it spends, trying the templates of each key in turn
+func (b *Builder) signTransaction(tx *wire.MsgTx, utxos []types.UTXO, signers ...wallet.Signer) error {
+	network := b.getNetwork()
 
-	var network *chaincfg.Params
-	if networkConfig.IsTestnet {
-		network = &chaincfg.TestNet3Params
-	} else {
-		network = &chaincfg.MainNetParams
+	var templates []ScriptTemplate
+	for _, signer := range signers {
+		templates = append(templates, keyTemplates(signer)...)
 	}
+	templates = append(templates, b.unlockers...)
 
+	unlockers := make([]ScriptTemplate, len(utxos))
 	for i, utxo := range utxos {
-		// Create the script to sign
-		senderAddr, err := btcutil.DecodeAddress(utxo.Address, network)
+		script, err := inputScript(utxo, network)
 		if err != nil {
-			return fmt.Errorf("failed to decode address: %v", err)
+			return fmt.Errorf("input %d: %v", i, err)
 		}
 
-		script, err := txscript.PayToAddrScript(senderAddr)
-		if err != nil {
-			return fmt.Errorf("failed to create script: %v", err)
+		unlockers[i] = resolveUnlocker(script, templates)
+		if unlockers[i] == nil {
+			return fmt.Errorf("input %d (%s:%d) with locking script %x: %w", i, utxo.TxID, utxo.Vout, script, types.ErrNoUnlocker)
 		}
+	}
 
-		// Create signature script
-		sigScript, err := txscript.SignatureScript(tx, i, script, txscript.SigHashAll, keyPair.PrivateKey, true)
-		if err != nil {
-			return fmt.Errorf("failed to create signature script: %v", err)
-		}
+	return b.SignWithTemplates(tx, utxos, unlockers)
+}
 
-		tx.TxIn[i].SignatureScript = sigScript
+// broadcastTransaction submits a signed transaction, in Extended Format when
+// the broadcaster supports it
+func (b *Builder) broadcastTransaction(tx *wire.MsgTx, inputs []types.UTXO) error {
+	prevOuts, err := b.prevOuts(inputs)
+	if err != nil {
+		return err
 	}
 
-	return nil
-}
+	// Catch invalid scripts before the broadcaster does
+	if err := b.Verify(tx, prevOuts); err != nil {
+		return err
+	}
 
-func (b *Builder) broadcastTransaction(txBytes []byte) error {
-	networkConfig := b.configManager.GetNetworkConfig()
-	url := networkConfig.RPCURL + "/tx/raw"
+	broadcaster := b.getBroadcaster()
 
-	// Create request
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(txBytes))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
+	var txBytes []byte
+	if broadcaster.SupportsExtendedFormat() {
+		txBytes, err = EncodeExtendedFormat(tx, prevOuts)
+		if err != nil {
+			return fmt.Errorf("failed to encode extended format: %v", err)
+		}
+	} else {
+		var buf bytes.Buffer
+		if err := tx.Serialize(&buf); err != nil {
+			return fmt.Errorf("failed to serialize transaction: %v", err)
+		}
+		txBytes = buf.Bytes()
 	}
 
-	req.Header.Set("Content-Type", "application/octet-stream")
-	req.Header.Set("User-Agent", "BSV-Enhanced-SDK/1.0.0")
+	return broadcaster.Broadcast(txBytes)
+}
 
-	// Send request
-	resp, err := b.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %v", err)
+// Verify runs every input's scripts under post-Genesis rules. prevOuts holds
+// the output spent by each input, in input order.
+func (b *Builder) Verify(tx *wire.MsgTx, prevOuts []*wire.TxOut) error {
+	if len(prevOuts) != len(tx.TxIn) {
+		return fmt.Errorf("transaction has %d inputs but %d prevouts", len(tx.TxIn), len(prevOuts))
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("broadcast failed with status %d: %s", resp.StatusCode, string(body))
+	for i, txIn := range tx.TxIn {
+		if prevOuts[i] == nil {
+			return fmt.Errorf("input %d has no prevout", i)
+		}
+
+		if err := VerifyScript(txIn.SignatureScript, prevOuts[i].PkScript, tx, i, prevOuts[i].Value, StandardScriptFlags); err != nil {
+			return fmt.Errorf("input %d failed script verification: %w", i, err)
+		}
 	}
 
 	return nil
 }
 
+// prevOuts returns the outputs spent by a transaction's inputs
+func (b *Builder) prevOuts(inputs []types.UTXO) ([]*wire.TxOut, error) {
+	network := b.getNetwork()
+
+	prevOuts := make([]*wire.TxOut, len(inputs))
+	for i, input := range inputs {
+		script, err := inputScript(input, network)
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %v", i, err)
+		}
+		prevOuts[i] = wire.NewTxOut(input.Value, script)
+	}
+
+	return prevOuts, nil
+}
+
 // ClearUTXOCache clears the UTXO cache
 func (b *Builder) ClearUTXOCache() {
 	b.utxoManager.ClearCache()
@@ -428,16 +1050,13 @@ func (b *Builder) ClearUTXOCacheForAddress(address string) {
 	b.utxoManager.ClearCacheForAddress(address)
 }
 
-func (b *Builder) calculateTransactionResult(tx *wire.MsgTx, params *types.TransactionParams, txID string, txBytes []byte) (*types.TransactionResult, error) {
+func (b *Builder) calculateTransactionResult(tx *wire.MsgTx, params *types.TransactionParams, selectedUTXOs []types.UTXO, changePath, txID string, txBytes []byte) (*types.TransactionResult, error) {
 	networkConfig := b.configManager.GetNetworkConfig()
 
-	// Get UTXOs used as inputs
+	// UTXOs used as inputs
 	var inputsUsed []*types.UTXO
-	selectedUTXOs, _, err := b.utxoManager.SelectUTXOs(params.From, params.Amount, params.FeeRate)
-	if err == nil {
-		for _, utxo := range selectedUTXOs {
-			inputsUsed = append(inputsUsed, &utxo)
-		}
+	for i := range selectedUTXOs {
+		inputsUsed = append(inputsUsed, &selectedUTXOs[i])
 	}
 
 	// Calculate outputs created
@@ -446,7 +1065,7 @@ func (b *Builder) calculateTransactionResult(tx *wire.MsgTx, params *types.Trans
 		output := &types.TransactionOutput{
 			Amount:       txOut.Value,
 			ScriptPubKey: hex.EncodeToString(txOut.PkScript),
-			IsData:       false, // Would need to check if it's OP_RETURN
+			IsData:       isDataScript(txOut.PkScript),
 		}
 		outputsCreated = append(outputsCreated, output)
 	}
@@ -457,12 +1076,20 @@ func (b *Builder) calculateTransactionResult(tx *wire.MsgTx, params *types.Trans
 		totalInput += utxo.Value
 	}
 
-	fee := totalInput - params.Amount
-	var change int64
-	if len(tx.TxOut) > 1 {
-		change = tx.TxOut[1].Value
-		fee = totalInput - params.Amount - change
+	// Change is the last output when it pays back to the sender or to a
+	// derived change address, after any token and data outputs
+	var totalOutput, change int64
+	for _, txOut := range tx.TxOut {
+		totalOutput += txOut.Value
+	}
+	if last := len(tx.TxOut) - 1; last > 0 {
+		senderScript, err := payToAddressScript(params.From, b.getNetwork())
+		if changePath != "" || (err == nil && bytes.Equal(tx.TxOut[last].PkScript, senderScript)) {
+			change = tx.TxOut[last].Value
+			outputsCreated[last].DerivationPath = changePath
+		}
 	}
+	fee := totalInput - totalOutput
 
 	// Create explorer URL
 	explorerURL := fmt.Sprintf("%s/tx/%s", networkConfig.ExplorerURL, txID)
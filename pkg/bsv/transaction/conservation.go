@@ -0,0 +1,72 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// TokenBalanceError is returned when a transaction's outputs hold a different
+// amount of a token than its inputs
+type TokenBalanceError struct {
+	TokenID string
+	Inputs  int64 // Amount held by the inputs
+	Outputs int64 // Amount held by the outputs
+}
+
+// Error implements error
+func (e *TokenBalanceError) Error() string {
+	return fmt.Sprintf("token %s: inputs hold %d but outputs hold %d", e.TokenID, e.Inputs, e.Outputs)
+}
+
+// Unwrap allows errors.Is(err, types.ErrTokensNotConserved)
+func (e *TokenBalanceError) Unwrap() error {
+	return types.ErrTokensNotConserved
+}
+
+// checkTokenConservation fails with a TokenBalanceError unless the outputs of
+// tx hold exactly the tokens its inputs spend, per token ID. Both sides are
+// classified from their locking scripts.
+func (b *Builder) checkTokenConservation(tx *wire.MsgTx, inputs []types.UTXO) error {
+	var tokenIDs []string
+	balances := make(map[string]*TokenBalanceError)
+	balance := func(tokenID string) *TokenBalanceError {
+		if balances[tokenID] == nil {
+			tokenIDs = append(tokenIDs, tokenID)
+			balances[tokenID] = &TokenBalanceError{TokenID: tokenID}
+		}
+		return balances[tokenID]
+	}
+
+	for _, input := range inputs {
+		b.utxoManager.ClassifyUTXO(&input)
+		if input.TokenID != "" {
+			balance(input.TokenID).Inputs += input.TokenAmount
+		}
+	}
+
+	txID := tx.TxHash().String()
+	for vout, txOut := range tx.TxOut {
+		output := types.UTXO{
+			TxID:         txID,
+			Vout:         uint32(vout),
+			Value:        txOut.Value,
+			ScriptPubKey: hex.EncodeToString(txOut.PkScript),
+		}
+		b.utxoManager.ClassifyUTXO(&output)
+		if output.TokenID != "" {
+			balance(output.TokenID).Outputs += output.TokenAmount
+		}
+	}
+
+	for _, tokenID := range tokenIDs {
+		if e := balances[tokenID]; e.Inputs != e.Outputs {
+			return e
+		}
+	}
+
+	return nil
+}
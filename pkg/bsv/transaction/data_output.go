@@ -0,0 +1,123 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// DataOutputPushes returns the elements a data output pushes, in order
+func DataOutputPushes(output *types.DataOutput) ([][]byte, error) {
+	var pushes [][]byte
+
+	if output.Data != "" {
+		data, err := hex.DecodeString(output.Data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data output hex: %v", err)
+		}
+		pushes = append(pushes, data)
+	}
+
+	for i, push := range output.Pushes {
+		set := 0
+		for _, isSet := range []bool{push.Hex != "", push.Text != "", push.Bytes != nil} {
+			if isSet {
+				set++
+			}
+		}
+		if set > 1 {
+			return nil, fmt.Errorf("push %d sets more than one of hex, text and bytes", i)
+		}
+
+		switch {
+		case push.Hex != "":
+			data, err := hex.DecodeString(push.Hex)
+			if err != nil {
+				return nil, fmt.Errorf("push %d: invalid hex: %v", i, err)
+			}
+			pushes = append(pushes, data)
+		case push.Text != "":
+			if !utf8.ValidString(push.Text) {
+				return nil, fmt.Errorf("push %d: text is not valid UTF-8", i)
+			}
+			pushes = append(pushes, []byte(push.Text))
+		default:
+			pushes = append(pushes, push.Bytes)
+		}
+	}
+
+	if len(pushes) == 0 {
+		return nil, fmt.Errorf("data output is empty")
+	}
+
+	return pushes, nil
+}
+
+// DataOutputScript returns the OP_FALSE OP_RETURN locking script of a data output
+func DataOutputScript(output *types.DataOutput) ([]byte, error) {
+	pushes, err := DataOutputPushes(output)
+	if err != nil {
+		return nil, err
+	}
+
+	return (&OpReturnTemplate{Data: pushes}).Lock()
+}
+
+// ParseDataScript returns the elements an OP_RETURN data output pushes. It
+// accepts both OP_FALSE OP_RETURN and bare OP_RETURN scripts.
+func ParseDataScript(script []byte) ([][]byte, error) {
+	if !isDataScript(script) {
+		return nil, fmt.Errorf("not a data output")
+	}
+
+	pc := 1
+	if script[0] == txscript.OP_FALSE {
+		pc = 2
+	}
+
+	var pushes [][]byte
+	for pc < len(script) {
+		op, err := parseOpcode(script, pc)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case op.opcode == txscript.OP_0:
+			pushes = append(pushes, []byte{})
+		case op.opcode <= txscript.OP_PUSHDATA4:
+			pushes = append(pushes, op.data)
+		default:
+			return nil, fmt.Errorf("data output contains opcode 0x%02x", op.opcode)
+		}
+		pc = op.next
+	}
+
+	return pushes, nil
+}
+
+// dataOutputsSize returns the serialized size of data outputs, for fee estimation
+func dataOutputsSize(outputs []*types.DataOutput) (int, error) {
+	size := 0
+	for i, output := range outputs {
+		script, err := DataOutputScript(output)
+		if err != nil {
+			return 0, fmt.Errorf("data output %d: %v", i, err)
+		}
+		size += wire.NewTxOut(0, script).SerializeSize()
+	}
+	return size, nil
+}
+
+// isDataScript reports whether a locking script is an OP_RETURN data carrier
+func isDataScript(script []byte) bool {
+	if len(script) > 0 && script[0] == txscript.OP_RETURN {
+		return true
+	}
+	return len(script) > 1 && script[0] == txscript.OP_FALSE && script[1] == txscript.OP_RETURN
+}
@@ -0,0 +1,168 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// extendedFormatMarker follows the version in Extended Format (BRC-30)
+// transactions. A raw transaction can never start this way since it would
+// have zero inputs followed by an impossible output count.
+var extendedFormatMarker = []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0xef}
+
+// maxScriptSize bounds script lengths read while decoding
+const maxScriptSize = 100000000
+
+// IsExtendedFormat reports whether serialized transaction bytes use the Extended Format
+func IsExtendedFormat(data []byte) bool {
+	return len(data) >= 10 && bytes.Equal(data[4:10], extendedFormatMarker)
+}
+
+// EncodeExtendedFormat serializes a transaction in the Extended Format (BRC-30).
+// prevOuts holds the output spent by each input, in input order.
+func EncodeExtendedFormat(tx *wire.MsgTx, prevOuts []*wire.TxOut) ([]byte, error) {
+	if len(prevOuts) != len(tx.TxIn) {
+		return nil, fmt.Errorf("transaction has %d inputs but %d prevouts", len(tx.TxIn), len(prevOuts))
+	}
+
+	var buf bytes.Buffer
+	var scratch [8]byte
+
+	binary.LittleEndian.PutUint32(scratch[:4], uint32(tx.Version))
+	buf.Write(scratch[:4])
+	buf.Write(extendedFormatMarker)
+
+	if err := wire.WriteVarInt(&buf, 0, uint64(len(tx.TxIn))); err != nil {
+		return nil, err
+	}
+
+	for i, txIn := range tx.TxIn {
+		if prevOuts[i] == nil {
+			return nil, fmt.Errorf("prevout %d is missing", i)
+		}
+
+		buf.Write(txIn.PreviousOutPoint.Hash[:])
+		binary.LittleEndian.PutUint32(scratch[:4], txIn.PreviousOutPoint.Index)
+		buf.Write(scratch[:4])
+		if err := wire.WriteVarBytes(&buf, 0, txIn.SignatureScript); err != nil {
+			return nil, err
+		}
+		binary.LittleEndian.PutUint32(scratch[:4], txIn.Sequence)
+		buf.Write(scratch[:4])
+
+		// Extension: the satoshis and locking script of the spent output
+		binary.LittleEndian.PutUint64(scratch[:], uint64(prevOuts[i].Value))
+		buf.Write(scratch[:])
+		if err := wire.WriteVarBytes(&buf, 0, prevOuts[i].PkScript); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := wire.WriteVarInt(&buf, 0, uint64(len(tx.TxOut))); err != nil {
+		return nil, err
+	}
+
+	for _, txOut := range tx.TxOut {
+		if err := wire.WriteTxOut(&buf, 0, 0, txOut); err != nil {
+			return nil, err
+		}
+	}
+
+	binary.LittleEndian.PutUint32(scratch[:4], tx.LockTime)
+	buf.Write(scratch[:4])
+
+	return buf.Bytes(), nil
+}
+
+// DecodeExtendedFormat parses an Extended Format transaction, returning the
+// transaction and the outputs spent by its inputs
+func DecodeExtendedFormat(data []byte) (*wire.MsgTx, []*wire.TxOut, error) {
+	if !IsExtendedFormat(data) {
+		return nil, nil, fmt.Errorf("not an extended format transaction")
+	}
+
+	r := bytes.NewReader(data[10:])
+	tx := wire.NewMsgTx(int32(binary.LittleEndian.Uint32(data[:4])))
+
+	inputCount, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read input count: %v", err)
+	}
+	if inputCount > uint64(r.Len()) {
+		return nil, nil, fmt.Errorf("input count %d exceeds transaction size", inputCount)
+	}
+
+	prevOuts := make([]*wire.TxOut, 0, inputCount)
+	for i := uint64(0); i < inputCount; i++ {
+		var hash chainhash.Hash
+		if _, err := io.ReadFull(r, hash[:]); err != nil {
+			return nil, nil, fmt.Errorf("failed to read input %d: %v", i, err)
+		}
+
+		var index, sequence uint32
+		if err := binary.Read(r, binary.LittleEndian, &index); err != nil {
+			return nil, nil, fmt.Errorf("failed to read input %d: %v", i, err)
+		}
+
+		sigScript, err := wire.ReadVarBytes(r, 0, maxScriptSize, "signature script")
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read input %d: %v", i, err)
+		}
+
+		if err := binary.Read(r, binary.LittleEndian, &sequence); err != nil {
+			return nil, nil, fmt.Errorf("failed to read input %d: %v", i, err)
+		}
+
+		var value uint64
+		if err := binary.Read(r, binary.LittleEndian, &value); err != nil {
+			return nil, nil, fmt.Errorf("failed to read prevout %d: %v", i, err)
+		}
+
+		pkScript, err := wire.ReadVarBytes(r, 0, maxScriptSize, "locking script")
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read prevout %d: %v", i, err)
+		}
+
+		txIn := wire.NewTxIn(wire.NewOutPoint(&hash, index), sigScript, nil)
+		txIn.Sequence = sequence
+		tx.AddTxIn(txIn)
+		prevOuts = append(prevOuts, wire.NewTxOut(int64(value), pkScript))
+	}
+
+	outputCount, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read output count: %v", err)
+	}
+	if outputCount > uint64(r.Len()) {
+		return nil, nil, fmt.Errorf("output count %d exceeds transaction size", outputCount)
+	}
+
+	for i := uint64(0); i < outputCount; i++ {
+		var value uint64
+		if err := binary.Read(r, binary.LittleEndian, &value); err != nil {
+			return nil, nil, fmt.Errorf("failed to read output %d: %v", i, err)
+		}
+
+		pkScript, err := wire.ReadVarBytes(r, 0, maxScriptSize, "locking script")
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read output %d: %v", i, err)
+		}
+
+		tx.AddTxOut(wire.NewTxOut(int64(value), pkScript))
+	}
+
+	if err := binary.Read(r, binary.LittleEndian, &tx.LockTime); err != nil {
+		return nil, nil, fmt.Errorf("failed to read lock time: %v", err)
+	}
+
+	if r.Len() != 0 {
+		return nil, nil, fmt.Errorf("%d trailing bytes after transaction", r.Len())
+	}
+
+	return tx, prevOuts, nil
+}
@@ -0,0 +1,261 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// PolicyFormat identifies the wire format of a miner fee policy endpoint
+type PolicyFormat string
+
+const (
+	PolicyFormatARC  PolicyFormat = "arc"  // ARC GET /v1/policy
+	PolicyFormatMAPI PolicyFormat = "mapi" // mAPI GET /mapi/feeQuote
+)
+
+// DefaultFeeQuoteTTL is used when a policy endpoint does not advertise an expiry
+const DefaultFeeQuoteTTL = 10 * time.Minute
+
+// FeeRate expresses a miner fee as satoshis charged per number of bytes
+type FeeRate = types.FeeRate
+
+// FeeQuote represents the fee policy advertised by a miner
+type FeeQuote struct {
+	Standard FeeRate   `json:"standard"` // Rate for regular transaction bytes
+	Data     FeeRate   `json:"data"`     // Rate for data carrier (OP_RETURN) bytes
+	Expiry   time.Time `json:"expiry"`   // When the quote stops being valid
+}
+
+// IsExpired reports whether the quote is past its expiry
+func (q *FeeQuote) IsExpired() bool {
+	return !q.Expiry.IsZero() && time.Now().After(q.Expiry)
+}
+
+// DataRate returns the rate for data carrier bytes, which is the standard
+// rate when the miner does not advertise one
+func (q *FeeQuote) DataRate() FeeRate {
+	if q.Data.IsSet() {
+		return q.Data
+	}
+	return q.Standard
+}
+
+// FeeQuoteProvider supplies the fee policy used when building transactions
+type FeeQuoteProvider interface {
+	GetFeeQuote() (*FeeQuote, error)
+}
+
+// StaticFeeQuoteProvider returns a fixed fee quote
+type StaticFeeQuoteProvider struct {
+	quote *FeeQuote
+}
+
+// NewStaticFeeQuoteProvider creates a provider that always quotes the given
+// satoshi-per-byte rate for both standard and data bytes
+func NewStaticFeeQuoteProvider(satoshisPerByte int64) *StaticFeeQuoteProvider {
+	rate := types.SatoshisPerByte(satoshisPerByte)
+	return &StaticFeeQuoteProvider{
+		quote: &FeeQuote{Standard: rate, Data: rate},
+	}
+}
+
+// NewStaticFeeQuoteProviderWithQuote creates a provider that always returns quote
+func NewStaticFeeQuoteProviderWithQuote(quote *FeeQuote) *StaticFeeQuoteProvider {
+	return &StaticFeeQuoteProvider{quote: quote}
+}
+
+// GetFeeQuote returns the static fee quote
+func (p *StaticFeeQuoteProvider) GetFeeQuote() (*FeeQuote, error) {
+	quote := *p.quote
+	return &quote, nil
+}
+
+// PolicyFeeQuoteProvider fetches fee quotes from an ARC or mAPI policy endpoint
+// and caches them until they expire
+type PolicyFeeQuoteProvider struct {
+	baseURL    string
+	format     PolicyFormat
+	apiKey     string
+	ttl        time.Duration
+	httpClient *http.Client
+	cached     *FeeQuote
+	mutex      sync.Mutex
+}
+
+// arcPolicyResponse represents the response from ARC GET /v1/policy
+type arcPolicyResponse struct {
+	Policy struct {
+		MiningFee *FeeRate `json:"miningFee"`
+	} `json:"policy"`
+}
+
+// mapiEnvelope represents the signed JSON envelope returned by mAPI
+type mapiEnvelope struct {
+	Payload string `json:"payload"`
+}
+
+// mapiFeeQuotePayload represents the payload of mAPI GET /mapi/feeQuote
+type mapiFeeQuotePayload struct {
+	ExpiryTime time.Time `json:"expiryTime"`
+	Fees       []struct {
+		FeeType   string   `json:"feeType"`
+		MiningFee *FeeRate `json:"miningFee"`
+	} `json:"fees"`
+}
+
+// NewARCFeeQuoteProvider creates a provider for an ARC broadcaster base URL
+func NewARCFeeQuoteProvider(baseURL string) *PolicyFeeQuoteProvider {
+	return NewPolicyFeeQuoteProvider(baseURL, PolicyFormatARC)
+}
+
+// NewMAPIFeeQuoteProvider creates a provider for a mAPI base URL
+func NewMAPIFeeQuoteProvider(baseURL string) *PolicyFeeQuoteProvider {
+	return NewPolicyFeeQuoteProvider(baseURL, PolicyFormatMAPI)
+}
+
+// NewPolicyFeeQuoteProvider creates a provider for a policy endpoint of the given format
+func NewPolicyFeeQuoteProvider(baseURL string, format PolicyFormat) *PolicyFeeQuoteProvider {
+	return &PolicyFeeQuoteProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		format:  format,
+		ttl:     DefaultFeeQuoteTTL,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// SetAPIKey sets the bearer token sent with policy requests
+func (p *PolicyFeeQuoteProvider) SetAPIKey(apiKey string) {
+	p.apiKey = apiKey
+}
+
+// SetTTL sets how long a quote without an advertised expiry is cached
+func (p *PolicyFeeQuoteProvider) SetTTL(ttl time.Duration) {
+	p.ttl = ttl
+}
+
+// GetFeeQuote returns the cached quote, fetching a fresh one once it has expired
+func (p *PolicyFeeQuoteProvider) GetFeeQuote() (*FeeQuote, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.cached != nil && !p.cached.IsExpired() {
+		quote := *p.cached
+		return &quote, nil
+	}
+
+	quote, err := p.fetchQuote()
+	if err != nil {
+		return nil, err
+	}
+
+	p.cached = quote
+	result := *quote
+	return &result, nil
+}
+
+func (p *PolicyFeeQuoteProvider) fetchQuote() (*FeeQuote, error) {
+	var path string
+	switch p.format {
+	case PolicyFormatARC:
+		path = "/v1/policy"
+	case PolicyFormatMAPI:
+		path = "/mapi/feeQuote"
+	default:
+		return nil, fmt.Errorf("unsupported policy format: %s", p.format)
+	}
+
+	req, err := http.NewRequest("GET", p.baseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "BSV-Enhanced-SDK/1.0.0")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch fee quote: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fee quote: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fee quote request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if p.format == PolicyFormatARC {
+		return p.parseARCPolicy(body)
+	}
+	return p.parseMAPIFeeQuote(body)
+}
+
+func (p *PolicyFeeQuoteProvider) parseARCPolicy(body []byte) (*FeeQuote, error) {
+	var policy arcPolicyResponse
+	if err := json.Unmarshal(body, &policy); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ARC policy: %v", err)
+	}
+
+	if policy.Policy.MiningFee == nil || policy.Policy.MiningFee.Bytes <= 0 {
+		return nil, fmt.Errorf("ARC policy has no mining fee")
+	}
+
+	// ARC advertises a single mining fee that applies to all bytes
+	return &FeeQuote{
+		Standard: *policy.Policy.MiningFee,
+		Data:     *policy.Policy.MiningFee,
+		Expiry:   time.Now().Add(p.ttl),
+	}, nil
+}
+
+func (p *PolicyFeeQuoteProvider) parseMAPIFeeQuote(body []byte) (*FeeQuote, error) {
+	var envelope mapiEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal mAPI envelope: %v", err)
+	}
+
+	var payload mapiFeeQuotePayload
+	if err := json.Unmarshal([]byte(envelope.Payload), &payload); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal mAPI fee quote: %v", err)
+	}
+
+	quote := &FeeQuote{Expiry: payload.ExpiryTime}
+	for _, fee := range payload.Fees {
+		if fee.MiningFee == nil {
+			continue
+		}
+		switch fee.FeeType {
+		case "standard":
+			quote.Standard = *fee.MiningFee
+		case "data":
+			quote.Data = *fee.MiningFee
+		}
+	}
+
+	if quote.Standard.Bytes <= 0 {
+		return nil, fmt.Errorf("mAPI fee quote has no standard fee")
+	}
+	if quote.Data.Bytes <= 0 {
+		quote.Data = quote.Standard
+	}
+	if quote.Expiry.IsZero() {
+		quote.Expiry = time.Now().Add(p.ttl)
+	}
+
+	return quote, nil
+}
@@ -0,0 +1,100 @@
+package transaction
+
+import (
+	"fmt"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// fundingKey is an extra address funding a transaction, with its signer when
+// the builder knows it
+type fundingKey struct {
+	address string
+	signer  wallet.Signer
+}
+
+// fundingKeys resolves the extra funding addresses of params, in the order
+// FundingAddresses, FundingKeys, FundingPaths. Keyring addresses without a
+// signer are kept, since unsigned transactions can still spend from them.
+// FundingPaths are derived from account, the sender's HD account.
+func (b *Builder) fundingKeys(params *types.TransactionParams, account *wallet.HDAccount) ([]fundingKey, error) {
+	var keys []fundingKey
+	for _, address := range params.FundingAddresses {
+		signer, _ := b.keyring.Signer(address)
+		keys = append(keys, fundingKey{address: address, signer: signer})
+	}
+
+	for i, privateKey := range params.FundingKeys {
+		address, keyPair, _, err := b.getSenderInfo(privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("funding key %d: %v", i, err)
+		}
+		keys = append(keys, fundingKey{address: address, signer: wallet.NewKeySigner(keyPair)})
+	}
+
+	if len(params.FundingPaths) == 0 {
+		return keys, nil
+	}
+
+	if account == nil {
+		return nil, fmt.Errorf("funding paths require a mnemonic or extended private key")
+	}
+
+	for i, path := range params.FundingPaths {
+		if path == nil {
+			return nil, fmt.Errorf("funding path %d is empty", i)
+		}
+
+		signer, err := wallet.NewHDSigner(account, path.Chain, path.Index)
+		if err != nil {
+			return nil, fmt.Errorf("funding path %d: %v", i, err)
+		}
+		address, err := wallet.SignerAddress(signer, b.getNetwork())
+		if err != nil {
+			return nil, fmt.Errorf("funding path %d: %v", i, err)
+		}
+		keys = append(keys, fundingKey{address: address, signer: signer})
+	}
+
+	return keys, nil
+}
+
+// fundingAddresses returns the sender followed by each extra address funding
+// a transaction, once each
+func (b *Builder) fundingAddresses(params *types.TransactionParams, account *wallet.HDAccount) ([]string, error) {
+	keys, err := b.fundingKeys(params, account)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := []string{params.From}
+	seen := map[string]bool{params.From: true}
+	for _, key := range keys {
+		if !seen[key.address] {
+			seen[key.address] = true
+			addresses = append(addresses, key.address)
+		}
+	}
+
+	return addresses, nil
+}
+
+// fundingSigners returns the signers of inputs from the extra funding
+// addresses
+func (b *Builder) fundingSigners(params *types.TransactionParams, account *wallet.HDAccount) ([]wallet.Signer, error) {
+	keys, err := b.fundingKeys(params, account)
+	if err != nil {
+		return nil, err
+	}
+
+	signers := make([]wallet.Signer, 0, len(keys))
+	for _, key := range keys {
+		if key.signer == nil {
+			return nil, fmt.Errorf("no key in the keyring for funding address %s", key.address)
+		}
+		signers = append(signers, key.signer)
+	}
+
+	return signers, nil
+}
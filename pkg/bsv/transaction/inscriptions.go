@@ -0,0 +1,404 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/ordinals"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// InscriptionOutput is an inscription found in a transaction output
+type InscriptionOutput struct {
+	ID    string // "<txid>_<vout>" of the output, identifying the inscription
+	Vout  uint32
+	Owner string // Address of the P2PKH script after the envelope, if any
+	Value int64
+	*ordinals.Inscription
+}
+
+// BuildInscription builds and signs a transaction inscribing each file into
+// its own 1-satoshi output, in order, followed by change
+func (b *Builder) BuildInscription(params *types.InscriptionParams) (*wire.MsgTx, error) {
+	signer, account, err := b.keySigner(params.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	unsigned, err := b.buildInscription(params, signer, account)
+	if err != nil {
+		return nil, err
+	}
+	return unsigned.Tx, nil
+}
+
+// BuildInscriptionWithSigner builds an inscription transaction signed by
+// signer rather than params.PrivateKey, which must be empty
+func (b *Builder) BuildInscriptionWithSigner(params *types.InscriptionParams, signer wallet.Signer) (*wire.MsgTx, error) {
+	account, err := signerAccount(params.PrivateKey, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	unsigned, err := b.buildInscription(params, signer, account)
+	if err != nil {
+		return nil, err
+	}
+	return unsigned.Tx, nil
+}
+
+// SignAndSendInscription builds, signs, and broadcasts an inscription
+// transaction. Output i holds inscription i, with ID "<txid>_<i>".
+func (b *Builder) SignAndSendInscription(params *types.InscriptionParams) (*types.TransactionResult, error) {
+	signer, account, err := b.keySigner(params.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build inscription: %w", err)
+	}
+
+	return b.signAndSendInscription(params, signer, account)
+}
+
+// SignAndSendInscriptionWithSigner builds, signs with signer, and broadcasts
+// an inscription transaction. params.PrivateKey must be empty.
+func (b *Builder) SignAndSendInscriptionWithSigner(params *types.InscriptionParams, signer wallet.Signer) (*types.TransactionResult, error) {
+	account, err := signerAccount(params.PrivateKey, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build inscription: %w", err)
+	}
+
+	return b.signAndSendInscription(params, signer, account)
+}
+
+func (b *Builder) signAndSendInscription(params *types.InscriptionParams, signer wallet.Signer, account *wallet.HDAccount) (*types.TransactionResult, error) {
+	unsigned, err := b.buildInscription(params, signer, account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build inscription: %w", err)
+	}
+
+	return b.sendOrdinalTransaction(unsigned)
+}
+
+// BuildOrdinalTransfer builds and signs a transaction sending 1-satoshi
+// ordinals to new owners. Ordinal i is spent by input i and lands in output
+// i, so each tracked satoshi keeps its position; funding inputs and change
+// come after them.
+func (b *Builder) BuildOrdinalTransfer(params *types.OrdinalTransferParams) (*wire.MsgTx, error) {
+	signer, account, err := b.keySigner(params.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	unsigned, err := b.buildOrdinalTransfer(params, signer, account)
+	if err != nil {
+		return nil, err
+	}
+	return unsigned.Tx, nil
+}
+
+// BuildOrdinalTransferWithSigner builds an ordinal transfer signed by signer
+// rather than params.PrivateKey, which must be empty
+func (b *Builder) BuildOrdinalTransferWithSigner(params *types.OrdinalTransferParams, signer wallet.Signer) (*wire.MsgTx, error) {
+	account, err := signerAccount(params.PrivateKey, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	unsigned, err := b.buildOrdinalTransfer(params, signer, account)
+	if err != nil {
+		return nil, err
+	}
+	return unsigned.Tx, nil
+}
+
+// SignAndSendOrdinalTransfer builds, signs, and broadcasts an ordinal transfer
+func (b *Builder) SignAndSendOrdinalTransfer(params *types.OrdinalTransferParams) (*types.TransactionResult, error) {
+	signer, account, err := b.keySigner(params.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build ordinal transfer: %w", err)
+	}
+
+	return b.signAndSendOrdinalTransfer(params, signer, account)
+}
+
+// SignAndSendOrdinalTransferWithSigner builds, signs with signer, and
+// broadcasts an ordinal transfer. params.PrivateKey must be empty.
+func (b *Builder) SignAndSendOrdinalTransferWithSigner(params *types.OrdinalTransferParams, signer wallet.Signer) (*types.TransactionResult, error) {
+	account, err := signerAccount(params.PrivateKey, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build ordinal transfer: %w", err)
+	}
+
+	return b.signAndSendOrdinalTransfer(params, signer, account)
+}
+
+func (b *Builder) signAndSendOrdinalTransfer(params *types.OrdinalTransferParams, signer wallet.Signer, account *wallet.HDAccount) (*types.TransactionResult, error) {
+	unsigned, err := b.buildOrdinalTransfer(params, signer, account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build ordinal transfer: %w", err)
+	}
+
+	return b.sendOrdinalTransaction(unsigned)
+}
+
+// ParseInscriptions returns the inscriptions in a transaction's outputs
+func (b *Builder) ParseInscriptions(tx *wire.MsgTx) []*InscriptionOutput {
+	network := b.getNetwork()
+	txID := tx.TxHash().String()
+
+	var found []*InscriptionOutput
+	for vout, txOut := range tx.TxOut {
+		inscription, ownerScript, ok := ordinals.Parse(txOut.PkScript)
+		if !ok {
+			continue
+		}
+
+		output := &InscriptionOutput{
+			ID:          fmt.Sprintf("%s_%d", txID, vout),
+			Vout:        uint32(vout),
+			Value:       txOut.Value,
+			Inscription: inscription,
+		}
+		if class, addresses, _, err := txscript.ExtractPkScriptAddrs(ownerScript, network); err == nil && class == txscript.PubKeyHashTy {
+			output.Owner = addresses[0].EncodeAddress()
+		}
+		found = append(found, output)
+	}
+
+	return found
+}
+
+// ParseInscriptionsHex returns the inscriptions in a hex encoded transaction
+func (b *Builder) ParseInscriptionsHex(txHex string) ([]*InscriptionOutput, error) {
+	raw, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction hex: %v", err)
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		return nil, fmt.Errorf("failed to decode transaction: %v", err)
+	}
+
+	return b.ParseInscriptions(tx), nil
+}
+
+func (b *Builder) buildInscription(params *types.InscriptionParams, signer wallet.Signer, account *wallet.HDAccount) (*UnsignedTransaction, error) {
+	if len(params.Inscriptions) == 0 {
+		return nil, fmt.Errorf("at least one inscription is required")
+	}
+
+	quote, err := b.ordinalSender(params.From, signer, params.FeeRate)
+	if err != nil {
+		return nil, err
+	}
+
+	network := b.getNetwork()
+	var outputs []*wire.TxOut
+	var dataSize int
+	for i, inscription := range params.Inscriptions {
+		if inscription.ContentType == "" {
+			return nil, fmt.Errorf("inscription %d: content type is required", i)
+		}
+
+		owner := inscription.To
+		if owner == "" {
+			owner = params.From
+		}
+		ownerScript, err := payToAddressScript(owner, network)
+		if err != nil {
+			return nil, fmt.Errorf("inscription %d: %v", i, err)
+		}
+
+		script, err := (&ordinals.Inscription{ContentType: inscription.ContentType, Body: inscription.Body}).LockingScript(ownerScript)
+		if err != nil {
+			return nil, fmt.Errorf("inscription %d: %v", i, err)
+		}
+		output := wire.NewTxOut(1, script)
+		outputs = append(outputs, output)
+		dataSize += output.SerializeSize()
+	}
+
+	return b.buildOrdinalTransaction(params.From, signer, account, quote, dataSize, nil, outputs)
+}
+
+func (b *Builder) buildOrdinalTransfer(params *types.OrdinalTransferParams, signer wallet.Signer, account *wallet.HDAccount) (*UnsignedTransaction, error) {
+	if len(params.Transfers) == 0 {
+		return nil, fmt.Errorf("at least one ordinal transfer is required")
+	}
+
+	quote, err := b.ordinalSender(params.From, signer, params.FeeRate)
+	if err != nil {
+		return nil, err
+	}
+
+	owned, err := b.utxoManager.GetUTXOs(params.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get UTXOs: %v", err)
+	}
+
+	network := b.getNetwork()
+	var ordinalInputs []types.UTXO
+	var outputs []*wire.TxOut
+	for i, transfer := range params.Transfers {
+		for _, input := range ordinalInputs {
+			if input.TxID == transfer.TxID && input.Vout == transfer.Vout {
+				return nil, fmt.Errorf("ordinal transfer %d: %s_%d is transferred twice", i, transfer.TxID, transfer.Vout)
+			}
+		}
+
+		input, err := findOrdinal(owned, transfer)
+		if err != nil {
+			return nil, fmt.Errorf("ordinal transfer %d: %v", i, err)
+		}
+
+		script, err := payToAddressScript(transfer.To, network)
+		if err != nil {
+			return nil, fmt.Errorf("ordinal transfer %d: %v", i, err)
+		}
+
+		ordinalInputs = append(ordinalInputs, input)
+		outputs = append(outputs, wire.NewTxOut(1, script))
+	}
+
+	return b.buildOrdinalTransaction(params.From, signer, account, quote, 0, ordinalInputs, outputs)
+}
+
+// ordinalSender checks the sender and fee rate of an ordinal transaction,
+// returning the rates to price it at
+func (b *Builder) ordinalSender(from string, signer wallet.Signer, feeRate int64) (*FeeQuote, error) {
+	if from == "" {
+		return nil, fmt.Errorf("sender address is required")
+	}
+	if err := b.validateFeeRate(feeRate); err != nil {
+		return nil, err
+	}
+
+	senderAddress, err := wallet.SignerAddress(signer, b.getNetwork())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sender info: %v", err)
+	}
+	if senderAddress != from {
+		return nil, fmt.Errorf("sender address mismatch: expected %s, got %s", from, senderAddress)
+	}
+
+	return b.feeQuote(feeRate)
+}
+
+// findOrdinal returns the owned UTXO holding a transferred ordinal. Only
+// 1-satoshi outputs can be sent whole without tracking the satoshi's offset.
+func findOrdinal(owned []types.UTXO, transfer *types.OrdinalTransfer) (types.UTXO, error) {
+	if transfer.To == "" {
+		return types.UTXO{}, fmt.Errorf("recipient address is required")
+	}
+
+	for _, u := range owned {
+		if u.TxID != transfer.TxID || u.Vout != transfer.Vout {
+			continue
+		}
+		if u.Value != 1 {
+			return types.UTXO{}, fmt.Errorf("%s_%d holds %d satoshis, not a single ordinal", u.TxID, u.Vout, u.Value)
+		}
+		return u, nil
+	}
+
+	return types.UTXO{}, fmt.Errorf("%s_%d is not an unspent output of the sender", transfer.TxID, transfer.Vout)
+}
+
+// buildOrdinalTransaction spends ordinalInputs into the leading outputs, in
+// order, funds the outputs and fee from the sender's native UTXOs, adds
+// change last and signs every input. dataSize bytes of the outputs are
+// charged at the data rate. account is the sender's HD account, or nil if it
+// has none.
+func (b *Builder) buildOrdinalTransaction(from string, signer wallet.Signer, account *wallet.HDAccount, quote *FeeQuote, dataSize int, ordinalInputs []types.UTXO, outputs []*wire.TxOut) (*UnsignedTransaction, error) {
+	var amount int64
+	var outputsSize int
+	for _, output := range outputs {
+		amount += output.Value
+		outputsSize += output.SerializeSize()
+	}
+
+	selected, fee, err := b.fundInputs([]string{from}, "", quote, amount, outputsSize, dataSize, ordinalInputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select UTXOs: %w", err)
+	}
+
+	network := b.getNetwork()
+	tx := wire.NewMsgTx(wire.TxVersion)
+	inputs := make([]types.UTXO, len(selected))
+	for i, input := range selected {
+		txHash, err := chainhash.NewHashFromStr(input.TxID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UTXO transaction hash: %v", err)
+		}
+
+		script, err := inputScript(input, network)
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %v", i, err)
+		}
+		input.ScriptPubKey = hex.EncodeToString(script)
+		inputs[i] = input
+
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(txHash, input.Vout), nil, nil))
+	}
+
+	for _, output := range outputs {
+		tx.AddTxOut(output)
+	}
+
+	unsigned := &UnsignedTransaction{Tx: tx, Inputs: inputs}
+	if change, hasChange := b.utxoManager.CalculateChange(inputs, amount, fee); hasChange {
+		changeScript, changePath, err := b.changeOutputScript(from, account)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create change script: %v", err)
+		}
+		tx.AddTxOut(wire.NewTxOut(change, changeScript))
+
+		if changePath != "" {
+			unsigned.ChangePath, unsigned.ChangeAccount = changePath, account.ID()
+		}
+	}
+
+	// Transfers must not burn tokens held by the ordinals they move, while
+	// new inscriptions may mint them
+	if len(ordinalInputs) > 0 {
+		if err := b.checkTokenConservation(tx, inputs); err != nil {
+			b.releaseChangeIndex(unsigned.ChangeAccount, unsigned.ChangePath)
+			return nil, err
+		}
+	}
+
+	if err := b.signTransaction(tx, inputs, signer); err != nil {
+		b.releaseChangeIndex(unsigned.ChangeAccount, unsigned.ChangePath)
+		return nil, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+
+	if err := b.checkTransactionSize(tx); err != nil {
+		b.releaseChangeIndex(unsigned.ChangeAccount, unsigned.ChangePath)
+		return nil, err
+	}
+
+	return unsigned, nil
+}
+
+// sendOrdinalTransaction broadcasts a signed ordinal transaction and
+// describes it
+func (b *Builder) sendOrdinalTransaction(unsigned *UnsignedTransaction) (*types.TransactionResult, error) {
+	var buf bytes.Buffer
+	if err := unsigned.Tx.Serialize(&buf); err != nil {
+		b.releaseChangeIndex(unsigned.ChangeAccount, unsigned.ChangePath)
+		return nil, fmt.Errorf("failed to serialize transaction: %v", err)
+	}
+
+	if err := b.broadcastTransaction(unsigned.Tx, unsigned.Inputs); err != nil {
+		b.releaseChangeIndex(unsigned.ChangeAccount, unsigned.ChangePath)
+		return nil, fmt.Errorf("failed to broadcast transaction: %v", err)
+	}
+
+	return b.broadcastResult(unsigned, buf.Bytes()), nil
+}
@@ -0,0 +1,1408 @@
+package transaction
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// ScriptFlags select the rules scripts are evaluated under
+type ScriptFlags uint32
+
+// Script verification flags
+const (
+	ScriptVerifyP2SH ScriptFlags = 1 << iota
+	ScriptVerifyStrictEncoding
+	ScriptVerifyDERSignatures
+	ScriptVerifyLowS
+	ScriptVerifyNullDummy
+	ScriptVerifySigPushOnly
+	ScriptVerifyMinimalData
+	ScriptVerifyDiscourageUpgradableNops
+	ScriptVerifyCleanStack
+	ScriptVerifyCheckLockTimeVerify
+	ScriptVerifyCheckSequenceVerify
+	ScriptVerifyMinimalIf
+	ScriptVerifyNullFail
+	ScriptEnableSighashForkID
+	ScriptUTXOAfterGenesis
+)
+
+// StandardScriptFlags are the rules the builder verifies transactions under:
+// post-Genesis consensus plus the standardness rules miners apply
+const StandardScriptFlags = ScriptVerifyStrictEncoding |
+	ScriptVerifyDERSignatures |
+	ScriptVerifyLowS |
+	ScriptVerifyNullDummy |
+	ScriptVerifySigPushOnly |
+	ScriptVerifyMinimalData |
+	ScriptVerifyDiscourageUpgradableNops |
+	ScriptVerifyCleanStack |
+	ScriptVerifyNullFail |
+	ScriptEnableSighashForkID |
+	ScriptUTXOAfterGenesis
+
+// Limits that only apply to outputs created before Genesis
+const (
+	maxScriptSizeBeforeGenesis  = 10000
+	maxElementSizeBeforeGenesis = 520
+	maxOpsBeforeGenesis         = 201
+	maxStackSizeBeforeGenesis   = 1000
+	maxPubKeysBeforeGenesis     = 20
+)
+
+// Policy limits on the memory a script may use after Genesis, matching the
+// BSV node's default maxstackmemoryusagepolicy. Each stack item counts its
+// size plus a fixed overhead, and no single item may exceed the total.
+const (
+	maxStackMemoryAfterGenesis = 100 * 1000 * 1000
+	maxElementSizeAfterGenesis = maxStackMemoryAfterGenesis
+	stackElementOverhead       = 32
+)
+
+// Lock time constants
+const (
+	lockTimeThreshold          = 500000000
+	sequenceLockTimeDisabled   = 1 << 31
+	sequenceLockTimeTypeFlag   = 1 << 22
+	sequenceLockTimeMask       = 0x0000ffff
+	maxLockTimeScriptNumLength = 5
+	finalSequence              = 0xffffffff
+)
+
+// Opcodes restored by BSV that btcd knows by their original names
+const (
+	opSplit   = txscript.OP_SUBSTR
+	opNum2Bin = txscript.OP_LEFT
+	opBin2Num = txscript.OP_RIGHT
+)
+
+// parsedOpcode is one opcode of a script with its push data
+type parsedOpcode struct {
+	opcode byte
+	data   []byte
+	next   int // Offset of the following opcode
+}
+
+// parseOpcode reads the opcode at offset pc
+func parseOpcode(script []byte, pc int) (parsedOpcode, error) {
+	opcode := script[pc]
+	pc++
+
+	var length int
+	switch {
+	case opcode >= 0x01 && opcode <= 0x4b:
+		length = int(opcode)
+	case opcode == txscript.OP_PUSHDATA1:
+		if pc+1 > len(script) {
+			return parsedOpcode{}, scriptError(ErrBadOpcode, "OP_PUSHDATA1 is missing its length")
+		}
+		length = int(script[pc])
+		pc++
+	case opcode == txscript.OP_PUSHDATA2:
+		if pc+2 > len(script) {
+			return parsedOpcode{}, scriptError(ErrBadOpcode, "OP_PUSHDATA2 is missing its length")
+		}
+		length = int(binary.LittleEndian.Uint16(script[pc:]))
+		pc += 2
+	case opcode == txscript.OP_PUSHDATA4:
+		if pc+4 > len(script) {
+			return parsedOpcode{}, scriptError(ErrBadOpcode, "OP_PUSHDATA4 is missing its length")
+		}
+		length = int(binary.LittleEndian.Uint32(script[pc:]))
+		pc += 4
+	default:
+		return parsedOpcode{opcode: opcode, next: pc}, nil
+	}
+
+	if length < 0 || pc+length > len(script) {
+		return parsedOpcode{}, scriptError(ErrBadOpcode, "push of %d bytes runs past the end of the script", length)
+	}
+
+	return parsedOpcode{opcode: opcode, data: script[pc : pc+length], next: pc + length}, nil
+}
+
+// isPushOnly reports whether a script only pushes data
+func isPushOnly(script []byte) bool {
+	for pc := 0; pc < len(script); {
+		op, err := parseOpcode(script, pc)
+		if err != nil || op.opcode > txscript.OP_16 {
+			return false
+		}
+		pc = op.next
+	}
+	return true
+}
+
+// isP2SH reports whether a locking script is the pay-to-script-hash pattern
+func isP2SH(script []byte) bool {
+	return len(script) == 23 &&
+		script[0] == txscript.OP_HASH160 &&
+		script[1] == 0x14 &&
+		script[22] == txscript.OP_EQUAL
+}
+
+// isDisabledOpcode reports whether an opcode fails even in an unexecuted
+// branch. Genesis restored every other opcode disabled by the original client.
+func isDisabledOpcode(opcode byte) bool {
+	return opcode == txscript.OP_2MUL || opcode == txscript.OP_2DIV
+}
+
+// checkMinimalPush reports whether data was pushed with the smallest opcode
+func checkMinimalPush(data []byte, opcode byte) bool {
+	switch {
+	case len(data) == 0:
+		return opcode == txscript.OP_0
+	case len(data) == 1 && data[0] >= 1 && data[0] <= 16:
+		return opcode == txscript.OP_1+data[0]-1
+	case len(data) == 1 && data[0] == 0x81:
+		return opcode == txscript.OP_1NEGATE
+	case len(data) <= 75:
+		return int(opcode) == len(data)
+	case len(data) <= 255:
+		return opcode == txscript.OP_PUSHDATA1
+	case len(data) <= 65535:
+		return opcode == txscript.OP_PUSHDATA2
+	}
+	return true
+}
+
+// scriptEngine evaluates the scripts of one transaction input
+type scriptEngine struct {
+	tx     *wire.MsgTx
+	index  int
+	amount int64
+	flags  ScriptFlags
+
+	stack       [][]byte
+	altStack    [][]byte
+	stackMemory int // Memory used by both stacks, see elementMemory
+
+	// Per-script state
+	script     []byte
+	codeSepPos int
+	condStack  []bool // Whether each open IF branch is executing
+	elseStack  []bool // Whether each open IF has seen its ELSE
+	returned   bool   // An OP_RETURN inside a branch stopped execution
+	opCount    int
+}
+
+// VerifyScript evaluates an unlocking script against the locking script it
+// spends, returning a *ScriptError when the spend is invalid
+func VerifyScript(unlockingScript, lockingScript []byte, tx *wire.MsgTx, index int, amount int64, flags ScriptFlags) error {
+	if index < 0 || index >= len(tx.TxIn) {
+		return scriptError(ErrInvalidStackOperation, "input index %d out of range", index)
+	}
+
+	// Unlocking scripts must be push-only after Genesis
+	if flags&(ScriptVerifySigPushOnly|ScriptUTXOAfterGenesis) != 0 && !isPushOnly(unlockingScript) {
+		return scriptError(ErrSigPushOnly, "unlocking script is not push-only")
+	}
+
+	e := &scriptEngine{tx: tx, index: index, amount: amount, flags: flags}
+
+	if err := e.eval(unlockingScript); err != nil {
+		return err
+	}
+
+	var stackCopy [][]byte
+	p2sh := flags&ScriptVerifyP2SH != 0 && !e.afterGenesis() && isP2SH(lockingScript)
+	if p2sh {
+		stackCopy = append([][]byte(nil), e.stack...)
+	}
+
+	// Each script starts with an empty alt stack
+	e.clearAltStack()
+	if err := e.eval(lockingScript); err != nil {
+		return err
+	}
+
+	if len(e.stack) == 0 || !castToBool(e.stack[len(e.stack)-1]) {
+		return scriptError(ErrEvalFalse, "script evaluated to false")
+	}
+
+	if p2sh {
+		if !isPushOnly(unlockingScript) {
+			return scriptError(ErrSigPushOnly, "P2SH unlocking script is not push-only")
+		}
+
+		e.stack = stackCopy
+		e.altStack = nil
+		e.stackMemory = 0
+		for _, item := range e.stack {
+			e.stackMemory += elementMemory(item)
+		}
+		redeemScript := e.pop()
+
+		if err := e.eval(redeemScript); err != nil {
+			return err
+		}
+
+		if len(e.stack) == 0 || !castToBool(e.stack[len(e.stack)-1]) {
+			return scriptError(ErrEvalFalse, "redeem script evaluated to false")
+		}
+	}
+
+	if flags&ScriptVerifyCleanStack != 0 && len(e.stack) != 1 {
+		return scriptError(ErrCleanStack, "stack has %d items after evaluation", len(e.stack))
+	}
+
+	return nil
+}
+
+// clearAltStack empties the alt stack, which scripts do not share
+func (e *scriptEngine) clearAltStack() {
+	for _, item := range e.altStack {
+		e.stackMemory -= elementMemory(item)
+	}
+	e.altStack = nil
+}
+
+func (e *scriptEngine) afterGenesis() bool {
+	return e.flags&ScriptUTXOAfterGenesis != 0
+}
+
+func (e *scriptEngine) maxNumLength() int {
+	if e.afterGenesis() {
+		return maxScriptNumLengthAfterGenesis
+	}
+	return maxScriptNumLengthBeforeGenesis
+}
+
+// maxElementSize is the largest stack item a script may create
+func (e *scriptEngine) maxElementSize() int {
+	if e.afterGenesis() {
+		return maxElementSizeAfterGenesis
+	}
+	return maxElementSizeBeforeGenesis
+}
+
+// elementMemory is the memory a stack item counts for
+func elementMemory(item []byte) int {
+	return len(item) + stackElementOverhead
+}
+
+func (e *scriptEngine) requireMinimal() bool {
+	return e.flags&ScriptVerifyMinimalData != 0
+}
+
+// executing reports whether the current opcode runs
+func (e *scriptEngine) executing() bool {
+	if e.returned {
+		return false
+	}
+	for _, exec := range e.condStack {
+		if !exec {
+			return false
+		}
+	}
+	return true
+}
+
+// eval runs one script against the current stack
+func (e *scriptEngine) eval(script []byte) error {
+	if !e.afterGenesis() && len(script) > maxScriptSizeBeforeGenesis {
+		return scriptError(ErrScriptSize, "script is %d bytes", len(script))
+	}
+
+	e.script = script
+	e.codeSepPos = 0
+	e.condStack = nil
+	e.elseStack = nil
+	e.returned = false
+	e.opCount = 0
+
+	for pc := 0; pc < len(script); {
+		op, err := parseOpcode(script, pc)
+		if err != nil {
+			return err
+		}
+		pc = op.next
+
+		exec := e.executing()
+
+		if !e.afterGenesis() && len(op.data) > maxElementSizeBeforeGenesis {
+			return scriptError(ErrPushSize, "push of %d bytes", len(op.data))
+		}
+
+		if op.opcode > txscript.OP_16 {
+			e.opCount++
+			if !e.afterGenesis() && e.opCount > maxOpsBeforeGenesis {
+				return scriptError(ErrOpCount, "more than %d opcodes", maxOpsBeforeGenesis)
+			}
+		}
+
+		if isDisabledOpcode(op.opcode) {
+			return scriptError(ErrDisabledOpcode, "opcode %#x is disabled", op.opcode)
+		}
+
+		if exec && op.opcode <= txscript.OP_PUSHDATA4 {
+			if e.requireMinimal() && !checkMinimalPush(op.data, op.opcode) {
+				return scriptError(ErrMinimalData, "push of %d bytes is not minimal", len(op.data))
+			}
+			e.push(append([]byte(nil), op.data...))
+		} else if exec || (op.opcode >= txscript.OP_IF && op.opcode <= txscript.OP_ENDIF) {
+			stop, err := e.execute(op, pc, exec)
+			if err != nil {
+				return err
+			}
+			if stop {
+				// A top-level OP_RETURN after Genesis ends the script
+				return nil
+			}
+		}
+
+		if !e.afterGenesis() && len(e.stack)+len(e.altStack) > maxStackSizeBeforeGenesis {
+			return scriptError(ErrStackSize, "stack has more than %d items", maxStackSizeBeforeGenesis)
+		}
+		if e.stackMemory > maxStackMemoryAfterGenesis {
+			return scriptError(ErrStackSize, "stacks use more than %d bytes", maxStackMemoryAfterGenesis)
+		}
+	}
+
+	if len(e.condStack) != 0 {
+		return scriptError(ErrUnbalancedConditional, "unterminated IF")
+	}
+
+	return nil
+}
+
+func (e *scriptEngine) push(data []byte) {
+	e.stack = append(e.stack, data)
+	e.stackMemory += elementMemory(data)
+}
+
+func (e *scriptEngine) pushNum(n *big.Int) {
+	e.push(encodeScriptNum(n))
+}
+
+// peek returns the item depth positions from the top (0 is the top)
+func (e *scriptEngine) peek(depth int) []byte {
+	return e.stack[len(e.stack)-1-depth]
+}
+
+func (e *scriptEngine) pop() []byte {
+	top := e.stack[len(e.stack)-1]
+	e.stack = e.stack[:len(e.stack)-1]
+	e.stackMemory -= elementMemory(top)
+	return top
+}
+
+func (e *scriptEngine) require(count int) error {
+	if len(e.stack) < count {
+		return scriptError(ErrInvalidStackOperation, "stack has %d items, need %d", len(e.stack), count)
+	}
+	return nil
+}
+
+func (e *scriptEngine) popNum() (*big.Int, error) {
+	return decodeScriptNum(e.pop(), e.maxNumLength(), e.requireMinimal())
+}
+
+// popInt pops a number that is used as a count or index
+func (e *scriptEngine) popInt() (int, error) {
+	n, err := e.popNum()
+	if err != nil {
+		return 0, err
+	}
+	if !n.IsInt64() || n.Int64() > math.MaxInt32 || n.Int64() < math.MinInt32 {
+		return 0, scriptError(ErrInvalidStackOperation, "number %s out of range", n)
+	}
+	return int(n.Int64()), nil
+}
+
+// execute runs a non-push opcode. It returns true when the script ends early.
+func (e *scriptEngine) execute(op parsedOpcode, pc int, exec bool) (bool, error) {
+	switch op.opcode {
+	case txscript.OP_1NEGATE:
+		e.pushNum(big.NewInt(-1))
+
+	case txscript.OP_1, txscript.OP_2, txscript.OP_3, txscript.OP_4, txscript.OP_5,
+		txscript.OP_6, txscript.OP_7, txscript.OP_8, txscript.OP_9, txscript.OP_10,
+		txscript.OP_11, txscript.OP_12, txscript.OP_13, txscript.OP_14, txscript.OP_15, txscript.OP_16:
+		e.pushNum(big.NewInt(int64(op.opcode - txscript.OP_1 + 1)))
+
+	case txscript.OP_NOP:
+
+	case txscript.OP_CHECKLOCKTIMEVERIFY:
+		if e.afterGenesis() || e.flags&ScriptVerifyCheckLockTimeVerify == 0 {
+			return false, e.upgradableNop(op.opcode)
+		}
+		return false, e.checkLockTimeVerify()
+
+	case txscript.OP_CHECKSEQUENCEVERIFY:
+		if e.afterGenesis() || e.flags&ScriptVerifyCheckSequenceVerify == 0 {
+			return false, e.upgradableNop(op.opcode)
+		}
+		return false, e.checkSequenceVerify()
+
+	case txscript.OP_NOP1, txscript.OP_NOP4, txscript.OP_NOP5, txscript.OP_NOP6,
+		txscript.OP_NOP7, txscript.OP_NOP8, txscript.OP_NOP9, txscript.OP_NOP10:
+		return false, e.upgradableNop(op.opcode)
+
+	case txscript.OP_IF, txscript.OP_NOTIF:
+		value := false
+		if exec {
+			if len(e.stack) < 1 {
+				return false, scriptError(ErrUnbalancedConditional, "IF without a condition")
+			}
+			top := e.pop()
+			if e.flags&ScriptVerifyMinimalIf != 0 && (len(top) > 1 || (len(top) == 1 && top[0] != 1)) {
+				return false, scriptError(ErrMinimalIf, "IF condition is not minimal")
+			}
+			value = castToBool(top)
+			if op.opcode == txscript.OP_NOTIF {
+				value = !value
+			}
+		}
+		e.condStack = append(e.condStack, value)
+		e.elseStack = append(e.elseStack, false)
+
+	case txscript.OP_ELSE:
+		if len(e.condStack) == 0 {
+			return false, scriptError(ErrUnbalancedConditional, "ELSE without IF")
+		}
+		last := len(e.condStack) - 1
+		if e.afterGenesis() && e.elseStack[last] {
+			return false, scriptError(ErrUnbalancedConditional, "second ELSE for one IF")
+		}
+		e.condStack[last] = !e.condStack[last]
+		e.elseStack[last] = true
+
+	case txscript.OP_ENDIF:
+		if len(e.condStack) == 0 {
+			return false, scriptError(ErrUnbalancedConditional, "ENDIF without IF")
+		}
+		e.condStack = e.condStack[:len(e.condStack)-1]
+		e.elseStack = e.elseStack[:len(e.elseStack)-1]
+
+	case txscript.OP_VERIFY:
+		if err := e.require(1); err != nil {
+			return false, err
+		}
+		if !castToBool(e.pop()) {
+			return false, scriptError(ErrVerify, "OP_VERIFY failed")
+		}
+
+	case txscript.OP_RETURN:
+		if !e.afterGenesis() {
+			return false, scriptError(ErrOpReturn, "OP_RETURN executed")
+		}
+		if len(e.condStack) == 0 {
+			return true, nil
+		}
+		e.returned = true
+
+	case txscript.OP_TOALTSTACK:
+		if err := e.require(1); err != nil {
+			return false, err
+		}
+		item := e.pop()
+		e.altStack = append(e.altStack, item)
+		e.stackMemory += elementMemory(item)
+
+	case txscript.OP_FROMALTSTACK:
+		if len(e.altStack) < 1 {
+			return false, scriptError(ErrInvalidAltStackOperation, "alt stack is empty")
+		}
+		item := e.altStack[len(e.altStack)-1]
+		e.altStack = e.altStack[:len(e.altStack)-1]
+		e.stackMemory -= elementMemory(item)
+		e.push(item)
+
+	case txscript.OP_2DROP:
+		if err := e.require(2); err != nil {
+			return false, err
+		}
+		e.pop()
+		e.pop()
+
+	case txscript.OP_2DUP:
+		if err := e.require(2); err != nil {
+			return false, err
+		}
+		a, b := e.peek(1), e.peek(0)
+		e.push(a)
+		e.push(b)
+
+	case txscript.OP_3DUP:
+		if err := e.require(3); err != nil {
+			return false, err
+		}
+		a, b, c := e.peek(2), e.peek(1), e.peek(0)
+		e.push(a)
+		e.push(b)
+		e.push(c)
+
+	case txscript.OP_2OVER:
+		if err := e.require(4); err != nil {
+			return false, err
+		}
+		a, b := e.peek(3), e.peek(2)
+		e.push(a)
+		e.push(b)
+
+	case txscript.OP_2ROT:
+		if err := e.require(6); err != nil {
+			return false, err
+		}
+		n := len(e.stack)
+		a, b := e.stack[n-6], e.stack[n-5]
+		e.stack = append(e.stack[:n-6], e.stack[n-4:]...)
+		e.stackMemory -= elementMemory(a) + elementMemory(b)
+		e.push(a)
+		e.push(b)
+
+	case txscript.OP_2SWAP:
+		if err := e.require(4); err != nil {
+			return false, err
+		}
+		n := len(e.stack)
+		e.stack[n-4], e.stack[n-2] = e.stack[n-2], e.stack[n-4]
+		e.stack[n-3], e.stack[n-1] = e.stack[n-1], e.stack[n-3]
+
+	case txscript.OP_IFDUP:
+		if err := e.require(1); err != nil {
+			return false, err
+		}
+		if castToBool(e.peek(0)) {
+			e.push(e.peek(0))
+		}
+
+	case txscript.OP_DEPTH:
+		e.pushNum(big.NewInt(int64(len(e.stack))))
+
+	case txscript.OP_DROP:
+		if err := e.require(1); err != nil {
+			return false, err
+		}
+		e.pop()
+
+	case txscript.OP_DUP:
+		if err := e.require(1); err != nil {
+			return false, err
+		}
+		e.push(e.peek(0))
+
+	case txscript.OP_NIP:
+		if err := e.require(2); err != nil {
+			return false, err
+		}
+		top := e.pop()
+		e.pop()
+		e.push(top)
+
+	case txscript.OP_OVER:
+		if err := e.require(2); err != nil {
+			return false, err
+		}
+		e.push(e.peek(1))
+
+	case txscript.OP_PICK, txscript.OP_ROLL:
+		if err := e.require(2); err != nil {
+			return false, err
+		}
+		n, err := e.popInt()
+		if err != nil {
+			return false, err
+		}
+		if n < 0 || n >= len(e.stack) {
+			return false, scriptError(ErrInvalidStackOperation, "index %d out of range", n)
+		}
+		item := e.peek(n)
+		if op.opcode == txscript.OP_ROLL {
+			i := len(e.stack) - 1 - n
+			e.stack = append(e.stack[:i], e.stack[i+1:]...)
+			e.stackMemory -= elementMemory(item)
+		}
+		e.push(item)
+
+	case txscript.OP_ROT:
+		if err := e.require(3); err != nil {
+			return false, err
+		}
+		n := len(e.stack)
+		e.stack[n-3], e.stack[n-2], e.stack[n-1] = e.stack[n-2], e.stack[n-1], e.stack[n-3]
+
+	case txscript.OP_SWAP:
+		if err := e.require(2); err != nil {
+			return false, err
+		}
+		n := len(e.stack)
+		e.stack[n-2], e.stack[n-1] = e.stack[n-1], e.stack[n-2]
+
+	case txscript.OP_TUCK:
+		if err := e.require(2); err != nil {
+			return false, err
+		}
+		top := e.pop()
+		second := e.pop()
+		e.push(top)
+		e.push(second)
+		e.push(top)
+
+	case txscript.OP_CAT:
+		if err := e.require(2); err != nil {
+			return false, err
+		}
+		b := e.pop()
+		a := e.pop()
+		if len(a)+len(b) > e.maxElementSize() {
+			return false, scriptError(ErrPushSize, "OP_CAT result of %d bytes", len(a)+len(b))
+		}
+		e.push(append(append([]byte(nil), a...), b...))
+
+	case opSplit:
+		if err := e.require(2); err != nil {
+			return false, err
+		}
+		n, err := e.popNum()
+		if err != nil {
+			return false, err
+		}
+		data := e.pop()
+		if n.Sign() < 0 || n.Cmp(big.NewInt(int64(len(data)))) > 0 {
+			return false, scriptError(ErrInvalidSplitRange, "split position %s out of range for %d bytes", n, len(data))
+		}
+		if len(data) > e.maxElementSize() {
+			return false, scriptError(ErrPushSize, "OP_SPLIT of %d bytes", len(data))
+		}
+		position := int(n.Int64())
+		e.push(append([]byte(nil), data[:position]...))
+		e.push(append([]byte(nil), data[position:]...))
+
+	case opNum2Bin:
+		if err := e.require(2); err != nil {
+			return false, err
+		}
+		return false, e.num2bin()
+
+	case opBin2Num:
+		if err := e.require(1); err != nil {
+			return false, err
+		}
+		data := minimallyEncode(e.pop())
+		if len(data) > e.maxNumLength() {
+			return false, scriptError(ErrInvalidNumberRange, "number of %d bytes is out of range", len(data))
+		}
+		e.push(data)
+
+	case txscript.OP_SIZE:
+		if err := e.require(1); err != nil {
+			return false, err
+		}
+		e.pushNum(big.NewInt(int64(len(e.peek(0)))))
+
+	case txscript.OP_INVERT:
+		if err := e.require(1); err != nil {
+			return false, err
+		}
+		data := e.pop()
+		result := make([]byte, len(data))
+		for i, b := range data {
+			result[i] = ^b
+		}
+		e.push(result)
+
+	case txscript.OP_AND, txscript.OP_OR, txscript.OP_XOR:
+		if err := e.require(2); err != nil {
+			return false, err
+		}
+		b := e.pop()
+		a := e.pop()
+		if len(a) != len(b) {
+			return false, scriptError(ErrInvalidOperandSize, "operands of %d and %d bytes", len(a), len(b))
+		}
+		result := make([]byte, len(a))
+		for i := range a {
+			switch op.opcode {
+			case txscript.OP_AND:
+				result[i] = a[i] & b[i]
+			case txscript.OP_OR:
+				result[i] = a[i] | b[i]
+			default:
+				result[i] = a[i] ^ b[i]
+			}
+		}
+		e.push(result)
+
+	case txscript.OP_LSHIFT, txscript.OP_RSHIFT:
+		if err := e.require(2); err != nil {
+			return false, err
+		}
+		n, err := e.popNum()
+		if err != nil {
+			return false, err
+		}
+		if n.Sign() < 0 {
+			return false, scriptError(ErrInvalidNumberRange, "negative shift %s", n)
+		}
+		data := e.pop()
+		e.push(shiftBytes(data, n, op.opcode == txscript.OP_LSHIFT))
+
+	case txscript.OP_EQUAL, txscript.OP_EQUALVERIFY:
+		if err := e.require(2); err != nil {
+			return false, err
+		}
+		equal := bytes.Equal(e.pop(), e.pop())
+		if op.opcode == txscript.OP_EQUALVERIFY {
+			if !equal {
+				return false, scriptError(ErrEqualVerify, "OP_EQUALVERIFY failed")
+			}
+		} else {
+			e.push(boolBytes(equal))
+		}
+
+	case txscript.OP_1ADD, txscript.OP_1SUB, txscript.OP_NEGATE, txscript.OP_ABS,
+		txscript.OP_NOT, txscript.OP_0NOTEQUAL:
+		if err := e.require(1); err != nil {
+			return false, err
+		}
+		n, err := e.popNum()
+		if err != nil {
+			return false, err
+		}
+		switch op.opcode {
+		case txscript.OP_1ADD:
+			n.Add(n, big.NewInt(1))
+		case txscript.OP_1SUB:
+			n.Sub(n, big.NewInt(1))
+		case txscript.OP_NEGATE:
+			n.Neg(n)
+		case txscript.OP_ABS:
+			n.Abs(n)
+		case txscript.OP_NOT:
+			n = boolNum(n.Sign() == 0)
+		case txscript.OP_0NOTEQUAL:
+			n = boolNum(n.Sign() != 0)
+		}
+		e.pushNum(n)
+
+	case txscript.OP_ADD, txscript.OP_SUB, txscript.OP_MUL, txscript.OP_DIV, txscript.OP_MOD,
+		txscript.OP_BOOLAND, txscript.OP_BOOLOR, txscript.OP_NUMEQUAL, txscript.OP_NUMEQUALVERIFY,
+		txscript.OP_NUMNOTEQUAL, txscript.OP_LESSTHAN, txscript.OP_GREATERTHAN,
+		txscript.OP_LESSTHANOREQUAL, txscript.OP_GREATERTHANOREQUAL, txscript.OP_MIN, txscript.OP_MAX:
+		if err := e.require(2); err != nil {
+			return false, err
+		}
+		b, err := e.popNum()
+		if err != nil {
+			return false, err
+		}
+		a, err := e.popNum()
+		if err != nil {
+			return false, err
+		}
+		result, err := binaryNumOp(op.opcode, a, b)
+		if err != nil {
+			return false, err
+		}
+		if op.opcode == txscript.OP_NUMEQUALVERIFY {
+			if result.Sign() == 0 {
+				return false, scriptError(ErrNumEqualVerify, "OP_NUMEQUALVERIFY failed")
+			}
+		} else {
+			e.pushNum(result)
+		}
+
+	case txscript.OP_WITHIN:
+		if err := e.require(3); err != nil {
+			return false, err
+		}
+		max, err := e.popNum()
+		if err != nil {
+			return false, err
+		}
+		min, err := e.popNum()
+		if err != nil {
+			return false, err
+		}
+		x, err := e.popNum()
+		if err != nil {
+			return false, err
+		}
+		e.push(boolBytes(min.Cmp(x) <= 0 && x.Cmp(max) < 0))
+
+	case txscript.OP_RIPEMD160, txscript.OP_SHA1, txscript.OP_SHA256, txscript.OP_HASH160, txscript.OP_HASH256:
+		if err := e.require(1); err != nil {
+			return false, err
+		}
+		e.push(hashOp(op.opcode, e.pop()))
+
+	case txscript.OP_CODESEPARATOR:
+		e.codeSepPos = pc
+
+	case txscript.OP_CHECKSIG, txscript.OP_CHECKSIGVERIFY:
+		if err := e.require(2); err != nil {
+			return false, err
+		}
+		pubKey := e.pop()
+		sig := e.pop()
+
+		valid, err := e.checkSig(sig, pubKey, e.subScript([][]byte{sig}))
+		if err != nil {
+			return false, err
+		}
+		if !valid && e.flags&ScriptVerifyNullFail != 0 && len(sig) > 0 {
+			return false, scriptError(ErrNullFail, "failed signature is not empty")
+		}
+		if op.opcode == txscript.OP_CHECKSIGVERIFY {
+			if !valid {
+				return false, scriptError(ErrCheckSigVerify, "OP_CHECKSIGVERIFY failed")
+			}
+		} else {
+			e.push(boolBytes(valid))
+		}
+
+	case txscript.OP_CHECKMULTISIG, txscript.OP_CHECKMULTISIGVERIFY:
+		valid, err := e.checkMultiSig()
+		if err != nil {
+			return false, err
+		}
+		if op.opcode == txscript.OP_CHECKMULTISIGVERIFY {
+			if !valid {
+				return false, scriptError(ErrCheckMultiSigVerify, "OP_CHECKMULTISIGVERIFY failed")
+			}
+		} else {
+			e.push(boolBytes(valid))
+		}
+
+	default:
+		return false, scriptError(ErrBadOpcode, "opcode %#x is invalid", op.opcode)
+	}
+
+	return false, nil
+}
+
+// upgradableNop handles NOPs reserved for future soft forks
+func (e *scriptEngine) upgradableNop(opcode byte) error {
+	if e.flags&ScriptVerifyDiscourageUpgradableNops != 0 {
+		return scriptError(ErrDiscourageUpgradableNops, "opcode %#x is reserved", opcode)
+	}
+	return nil
+}
+
+func boolNum(value bool) *big.Int {
+	if value {
+		return big.NewInt(1)
+	}
+	return new(big.Int)
+}
+
+// binaryNumOp applies a two-operand numeric opcode
+func binaryNumOp(opcode byte, a, b *big.Int) (*big.Int, error) {
+	switch opcode {
+	case txscript.OP_ADD:
+		return new(big.Int).Add(a, b), nil
+	case txscript.OP_SUB:
+		return new(big.Int).Sub(a, b), nil
+	case txscript.OP_MUL:
+		return new(big.Int).Mul(a, b), nil
+	case txscript.OP_DIV:
+		if b.Sign() == 0 {
+			return nil, scriptError(ErrDivByZero, "division by zero")
+		}
+		// Truncates towards zero like the node
+		return new(big.Int).Quo(a, b), nil
+	case txscript.OP_MOD:
+		if b.Sign() == 0 {
+			return nil, scriptError(ErrModByZero, "modulo by zero")
+		}
+		return new(big.Int).Rem(a, b), nil
+	case txscript.OP_BOOLAND:
+		return boolNum(a.Sign() != 0 && b.Sign() != 0), nil
+	case txscript.OP_BOOLOR:
+		return boolNum(a.Sign() != 0 || b.Sign() != 0), nil
+	case txscript.OP_NUMEQUAL, txscript.OP_NUMEQUALVERIFY:
+		return boolNum(a.Cmp(b) == 0), nil
+	case txscript.OP_NUMNOTEQUAL:
+		return boolNum(a.Cmp(b) != 0), nil
+	case txscript.OP_LESSTHAN:
+		return boolNum(a.Cmp(b) < 0), nil
+	case txscript.OP_GREATERTHAN:
+		return boolNum(a.Cmp(b) > 0), nil
+	case txscript.OP_LESSTHANOREQUAL:
+		return boolNum(a.Cmp(b) <= 0), nil
+	case txscript.OP_GREATERTHANOREQUAL:
+		return boolNum(a.Cmp(b) >= 0), nil
+	case txscript.OP_MIN:
+		if a.Cmp(b) < 0 {
+			return a, nil
+		}
+		return b, nil
+	default:
+		if a.Cmp(b) > 0 {
+			return a, nil
+		}
+		return b, nil
+	}
+}
+
+// shiftBytes shifts data as a big-endian bit string, keeping its length
+func shiftBytes(data []byte, n *big.Int, left bool) []byte {
+	result := make([]byte, len(data))
+	bits := int64(len(data)) * 8
+	if !n.IsInt64() || n.Int64() >= bits {
+		return result
+	}
+
+	value := new(big.Int).SetBytes(data)
+	if left {
+		value.Lsh(value, uint(n.Int64()))
+		mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(bits)), big.NewInt(1))
+		value.And(value, mask)
+	} else {
+		value.Rsh(value, uint(n.Int64()))
+	}
+
+	return value.FillBytes(result)
+}
+
+// hashOp applies a hashing opcode
+func hashOp(opcode byte, data []byte) []byte {
+	switch opcode {
+	case txscript.OP_RIPEMD160:
+		hasher := ripemd160.New()
+		hasher.Write(data)
+		return hasher.Sum(nil)
+	case txscript.OP_SHA1:
+		sum := sha1.Sum(data)
+		return sum[:]
+	case txscript.OP_SHA256:
+		sum := sha256.Sum256(data)
+		return sum[:]
+	case txscript.OP_HASH160:
+		sum := sha256.Sum256(data)
+		hasher := ripemd160.New()
+		hasher.Write(sum[:])
+		return hasher.Sum(nil)
+	default:
+		return chainhash.DoubleHashB(data)
+	}
+}
+
+// num2bin pads or checks a number to a given byte length
+func (e *scriptEngine) num2bin() error {
+	size, err := e.popNum()
+	if err != nil {
+		return err
+	}
+
+	if size.Sign() < 0 || !size.IsInt64() || size.Int64() > int64(e.maxElementSize()) {
+		return scriptError(ErrPushSize, "OP_NUM2BIN size %s out of range", size)
+	}
+
+	length := int(size.Int64())
+	data := minimallyEncode(e.pop())
+	if len(data) > length {
+		return scriptError(ErrImpossibleEncoding, "%d-byte number does not fit in %d bytes", len(data), length)
+	}
+
+	if len(data) == length {
+		e.push(data)
+		return nil
+	}
+
+	result := make([]byte, length)
+	copy(result, data)
+
+	var signBit byte
+	if len(data) > 0 {
+		signBit = data[len(data)-1] & 0x80
+		result[len(data)-1] &= 0x7f
+	}
+	result[length-1] |= signBit
+
+	e.push(result)
+	return nil
+}
+
+// checkLockTimeVerify implements OP_CHECKLOCKTIMEVERIFY before Genesis
+func (e *scriptEngine) checkLockTimeVerify() error {
+	if err := e.require(1); err != nil {
+		return err
+	}
+
+	lockTime, err := decodeScriptNum(e.peek(0), maxLockTimeScriptNumLength, e.requireMinimal())
+	if err != nil {
+		return err
+	}
+
+	if lockTime.Sign() < 0 {
+		return scriptError(ErrNegativeLockTime, "negative lock time %s", lockTime)
+	}
+
+	txLockTime := int64(e.tx.LockTime)
+	if (txLockTime < lockTimeThreshold) != (lockTime.Int64() < lockTimeThreshold) {
+		return scriptError(ErrUnsatisfiedLockTime, "lock time types do not match")
+	}
+
+	if lockTime.Int64() > txLockTime {
+		return scriptError(ErrUnsatisfiedLockTime, "lock time %s is after transaction lock time %d", lockTime, txLockTime)
+	}
+
+	if e.tx.TxIn[e.index].Sequence == finalSequence {
+		return scriptError(ErrUnsatisfiedLockTime, "input is final")
+	}
+
+	return nil
+}
+
+// checkSequenceVerify implements OP_CHECKSEQUENCEVERIFY before Genesis
+func (e *scriptEngine) checkSequenceVerify() error {
+	if err := e.require(1); err != nil {
+		return err
+	}
+
+	sequence, err := decodeScriptNum(e.peek(0), maxLockTimeScriptNumLength, e.requireMinimal())
+	if err != nil {
+		return err
+	}
+
+	if sequence.Sign() < 0 {
+		return scriptError(ErrNegativeLockTime, "negative sequence %s", sequence)
+	}
+
+	if sequence.Int64()&sequenceLockTimeDisabled != 0 {
+		return nil
+	}
+
+	if e.tx.Version < 2 {
+		return scriptError(ErrUnsatisfiedLockTime, "transaction version %d does not support relative lock time", e.tx.Version)
+	}
+
+	txSequence := int64(e.tx.TxIn[e.index].Sequence)
+	if txSequence&sequenceLockTimeDisabled != 0 {
+		return scriptError(ErrUnsatisfiedLockTime, "input has relative lock time disabled")
+	}
+
+	mask := int64(sequenceLockTimeTypeFlag | sequenceLockTimeMask)
+	required := sequence.Int64() & mask
+	actual := txSequence & mask
+
+	if (required < sequenceLockTimeTypeFlag) != (actual < sequenceLockTimeTypeFlag) {
+		return scriptError(ErrUnsatisfiedLockTime, "relative lock time types do not match")
+	}
+
+	if required > actual {
+		return scriptError(ErrUnsatisfiedLockTime, "relative lock time not reached")
+	}
+
+	return nil
+}
+
+// subScript returns the script code signatures commit to: the script after
+// the last executed OP_CODESEPARATOR, with non-FORKID signatures removed
+func (e *scriptEngine) subScript(sigs [][]byte) []byte {
+	script := e.script[e.codeSepPos:]
+
+	for _, sig := range sigs {
+		if len(sig) > 0 && e.usesForkIDDigest(SigHashType(sig[len(sig)-1])) {
+			continue
+		}
+		script = findAndDelete(script, sig)
+	}
+
+	return script
+}
+
+// findAndDelete removes every canonical push of data from a script
+func findAndDelete(script, data []byte) []byte {
+	if len(data) == 0 {
+		return script
+	}
+
+	push := canonicalPush(data)
+	result := make([]byte, 0, len(script))
+	for pc := 0; pc < len(script); {
+		op, err := parseOpcode(script, pc)
+		if err != nil {
+			return append(result, script[pc:]...)
+		}
+		if !bytes.Equal(script[pc:op.next], push) {
+			result = append(result, script[pc:op.next]...)
+		}
+		pc = op.next
+	}
+	return result
+}
+
+// canonicalPush returns the smallest push of data
+func canonicalPush(data []byte) []byte {
+	var buf bytes.Buffer
+	switch {
+	case len(data) <= 75:
+		buf.WriteByte(byte(len(data)))
+	case len(data) <= 0xff:
+		buf.WriteByte(txscript.OP_PUSHDATA1)
+		buf.WriteByte(byte(len(data)))
+	case len(data) <= 0xffff:
+		buf.WriteByte(txscript.OP_PUSHDATA2)
+		binary.Write(&buf, binary.LittleEndian, uint16(len(data)))
+	default:
+		buf.WriteByte(txscript.OP_PUSHDATA4)
+		binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	}
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+// usesForkIDDigest reports whether a signature with this hash type is
+// checked against the FORKID digest
+func (e *scriptEngine) usesForkIDDigest(hashType SigHashType) bool {
+	return e.flags&ScriptEnableSighashForkID != 0 && hashType.hasForkID()
+}
+
+// checkSignatureEncoding applies the signature encoding rules selected by the flags
+func (e *scriptEngine) checkSignatureEncoding(sig []byte) error {
+	if len(sig) == 0 {
+		return nil
+	}
+
+	if e.flags&(ScriptVerifyDERSignatures|ScriptVerifyLowS|ScriptVerifyStrictEncoding) != 0 && !isValidSignatureEncoding(sig) {
+		return scriptError(ErrSigDER, "signature is not strict DER")
+	}
+
+	if e.flags&ScriptVerifyLowS != 0 && !hasLowS(sig) {
+		return scriptError(ErrSigHighS, "signature S value is not low")
+	}
+
+	if e.flags&ScriptVerifyStrictEncoding != 0 {
+		hashType := SigHashType(sig[len(sig)-1])
+		base := hashType &^ (SigHashAnyOneCanPay | SigHashForkID)
+		if base < SigHashAll || base > SigHashSingle {
+			return scriptError(ErrSigHashType, "undefined hash type %#x", byte(hashType))
+		}
+
+		forkIDEnabled := e.flags&ScriptEnableSighashForkID != 0
+		if !forkIDEnabled && hashType.hasForkID() {
+			return scriptError(ErrIllegalForkID, "FORKID hash type is not enabled")
+		}
+		if forkIDEnabled && !hashType.hasForkID() {
+			return scriptError(ErrMustUseForkID, "signature must use FORKID")
+		}
+	}
+
+	return nil
+}
+
+// checkPubKeyEncoding requires a compressed or uncompressed public key under STRICTENC
+func (e *scriptEngine) checkPubKeyEncoding(pubKey []byte) error {
+	if e.flags&ScriptVerifyStrictEncoding == 0 {
+		return nil
+	}
+
+	if len(pubKey) == 33 && (pubKey[0] == 0x02 || pubKey[0] == 0x03) {
+		return nil
+	}
+
+	if len(pubKey) == 65 && pubKey[0] == 0x04 {
+		return nil
+	}
+
+	return scriptError(ErrPubKeyType, "unsupported public key encoding")
+}
+
+// checkSig checks an encoded signature against a public key
+func (e *scriptEngine) checkSig(sig, pubKey, subScript []byte) (bool, error) {
+	if err := e.checkSignatureEncoding(sig); err != nil {
+		return false, err
+	}
+
+	if err := e.checkPubKeyEncoding(pubKey); err != nil {
+		return false, err
+	}
+
+	if len(sig) == 0 {
+		return false, nil
+	}
+
+	hashType := SigHashType(sig[len(sig)-1])
+
+	var signature *ecdsa.Signature
+	var err error
+	if e.flags&(ScriptVerifyDERSignatures|ScriptVerifyLowS|ScriptVerifyStrictEncoding) != 0 {
+		signature, err = ecdsa.ParseDERSignature(sig[:len(sig)-1])
+	} else {
+		signature, err = ecdsa.ParseSignature(sig[:len(sig)-1])
+	}
+	if err != nil {
+		return false, nil
+	}
+
+	key, err := btcec.ParsePubKey(pubKey)
+	if err != nil {
+		return false, nil
+	}
+
+	var hash []byte
+	if e.usesForkIDDigest(hashType) {
+		hash = forkIDSignatureHash(e.tx, e.index, subScript, hashType, e.amount)
+	} else {
+		hash = legacySignatureHash(e.tx, e.index, subScript, hashType)
+	}
+
+	return signature.Verify(hash, key), nil
+}
+
+// checkMultiSig implements OP_CHECKMULTISIG, consuming its operands
+func (e *scriptEngine) checkMultiSig() (bool, error) {
+	i := 1
+	if err := e.require(i); err != nil {
+		return false, err
+	}
+
+	keyCount, err := decodeScriptNum(e.peek(i-1), e.maxNumLength(), e.requireMinimal())
+	if err != nil {
+		return false, err
+	}
+	if keyCount.Sign() < 0 || !keyCount.IsInt64() || keyCount.Int64() > math.MaxInt32 ||
+		(!e.afterGenesis() && keyCount.Int64() > maxPubKeysBeforeGenesis) {
+		return false, scriptError(ErrPubKeyCount, "invalid public key count %s", keyCount)
+	}
+	keys := int(keyCount.Int64())
+
+	if !e.afterGenesis() {
+		e.opCount += keys
+		if e.opCount > maxOpsBeforeGenesis {
+			return false, scriptError(ErrOpCount, "more than %d opcodes", maxOpsBeforeGenesis)
+		}
+	}
+
+	i++
+	keyIndex := i
+	i += keys
+	if err := e.require(i); err != nil {
+		return false, err
+	}
+
+	sigCount, err := decodeScriptNum(e.peek(i-1), e.maxNumLength(), e.requireMinimal())
+	if err != nil {
+		return false, err
+	}
+	if sigCount.Sign() < 0 || sigCount.Cmp(big.NewInt(int64(keys))) > 0 {
+		return false, scriptError(ErrSigCount, "invalid signature count %s", sigCount)
+	}
+	sigs := int(sigCount.Int64())
+
+	i++
+	sigIndex := i
+	i += sigs
+	if err := e.require(i); err != nil {
+		return false, err
+	}
+
+	allSigs := make([][]byte, sigs)
+	for j := 0; j < sigs; j++ {
+		allSigs[j] = e.peek(sigIndex + j - 1)
+	}
+	subScript := e.subScript(allSigs)
+
+	success := true
+	remainingKeys := keys
+	for success && sigs > 0 {
+		sig := e.peek(sigIndex - 1)
+		pubKey := e.peek(keyIndex - 1)
+
+		valid, err := e.checkSig(sig, pubKey, subScript)
+		if err != nil {
+			return false, err
+		}
+
+		if valid {
+			sigIndex++
+			sigs--
+		}
+		keyIndex++
+		remainingKeys--
+
+		// More signatures left than keys means failure
+		if sigs > remainingKeys {
+			success = false
+		}
+	}
+
+	// Pop everything but the dummy element, checking NULLFAIL on the signatures
+	keysToPop := keys + 2
+	for ; i > 1; i-- {
+		if !success && e.flags&ScriptVerifyNullFail != 0 && keysToPop == 0 && len(e.peek(0)) > 0 {
+			return false, scriptError(ErrNullFail, "failed signature is not empty")
+		}
+		if keysToPop > 0 {
+			keysToPop--
+		}
+		e.pop()
+	}
+
+	if err := e.require(1); err != nil {
+		return false, err
+	}
+
+	if e.flags&ScriptVerifyNullDummy != 0 && len(e.peek(0)) != 0 {
+		return false, scriptError(ErrSigNullDummy, "multisig dummy element is not empty")
+	}
+	e.pop()
+
+	return success, nil
+}
+
+// isValidSignatureEncoding checks strict DER encoding with a trailing hash type (BIP66)
+func isValidSignatureEncoding(sig []byte) bool {
+	// 0x30 [total-length] 0x02 [R-length] [R] 0x02 [S-length] [S] [sighash]
+	if len(sig) < 9 || len(sig) > 73 {
+		return false
+	}
+	if sig[0] != 0x30 || int(sig[1]) != len(sig)-3 {
+		return false
+	}
+
+	lenR := int(sig[3])
+	if 5+lenR >= len(sig) {
+		return false
+	}
+
+	lenS := int(sig[5+lenR])
+	if lenR+lenS+7 != len(sig) {
+		return false
+	}
+
+	if sig[2] != 0x02 || lenR == 0 || sig[4]&0x80 != 0 {
+		return false
+	}
+	if lenR > 1 && sig[4] == 0x00 && sig[5]&0x80 == 0 {
+		return false
+	}
+
+	if sig[lenR+4] != 0x02 || lenS == 0 || sig[lenR+6]&0x80 != 0 {
+		return false
+	}
+	if lenS > 1 && sig[lenR+6] == 0x00 && sig[lenR+7]&0x80 == 0 {
+		return false
+	}
+
+	return true
+}
+
+// hasLowS reports whether a DER signature's S value is at most half the curve order
+func hasLowS(sig []byte) bool {
+	if !isValidSignatureEncoding(sig) {
+		return false
+	}
+
+	lenR := int(sig[3])
+	lenS := int(sig[5+lenR])
+	s := new(big.Int).SetBytes(sig[6+lenR : 6+lenR+lenS])
+
+	halfOrder := new(big.Int).Rsh(btcec.S256().N, 1)
+	return s.Cmp(halfOrder) <= 0
+}
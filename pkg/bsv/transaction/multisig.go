@@ -0,0 +1,436 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/utxo"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+	"github.com/muhammadamman/BSV-Go/pkg/config"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// ParseMultiSigScript recovers the template of a bare multisig locking script
+func ParseMultiSigScript(lockingScript []byte) (*MultiSigTemplate, error) {
+	var ops []parsedOpcode
+	for pc := 0; pc < len(lockingScript); {
+		op, err := parseOpcode(lockingScript, pc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid script: %v", err)
+		}
+		ops = append(ops, op)
+		pc = op.next
+	}
+
+	if len(ops) < 4 || ops[len(ops)-1].opcode != txscript.OP_CHECKMULTISIG {
+		return nil, fmt.Errorf("not a multisig script")
+	}
+
+	required, err := multiSigCount(ops[0])
+	if err != nil {
+		return nil, err
+	}
+
+	keyOps := ops[1 : len(ops)-2]
+	publicKeys := make([][]byte, len(keyOps))
+	for i, op := range keyOps {
+		if op.data == nil {
+			return nil, fmt.Errorf("not a multisig script")
+		}
+		publicKeys[i] = op.data
+	}
+
+	template, err := NewMultiSigTemplate(required, publicKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the canonical encoding round-trips, which also checks the key count
+	if err := checkLockingScript(template, lockingScript); err != nil {
+		return nil, fmt.Errorf("not a multisig script")
+	}
+
+	return template, nil
+}
+
+// multiSigCount reads a signature or key count pushed as a number
+func multiSigCount(op parsedOpcode) (int, error) {
+	if op.opcode >= txscript.OP_1 && op.opcode <= txscript.OP_16 {
+		return int(op.opcode-txscript.OP_1) + 1, nil
+	}
+
+	if op.data == nil {
+		return 0, fmt.Errorf("not a multisig script")
+	}
+
+	n, err := decodeScriptNum(op.data, 4, true)
+	if err != nil || n.Sign() <= 0 {
+		return 0, fmt.Errorf("not a multisig script")
+	}
+
+	return int(n.Int64()), nil
+}
+
+// ScriptHash returns the identifier the multisig's UTXOs are watched by. Bare
+// multisig has no address, so outputs are indexed by locking script hash.
+func (t *MultiSigTemplate) ScriptHash() (string, error) {
+	lockingScript, err := t.Lock()
+	if err != nil {
+		return "", err
+	}
+	return utxo.ScriptHash(lockingScript), nil
+}
+
+// indexOf returns the position of a public key in the template, or -1
+func (t *MultiSigTemplate) indexOf(publicKey []byte) int {
+	for i, key := range t.PublicKeys {
+		if bytes.Equal(key, publicKey) {
+			return i
+		}
+	}
+	return -1
+}
+
+// GetScriptUTXOs retrieves the UTXOs locked by a script hash
+func (b *Builder) GetScriptUTXOs(scriptHash string) ([]types.UTXO, error) {
+	return b.utxoManager.GetScriptUTXOs(scriptHash)
+}
+
+// BuildMultiSigTransaction builds an unsigned transaction spending the UTXOs of
+// a multisig, with change returned to the same script. params.From and
+// params.PrivateKey are ignored. Cosigners sign the result with CosignOffline.
+func (b *Builder) BuildMultiSigTransaction(multisig *MultiSigTemplate, params *types.TransactionParams) (*UnsignedTransaction, error) {
+	lockingScript, err := multisig.Lock()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(params.TokenTransfers) > 0 {
+		return nil, fmt.Errorf("multisig transactions do not support token transfers")
+	}
+
+	multisigParams := *params
+	multisigParams.From = utxo.ScriptHash(lockingScript)
+	if err := b.validateParams(&multisigParams); err != nil {
+		return nil, err
+	}
+
+	quote, err := b.feeQuote(params.FeeRate)
+	if err != nil {
+		return nil, err
+	}
+
+	txConfig := b.configManager.GetTransactionConfig()
+	strategy := config.CoinSelectionStrategy(params.CoinSelectionStrategy)
+	if strategy == "" {
+		strategy = txConfig.CoinSelectionStrategy
+	}
+
+	// Size inputs and change for the multisig script
+	target := newSelectionTarget(quote, multisigParams.Amount, txConfig.DustLimit)
+	target.InputSize = EstimateInputSize(multisig)
+	target.ChangeSize = 8 + wire.VarIntSerializeSize(uint64(len(lockingScript))) + len(lockingScript)
+
+	dataSize, err := dataOutputsSize(params.DataOutputs)
+	if err != nil {
+		return nil, err
+	}
+	target.BaseSize += dataSize
+	target.DataSize = dataSize
+
+	selected, fee, err := b.utxoManager.SelectScriptUTXOs(multisigParams.From, target, strategy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select UTXOs: %w", err)
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	inputs := make([]types.UTXO, len(selected))
+	for i, input := range selected {
+		txHash, err := chainhash.NewHashFromStr(input.TxID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UTXO transaction hash: %v", err)
+		}
+
+		input.ScriptPubKey = hex.EncodeToString(lockingScript)
+		inputs[i] = input
+
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(txHash, input.Vout), nil, nil))
+	}
+
+	if _, err := b.addOutputs(tx, &multisigParams, inputs, fee, lockingScript, nil); err != nil {
+		return nil, fmt.Errorf("failed to add outputs: %v", err)
+	}
+
+	return &UnsignedTransaction{
+		Version:   UnsignedTransactionVersion,
+		IsTestnet: b.configManager.GetNetworkConfig().IsTestnet,
+		Tx:        tx,
+		Inputs:    inputs,
+	}, nil
+}
+
+// CosignOffline adds the key's signature to every unsigned multisig input that
+// lists it, and returns the number of signatures added. Cosigners may sign
+// copies of the same transaction independently; MergeSignatures collects them.
+func CosignOffline(unsigned *UnsignedTransaction, privateKey types.Secret) (int, error) {
+	if err := unsigned.validate(); err != nil {
+		return 0, err
+	}
+
+	_, keyPair, _, err := getSenderInfo(privateKey, unsigned.IsTestnet)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse key: %v", err)
+	}
+
+	return CosignOfflineWithSigner(unsigned, wallet.NewKeySigner(keyPair))
+}
+
+// CosignOfflineWithSigner adds the signer's signature to every unsigned
+// multisig input that lists its key, like CosignOffline
+func CosignOfflineWithSigner(unsigned *UnsignedTransaction, signer wallet.Signer) (int, error) {
+	if err := unsigned.validate(); err != nil {
+		return 0, err
+	}
+	if signer == nil {
+		return 0, fmt.Errorf("signer is required")
+	}
+
+	network := networkParams(unsigned.IsTestnet)
+
+	signed := 0
+	for i, input := range unsigned.Inputs {
+		if len(unsigned.Tx.TxIn[i].SignatureScript) > 0 {
+			continue
+		}
+
+		script, err := inputScript(input, network)
+		if err != nil {
+			return signed, fmt.Errorf("input %d: %v", i, err)
+		}
+
+		multisig, err := ParseMultiSigScript(script)
+		if err != nil {
+			continue
+		}
+
+		multisig.Signers = append(multisig.Signers, signer)
+		for _, publicKey := range multisig.PublicKeys {
+			if multisig.signerFor(publicKey) == nil || unsigned.hasSignature(i, publicKey) {
+				continue
+			}
+
+			signature, err := SignInputWithSigner(unsigned.Tx, i, script, input.Value, SigHashAllForkID, signer)
+			if err != nil {
+				return signed, fmt.Errorf("failed to sign input %d: %v", i, err)
+			}
+
+			unsigned.Signatures = append(unsigned.Signatures, PartialSignature{
+				Input:     i,
+				PublicKey: hex.EncodeToString(publicKey),
+				Signature: hex.EncodeToString(signature),
+			})
+			signed++
+		}
+	}
+
+	if signed == 0 {
+		return 0, fmt.Errorf("no unsigned multisig inputs list the key")
+	}
+
+	return signed, nil
+}
+
+// MergeSignatures collects the cosigner signatures of another copy of the same
+// transaction. Every signature is checked before it is accepted.
+func (u *UnsignedTransaction) MergeSignatures(other *UnsignedTransaction) error {
+	if err := u.validate(); err != nil {
+		return err
+	}
+
+	if err := other.validate(); err != nil {
+		return err
+	}
+
+	if unsignedHash(u.Tx) != unsignedHash(other.Tx) {
+		return fmt.Errorf("signatures are for a different transaction")
+	}
+
+	for _, signature := range other.Signatures {
+		publicKey, err := hex.DecodeString(signature.PublicKey)
+		if err != nil {
+			return fmt.Errorf("input %d: invalid public key: %v", signature.Input, err)
+		}
+
+		if u.hasSignature(signature.Input, publicKey) {
+			continue
+		}
+
+		if err := u.checkSignature(signature); err != nil {
+			return fmt.Errorf("input %d: %v", signature.Input, err)
+		}
+
+		u.Signatures = append(u.Signatures, signature)
+	}
+
+	return nil
+}
+
+// AssembleMultiSig turns the collected signatures into unlocking scripts for
+// every unsigned multisig input, and returns the number of inputs assembled.
+// It fails without changing the transaction if an input lacks signatures.
+func AssembleMultiSig(unsigned *UnsignedTransaction) (int, error) {
+	if err := unsigned.validate(); err != nil {
+		return 0, err
+	}
+
+	network := networkParams(unsigned.IsTestnet)
+
+	unlockingScripts := make(map[int][]byte)
+	for i, input := range unsigned.Inputs {
+		if len(unsigned.Tx.TxIn[i].SignatureScript) > 0 {
+			continue
+		}
+
+		script, err := inputScript(input, network)
+		if err != nil {
+			return 0, fmt.Errorf("input %d: %v", i, err)
+		}
+
+		multisig, err := ParseMultiSigScript(script)
+		if err != nil {
+			continue
+		}
+
+		// CHECKMULTISIG pops one element more than it uses, and matches
+		// signatures against keys in locking script order
+		unlockingScript := []byte{txscript.OP_0}
+		collected := 0
+		for _, publicKey := range multisig.PublicKeys {
+			if collected == multisig.Required {
+				break
+			}
+
+			signature := unsigned.signatureFor(i, publicKey)
+			if signature == nil {
+				continue
+			}
+			unlockingScript = append(unlockingScript, pushData(signature)...)
+			collected++
+		}
+
+		if collected < multisig.Required {
+			return 0, fmt.Errorf("input %d has %d of %d required signatures", i, collected, multisig.Required)
+		}
+
+		if err := VerifyScript(unlockingScript, script, unsigned.Tx, i, input.Value, StandardScriptFlags); err != nil {
+			return 0, fmt.Errorf("input %d failed script verification: %w", i, err)
+		}
+		unlockingScripts[i] = unlockingScript
+	}
+
+	if len(unlockingScripts) == 0 {
+		return 0, fmt.Errorf("no unsigned multisig inputs")
+	}
+
+	for i, unlockingScript := range unlockingScripts {
+		unsigned.Tx.TxIn[i].SignatureScript = unlockingScript
+	}
+	unsigned.Signatures = nil
+
+	return len(unlockingScripts), nil
+}
+
+// hasSignature reports whether a signature by publicKey is collected for an input
+func (u *UnsignedTransaction) hasSignature(input int, publicKey []byte) bool {
+	return u.signatureFor(input, publicKey) != nil
+}
+
+// signatureFor returns the collected signature by publicKey for an input
+func (u *UnsignedTransaction) signatureFor(input int, publicKey []byte) []byte {
+	for _, signature := range u.Signatures {
+		if signature.Input != input {
+			continue
+		}
+
+		key, err := hex.DecodeString(signature.PublicKey)
+		if err != nil || !bytes.Equal(key, publicKey) {
+			continue
+		}
+
+		sig, err := hex.DecodeString(signature.Signature)
+		if err != nil {
+			continue
+		}
+		return sig
+	}
+	return nil
+}
+
+// checkSignature verifies a cosigner signature against its input
+func (u *UnsignedTransaction) checkSignature(signature PartialSignature) error {
+	script, err := inputScript(u.Inputs[signature.Input], networkParams(u.IsTestnet))
+	if err != nil {
+		return err
+	}
+
+	multisig, err := ParseMultiSigScript(script)
+	if err != nil {
+		return err
+	}
+
+	publicKey, err := hex.DecodeString(signature.PublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %v", err)
+	}
+
+	if multisig.indexOf(publicKey) < 0 {
+		return fmt.Errorf("public key %s is not a cosigner", signature.PublicKey)
+	}
+
+	sig, err := hex.DecodeString(signature.Signature)
+	if err != nil || len(sig) == 0 {
+		return fmt.Errorf("invalid signature encoding")
+	}
+
+	hashType := SigHashType(sig[len(sig)-1])
+	if !hashType.hasForkID() {
+		return fmt.Errorf("signature does not use SIGHASH_FORKID")
+	}
+
+	hash, err := SignatureHash(u.Tx, signature.Input, script, hashType, u.Inputs[signature.Input].Value)
+	if err != nil {
+		return err
+	}
+
+	parsedKey, err := btcec.ParsePubKey(publicKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %v", err)
+	}
+
+	parsedSig, err := ecdsa.ParseDERSignature(sig[:len(sig)-1])
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+
+	if !parsedSig.Verify(hash, parsedKey) {
+		return fmt.Errorf("signature by %s does not verify", signature.PublicKey)
+	}
+
+	return nil
+}
+
+// unsignedHash identifies a transaction independently of its unlocking scripts
+func unsignedHash(tx *wire.MsgTx) chainhash.Hash {
+	stripped := tx.Copy()
+	for _, txIn := range stripped.TxIn {
+		txIn.SignatureScript = nil
+	}
+	return stripped.TxHash()
+}
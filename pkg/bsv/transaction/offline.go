@@ -0,0 +1,450 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// UnsignedTransactionVersion is the current version of the unsigned transaction format
+const UnsignedTransactionVersion = 1
+
+// Network names used in the unsigned transaction format
+const (
+	networkMainnet = "mainnet"
+	networkTestnet = "testnet"
+)
+
+// UnsignedTransaction is a transaction prepared on an online host for signing
+// elsewhere. Every input carries the value and locking script of the output it
+// spends, so it can be signed without access to the network.
+type UnsignedTransaction struct {
+	Version   int          // Format version
+	IsTestnet bool         // Network the transaction is built for
+	Tx        *wire.MsgTx  // Transaction, with unsigned or partially signed inputs
+	Inputs    []types.UTXO // Prevouts spent by Tx.TxIn, in the same order
+
+	// Cosigner signatures for multisig inputs, not yet assembled into unlocking scripts
+	Signatures []PartialSignature
+
+	// Derivation path of the change output, the last output, for HD senders,
+	// and the ID of the account it belongs to
+	ChangePath    string
+	ChangeAccount string
+
+	// Derivation path of each input spent from the sender's HD account, in
+	// the order of Inputs; empty for other inputs
+	InputPaths []string
+}
+
+// PartialSignature is one cosigner's signature for a multisig input
+type PartialSignature struct {
+	Input     int    `json:"input"`     // Index of the signed input
+	PublicKey string `json:"publicKey"` // Signing key in hex, as it appears in the locking script
+	Signature string `json:"signature"` // DER signature and hash type in hex
+}
+
+// unsignedTransactionJSON is the portable JSON encoding of an UnsignedTransaction
+type unsignedTransactionJSON struct {
+	Version int          `json:"version"` // Format version
+	Network string       `json:"network"` // "mainnet" or "testnet"
+	Tx      string       `json:"tx"`      // Transaction in hex
+	Inputs  []types.UTXO `json:"inputs"`  // Prevouts spent by the transaction
+
+	Signatures    []PartialSignature `json:"signatures,omitempty"`    // Unassembled multisig signatures
+	ChangePath    string             `json:"changePath,omitempty"`    // Derivation path of the change output
+	ChangeAccount string             `json:"changeAccount,omitempty"` // Account of the change output, by extended public key
+	InputPaths    []string           `json:"inputPaths,omitempty"`    // Derivation paths of HD inputs
+}
+
+// BuildUnsignedTransaction selects inputs and adds outputs without signing.
+// PrivateKey is not required; the inputs are taken from params.From. An HD
+// change index stays reserved until the transaction is finalized or
+// discarded.
+func (b *Builder) BuildUnsignedTransaction(params *types.TransactionParams) (*UnsignedTransaction, error) {
+	var account *wallet.HDAccount
+	if !params.PrivateKey.IsEmpty() {
+		_, _, senderAccount, err := b.getSenderInfo(params.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive sender account: %v", err)
+		}
+		account = senderAccount
+	}
+
+	return b.buildUnsignedTransaction(params, account)
+}
+
+// FinalizeTransaction checks that an offline-signed transaction is complete and
+// broadcasts it. An HD change address is marked as used once the broadcast
+// succeeds. If it fails the change index stays reserved, so the transaction
+// can be finalized again or discarded.
+func (b *Builder) FinalizeTransaction(unsigned *UnsignedTransaction) (*types.TransactionResult, error) {
+	if err := unsigned.validate(); err != nil {
+		return nil, err
+	}
+
+	if unsigned.IsTestnet != b.configManager.GetNetworkConfig().IsTestnet {
+		return nil, fmt.Errorf("transaction was built for %s", unsigned.networkName())
+	}
+
+	for i, txIn := range unsigned.Tx.TxIn {
+		if len(txIn.SignatureScript) == 0 {
+			return nil, fmt.Errorf("input %d is not signed", i)
+		}
+	}
+
+	if err := b.checkTransactionSize(unsigned.Tx); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := unsigned.Tx.Serialize(&buf); err != nil {
+		return nil, fmt.Errorf("failed to serialize transaction: %v", err)
+	}
+
+	if err := b.broadcastTransaction(unsigned.Tx, unsigned.Inputs); err != nil {
+		return nil, fmt.Errorf("failed to broadcast transaction: %v", err)
+	}
+
+	if err := b.commitChangeIndex(unsigned.ChangeAccount, unsigned.ChangePath, unsigned.Tx); err != nil {
+		return nil, err
+	}
+
+	return b.broadcastResult(unsigned, buf.Bytes()), nil
+}
+
+// DiscardTransaction releases the HD change index reserved for an unsigned
+// transaction that will not be broadcast, so the next build can use it
+func (b *Builder) DiscardTransaction(unsigned *UnsignedTransaction) error {
+	if unsigned.ChangePath == "" {
+		return nil
+	}
+
+	index, err := changePathIndex(unsigned.ChangePath)
+	if err != nil {
+		return err
+	}
+	return b.changeIndexes.ReleaseChangeIndex(unsigned.ChangeAccount, index)
+}
+
+// offlineScanLimit is how many addresses of each chain of an HD account
+// SignOffline tries for inputs without a derivation path, the BIP44 gap limit
+const offlineScanLimit = 20
+
+// SignOffline signs every input of an unsigned transaction that is locked to the
+// given WIF private key or mnemonic. It needs no network access or
+// configuration, and returns the number of inputs it signed. Inputs locked to
+// other keys are left untouched so several keys can sign in turn.
+//
+// A mnemonic or extended private key signs each input with the key at its
+// entry in InputPaths. Inputs without a path are matched against the first
+// offlineScanLimit receiving and change addresses of the account.
+func SignOffline(unsigned *UnsignedTransaction, privateKey types.Secret) (int, error) {
+	if err := unsigned.validate(); err != nil {
+		return 0, err
+	}
+
+	_, keyPair, account, err := getSenderInfo(privateKey, unsigned.IsTestnet)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse key: %v", err)
+	}
+	if account != nil {
+		return signOfflineWithAccount(unsigned, account)
+	}
+
+	return SignOfflineWithSigner(unsigned, wallet.NewKeySigner(keyPair))
+}
+
+// SignOfflineWithSigner signs every input of an unsigned transaction that is
+// locked to the signer's key, like SignOffline
+func SignOfflineWithSigner(unsigned *UnsignedTransaction, signer wallet.Signer) (int, error) {
+	if err := unsigned.validate(); err != nil {
+		return 0, err
+	}
+
+	network := networkParams(unsigned.IsTestnet)
+	address, err := wallet.SignerAddress(signer, network)
+	if err != nil {
+		return 0, fmt.Errorf("failed to derive address: %v", err)
+	}
+
+	// Sign the key's P2PKH and P2PK inputs
+	templates := keyTemplates(signer)
+
+	signed, err := signOfflineInputs(unsigned, func(int) ([]ScriptTemplate, error) {
+		return templates, nil
+	})
+	if err != nil {
+		return signed, err
+	}
+	if signed == 0 {
+		return 0, fmt.Errorf("no inputs are locked to %s", address)
+	}
+
+	return signed, nil
+}
+
+// signOfflineWithAccount signs the inputs of an unsigned transaction locked
+// to keys of an HD account
+func signOfflineWithAccount(unsigned *UnsignedTransaction, account *wallet.HDAccount) (int, error) {
+	var scanned []ScriptTemplate
+	signed, err := signOfflineInputs(unsigned, func(i int) ([]ScriptTemplate, error) {
+		if i < len(unsigned.InputPaths) && unsigned.InputPaths[i] != "" {
+			chain, index, err := account.ParsePath(unsigned.InputPaths[i])
+			if err != nil {
+				// Another account's input
+				return nil, nil
+			}
+			signer, err := wallet.NewHDSigner(account, chain, index)
+			if err != nil {
+				return nil, err
+			}
+			return keyTemplates(signer), nil
+		}
+
+		if scanned == nil {
+			for _, chain := range []uint32{wallet.ExternalChain, wallet.InternalChain} {
+				for index := uint32(0); index < offlineScanLimit; index++ {
+					signer, err := wallet.NewHDSigner(account, chain, index)
+					if err != nil {
+						return nil, err
+					}
+					scanned = append(scanned, keyTemplates(signer)...)
+				}
+			}
+		}
+		return scanned, nil
+	})
+	if err != nil {
+		return signed, err
+	}
+	if signed == 0 {
+		return 0, fmt.Errorf("no inputs are locked to keys of the account")
+	}
+
+	return signed, nil
+}
+
+// signOfflineInputs signs each input of an unsigned transaction with the
+// first of its templates that unlocks it, skipping inputs none unlock, and
+// returns the number of inputs signed
+func signOfflineInputs(unsigned *UnsignedTransaction, templatesFor func(input int) ([]ScriptTemplate, error)) (int, error) {
+	network := networkParams(unsigned.IsTestnet)
+
+	signed := 0
+	for i, input := range unsigned.Inputs {
+		script, err := inputScript(input, network)
+		if err != nil {
+			return signed, fmt.Errorf("input %d: %v", i, err)
+		}
+
+		templates, err := templatesFor(i)
+		if err != nil {
+			return signed, fmt.Errorf("input %d: %v", i, err)
+		}
+
+		unlocker := resolveUnlocker(script, templates)
+		if unlocker == nil {
+			continue
+		}
+
+		unlockingScript, err := unlocker.Unlock(unsigned.Tx, i, script, input.Value)
+		if err != nil {
+			return signed, fmt.Errorf("failed to sign input %d: %v", i, err)
+		}
+		unsigned.Tx.TxIn[i].SignatureScript = unlockingScript
+		signed++
+	}
+
+	return signed, nil
+}
+
+// Serialize encodes the unsigned transaction in the portable JSON format
+func (u *UnsignedTransaction) Serialize() ([]byte, error) {
+	if err := u.validate(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := u.Tx.Serialize(&buf); err != nil {
+		return nil, fmt.Errorf("failed to serialize transaction: %v", err)
+	}
+
+	return json.MarshalIndent(&unsignedTransactionJSON{
+		Version: u.Version,
+		Network: u.networkName(),
+		Tx:      hex.EncodeToString(buf.Bytes()),
+		Inputs:  u.Inputs,
+
+		Signatures:    u.Signatures,
+		ChangePath:    u.ChangePath,
+		ChangeAccount: u.ChangeAccount,
+		InputPaths:    u.InputPaths,
+	}, "", "  ")
+}
+
+// ParseUnsignedTransaction decodes an unsigned transaction from the portable JSON format
+func ParseUnsignedTransaction(data []byte) (*UnsignedTransaction, error) {
+	var encoded unsignedTransactionJSON
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, fmt.Errorf("failed to parse unsigned transaction: %v", err)
+	}
+
+	if encoded.Version != UnsignedTransactionVersion {
+		return nil, fmt.Errorf("unsupported unsigned transaction version: %d", encoded.Version)
+	}
+
+	if encoded.Network != networkMainnet && encoded.Network != networkTestnet {
+		return nil, fmt.Errorf("unknown network: %s", encoded.Network)
+	}
+
+	txBytes, err := hex.DecodeString(encoded.Tx)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction hex: %v", err)
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(txBytes)); err != nil {
+		return nil, fmt.Errorf("failed to deserialize transaction: %v", err)
+	}
+
+	unsigned := &UnsignedTransaction{
+		Version:   encoded.Version,
+		IsTestnet: encoded.Network == networkTestnet,
+		Tx:        tx,
+		Inputs:    encoded.Inputs,
+
+		Signatures:    encoded.Signatures,
+		ChangePath:    encoded.ChangePath,
+		ChangeAccount: encoded.ChangeAccount,
+		InputPaths:    encoded.InputPaths,
+	}
+
+	if err := unsigned.validate(); err != nil {
+		return nil, err
+	}
+
+	return unsigned, nil
+}
+
+// WriteFile saves the unsigned transaction to a file
+func (u *UnsignedTransaction) WriteFile(path string) error {
+	data, err := u.Serialize()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write unsigned transaction: %v", err)
+	}
+
+	return nil
+}
+
+// ReadUnsignedTransactionFile loads an unsigned transaction from a file
+func ReadUnsignedTransactionFile(path string) (*UnsignedTransaction, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read unsigned transaction: %v", err)
+	}
+
+	return ParseUnsignedTransaction(data)
+}
+
+// validate checks that every input has the prevout data needed to sign it
+func (u *UnsignedTransaction) validate() error {
+	if u == nil || u.Tx == nil {
+		return fmt.Errorf("unsigned transaction is empty")
+	}
+
+	if len(u.Inputs) != len(u.Tx.TxIn) {
+		return fmt.Errorf("transaction has %d inputs but %d prevouts", len(u.Tx.TxIn), len(u.Inputs))
+	}
+
+	for i, input := range u.Inputs {
+		outPoint := u.Tx.TxIn[i].PreviousOutPoint
+		if input.TxID != outPoint.Hash.String() || input.Vout != outPoint.Index {
+			return fmt.Errorf("prevout %d does not match input %s", i, outPoint.String())
+		}
+
+		if input.ScriptPubKey == "" {
+			return fmt.Errorf("prevout %d is missing its locking script", i)
+		}
+
+		if input.Value <= 0 {
+			return fmt.Errorf("prevout %d is missing its value", i)
+		}
+	}
+
+	if len(u.InputPaths) != 0 && len(u.InputPaths) != len(u.Inputs) {
+		return fmt.Errorf("transaction has %d inputs but %d input paths", len(u.Inputs), len(u.InputPaths))
+	}
+
+	if u.ChangePath != "" {
+		if u.ChangeAccount == "" {
+			return fmt.Errorf("change path %s has no account", u.ChangePath)
+		}
+		if _, err := changePathIndex(u.ChangePath); err != nil {
+			return err
+		}
+	}
+
+	for i, signature := range u.Signatures {
+		if signature.Input < 0 || signature.Input >= len(u.Tx.TxIn) {
+			return fmt.Errorf("signature %d is for missing input %d", i, signature.Input)
+		}
+	}
+
+	return nil
+}
+
+// changePathIndex returns the address index, the last element, of a change
+// derivation path
+func changePathIndex(path string) (uint32, error) {
+	index, err := strconv.ParseUint(path[strings.LastIndex(path, "/")+1:], 10, 31)
+	if err != nil {
+		return 0, fmt.Errorf("invalid change path %s", path)
+	}
+	return uint32(index), nil
+}
+
+func (u *UnsignedTransaction) networkName() string {
+	if u.IsTestnet {
+		return networkTestnet
+	}
+	return networkMainnet
+}
+
+// inputScript returns the locking script of a prevout, deriving it from the
+// address when the script is not known
+func inputScript(input types.UTXO, network *chaincfg.Params) ([]byte, error) {
+	if input.ScriptPubKey != "" {
+		script, err := hex.DecodeString(input.ScriptPubKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid locking script: %v", err)
+		}
+		return script, nil
+	}
+
+	return payToAddressScript(input.Address, network)
+}
+
+// payToAddressScript creates the P2PKH locking script for an address
+func payToAddressScript(address string, network *chaincfg.Params) ([]byte, error) {
+	template, err := P2PKHFromAddress(address, network)
+	if err != nil {
+		return nil, err
+	}
+
+	return template.Lock()
+}
@@ -0,0 +1,510 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/utxo"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// TransactionSizeError is returned when a transaction exceeds
+// TransactionConfig.MaxTransactionSize
+type TransactionSizeError struct {
+	Size    int // Serialized size in bytes
+	MaxSize int // Configured maximum in bytes
+}
+
+// Error implements error
+func (e *TransactionSizeError) Error() string {
+	return fmt.Sprintf("transaction size %d bytes exceeds maximum %d bytes", e.Size, e.MaxSize)
+}
+
+// Unwrap allows errors.Is(err, types.ErrTransactionTooLarge)
+func (e *TransactionSizeError) Unwrap() error {
+	return types.ErrTransactionTooLarge
+}
+
+// payoutBatch is the set of payout outputs carried by one transaction
+type payoutBatch struct {
+	outputs []*types.PayoutOutput
+	amount  int64
+}
+
+// BuildPayout builds and signs the transactions for a multi-output payout. With
+// SplitNone the payout must fit in a single transaction; otherwise it is split
+// into as many transactions under MaxTransactionSize as needed. HD change
+// indexes stay reserved, since the caller broadcasts the transactions.
+func (b *Builder) BuildPayout(params *types.PayoutParams) ([]*wire.MsgTx, error) {
+	signer, account, err := b.keySigner(params.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	built, err := b.buildPayout(params, signer, account)
+	if err != nil {
+		return nil, err
+	}
+	return payoutTransactions(built), nil
+}
+
+// BuildPayoutWithSigner builds a payout signed by signer rather than
+// params.PrivateKey, which must be empty
+func (b *Builder) BuildPayoutWithSigner(params *types.PayoutParams, signer wallet.Signer) ([]*wire.MsgTx, error) {
+	account, err := signerAccount(params.PrivateKey, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	built, err := b.buildPayout(params, signer, account)
+	if err != nil {
+		return nil, err
+	}
+	return payoutTransactions(built), nil
+}
+
+// SignAndSendPayout builds, signs, and broadcasts a payout in order
+func (b *Builder) SignAndSendPayout(params *types.PayoutParams) ([]*types.TransactionResult, error) {
+	signer, account, err := b.keySigner(params.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build payout: %w", err)
+	}
+
+	return b.signAndSendPayout(params, signer, account)
+}
+
+// SignAndSendPayoutWithSigner builds, signs with signer, and broadcasts a
+// payout in order. params.PrivateKey must be empty.
+func (b *Builder) SignAndSendPayoutWithSigner(params *types.PayoutParams, signer wallet.Signer) ([]*types.TransactionResult, error) {
+	account, err := signerAccount(params.PrivateKey, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build payout: %w", err)
+	}
+
+	return b.signAndSendPayout(params, signer, account)
+}
+
+func (b *Builder) signAndSendPayout(params *types.PayoutParams, signer wallet.Signer, account *wallet.HDAccount) ([]*types.TransactionResult, error) {
+	built, err := b.buildPayout(params, signer, account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build payout: %w", err)
+	}
+
+	var results []*types.TransactionResult
+	for i, unsigned := range built {
+		var buf bytes.Buffer
+		if err := unsigned.Tx.Serialize(&buf); err != nil {
+			b.releaseChangeIndexes(built[i:])
+			return results, fmt.Errorf("failed to serialize transaction %d: %v", i, err)
+		}
+
+		// Transactions after a failed one are never broadcast either
+		if err := b.broadcastTransaction(unsigned.Tx, unsigned.Inputs); err != nil {
+			b.releaseChangeIndexes(built[i:])
+			return results, fmt.Errorf("failed to broadcast transaction %d: %v", i, err)
+		}
+
+		results = append(results, b.broadcastResult(unsigned, buf.Bytes()))
+	}
+
+	return results, nil
+}
+
+// broadcastResult describes a broadcast transaction from its inputs and
+// outputs. An HD change output, always last, is reported with its path.
+func (b *Builder) broadcastResult(unsigned *UnsignedTransaction, txBytes []byte) *types.TransactionResult {
+	tx, inputs := unsigned.Tx, unsigned.Inputs
+
+	var totalInput, totalOutput int64
+	var inputsUsed []*types.UTXO
+	for i := range inputs {
+		totalInput += inputs[i].Value
+		inputsUsed = append(inputsUsed, &inputs[i])
+	}
+
+	var outputsCreated []*types.TransactionOutput
+	for _, txOut := range tx.TxOut {
+		totalOutput += txOut.Value
+		outputsCreated = append(outputsCreated, &types.TransactionOutput{
+			Amount:       txOut.Value,
+			ScriptPubKey: hex.EncodeToString(txOut.PkScript),
+		})
+	}
+
+	var change int64
+	if last := len(outputsCreated) - 1; unsigned.ChangePath != "" && last >= 0 {
+		change = outputsCreated[last].Amount
+		outputsCreated[last].DerivationPath = unsigned.ChangePath
+	}
+
+	txID := tx.TxHash().String()
+	return &types.TransactionResult{
+		SignedTx:       hex.EncodeToString(txBytes),
+		TxID:           txID,
+		Fee:            totalInput - totalOutput,
+		Change:         change,
+		ExplorerURL:    fmt.Sprintf("%s/tx/%s", b.configManager.GetNetworkConfig().ExplorerURL, txID),
+		InputsUsed:     inputsUsed,
+		OutputsCreated: outputsCreated,
+	}
+}
+
+// payoutTransactions returns the signed transactions of a payout
+func payoutTransactions(built []*UnsignedTransaction) []*wire.MsgTx {
+	txs := make([]*wire.MsgTx, len(built))
+	for i, unsigned := range built {
+		txs[i] = unsigned.Tx
+	}
+	return txs
+}
+
+// releaseChangeIndexes releases the HD change indexes of transactions that
+// will not be broadcast
+func (b *Builder) releaseChangeIndexes(built []*UnsignedTransaction) {
+	for _, unsigned := range built {
+		b.releaseChangeIndex(unsigned.ChangeAccount, unsigned.ChangePath)
+	}
+}
+
+// buildPayout builds and signs the transactions of a payout. account is the
+// sender's HD account, or nil if it has none.
+func (b *Builder) buildPayout(params *types.PayoutParams, signer wallet.Signer, account *wallet.HDAccount) ([]*UnsignedTransaction, error) {
+	if err := b.validatePayoutParams(params); err != nil {
+		return nil, err
+	}
+
+	senderAddress, err := wallet.SignerAddress(signer, b.getNetwork())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sender info: %v", err)
+	}
+	if senderAddress != params.From {
+		return nil, fmt.Errorf("sender address mismatch: expected %s, got %s", params.From, senderAddress)
+	}
+
+	// Payouts carry no data, so only the standard rate applies
+	quote, err := b.feeQuote(params.FeeRate)
+	if err != nil {
+		return nil, err
+	}
+	feeRate := quote.Standard
+
+	available, truncated, err := b.utxoManager.GetSpendableUTXOs(params.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get UTXOs: %v", err)
+	}
+
+	var built []*UnsignedTransaction
+
+	switch params.SplitMode {
+	case "", types.SplitNone:
+		batch := newPayoutBatch(params.Outputs)
+		selected, fee, err := b.selectForBatch(available, batch, feeRate)
+		if err != nil {
+			return nil, utxo.TruncationError(err, truncated)
+		}
+		unsigned, err := b.buildPayoutTransaction(params.From, account, selected, batch, fee, signer)
+		if err != nil {
+			return nil, err
+		}
+		built = []*UnsignedTransaction{unsigned}
+
+	case types.SplitIndependent:
+		built, err = b.buildIndependentPayout(params, available, feeRate, signer, account)
+		if err != nil {
+			return nil, utxo.TruncationError(err, truncated)
+		}
+
+	case types.SplitChained:
+		built, err = b.buildChainedPayout(params, available, feeRate, signer, account)
+		if err != nil {
+			return nil, utxo.TruncationError(err, truncated)
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported split mode: %s", params.SplitMode)
+	}
+
+	for _, unsigned := range built {
+		if err := b.checkTransactionSize(unsigned.Tx); err != nil {
+			b.releaseChangeIndexes(built)
+			return nil, err
+		}
+	}
+
+	return built, nil
+}
+
+func (b *Builder) validatePayoutParams(params *types.PayoutParams) error {
+	if params.From == "" {
+		return fmt.Errorf("sender address is required")
+	}
+	if len(params.Outputs) == 0 {
+		return fmt.Errorf("at least one payout output is required")
+	}
+	if err := b.validateFeeRate(params.FeeRate); err != nil {
+		return err
+	}
+
+	for i, output := range params.Outputs {
+		if output.To == "" {
+			return fmt.Errorf("payout output %d: recipient address is required", i)
+		}
+		if output.Amount <= 0 {
+			return fmt.Errorf("payout output %d: amount must be positive", i)
+		}
+	}
+
+	return nil
+}
+
+// buildIndependentPayout packs outputs into transactions that each select
+// their own UTXOs. Each batch takes as many outputs as fit next to the inputs
+// it needs, so UTXOs are selected again only when a batch needs more inputs
+// than it was sized for.
+func (b *Builder) buildIndependentPayout(params *types.PayoutParams, available []types.UTXO, feeRate FeeRate, signer wallet.Signer, account *wallet.HDAccount) ([]*UnsignedTransaction, error) {
+	maxSize := b.configManager.GetTransactionConfig().MaxTransactionSize
+
+	var built []*UnsignedTransaction
+
+	remaining := params.Outputs
+	for len(remaining) > 0 {
+		// Size the batch for a single input, then shrink it to fit the
+		// inputs actually selected
+		count := min(payoutOutputsFit(1, maxSize), len(remaining))
+		var batch *payoutBatch
+		var selected []types.UTXO
+		var fee int64
+		for {
+			if count < 1 {
+				b.releaseChangeIndexes(built)
+				return nil, &TransactionSizeError{Size: estimatePayoutSize(max(len(selected), 1), 1), MaxSize: maxSize}
+			}
+
+			var err error
+			batch = newPayoutBatch(remaining[:count])
+			selected, fee, err = b.selectForBatch(available, batch, feeRate)
+			if err != nil {
+				b.releaseChangeIndexes(built)
+				return nil, err
+			}
+			if estimatePayoutSize(len(selected), count) <= maxSize {
+				break
+			}
+			count = min(count-1, payoutOutputsFit(len(selected), maxSize))
+		}
+
+		unsigned, err := b.buildPayoutTransaction(params.From, account, selected, batch, fee, signer)
+		if err != nil {
+			b.releaseChangeIndexes(built)
+			return nil, err
+		}
+
+		built = append(built, unsigned)
+		available = excludeUTXOs(available, selected)
+		remaining = remaining[count:]
+	}
+
+	return built, nil
+}
+
+// buildChainedPayout funds the whole payout once; every following transaction
+// spends the previous transaction's change output
+func (b *Builder) buildChainedPayout(params *types.PayoutParams, available []types.UTXO, feeRate FeeRate, signer wallet.Signer, account *wallet.HDAccount) ([]*UnsignedTransaction, error) {
+	maxSize := b.configManager.GetTransactionConfig().MaxTransactionSize
+
+	// Outputs that fit next to a single input and change output
+	perChained := payoutOutputsFit(1, maxSize)
+	if perChained < 1 {
+		return nil, &TransactionSizeError{Size: estimatePayoutSize(1, 1), MaxSize: maxSize}
+	}
+
+	// Shrink the first batch until its funding inputs fit as well
+	for firstCount := min(perChained, len(params.Outputs)); firstCount >= 1; firstCount-- {
+		batches := []*payoutBatch{newPayoutBatch(params.Outputs[:firstCount])}
+		for start := firstCount; start < len(params.Outputs); start += perChained {
+			end := min(start+perChained, len(params.Outputs))
+			batches = append(batches, newPayoutBatch(params.Outputs[start:end]))
+		}
+
+		// The first transaction also funds every later payment and fee
+		var laterCost int64
+		for _, batch := range batches[1:] {
+			laterCost += batch.amount + feeRate.FeeForSize(estimatePayoutSize(1, len(batch.outputs)))
+		}
+
+		first := &payoutBatch{outputs: batches[0].outputs, amount: batches[0].amount + laterCost}
+		selected, fee, err := b.selectForBatch(available, first, feeRate)
+		if err != nil {
+			return nil, err
+		}
+		if estimatePayoutSize(len(selected), firstCount) > maxSize {
+			continue
+		}
+
+		var built []*UnsignedTransaction
+
+		spend := selected
+		signers := []wallet.Signer{signer}
+		for i, batch := range batches {
+			batchFee := feeRate.FeeForSize(estimatePayoutSize(len(spend), len(batch.outputs)))
+			if i == 0 {
+				batchFee = fee
+			}
+
+			unsigned, err := b.buildPayoutTransaction(params.From, account, spend, batch, batchFee, signers...)
+			if err != nil {
+				b.releaseChangeIndexes(built)
+				return nil, err
+			}
+			built = append(built, unsigned)
+
+			if i == len(batches)-1 {
+				break
+			}
+
+			// The change output is always last
+			tx := unsigned.Tx
+			changeIndex := len(tx.TxOut) - 1
+			if changeIndex < len(batch.outputs) {
+				b.releaseChangeIndexes(built)
+				return nil, fmt.Errorf("chained transaction %d has no change output to spend", i)
+			}
+
+			// HD change is spent with the key of its internal address
+			changeAddress := params.From
+			signers = []wallet.Signer{signer}
+			if unsigned.ChangePath != "" {
+				changeSigner, err := b.changeSigner(account, unsigned.ChangePath)
+				if err != nil {
+					b.releaseChangeIndexes(built)
+					return nil, err
+				}
+				if changeAddress, err = wallet.SignerAddress(changeSigner, b.getNetwork()); err != nil {
+					b.releaseChangeIndexes(built)
+					return nil, err
+				}
+				signers = append(signers, changeSigner)
+			}
+
+			spend = []types.UTXO{{
+				TxID:         tx.TxHash().String(),
+				Vout:         uint32(changeIndex),
+				Value:        tx.TxOut[changeIndex].Value,
+				ScriptPubKey: hex.EncodeToString(tx.TxOut[changeIndex].PkScript),
+				Address:      changeAddress,
+				Type:         types.UTXOTypeP2PKH,
+				IsNative:     true,
+			}}
+		}
+
+		return built, nil
+	}
+
+	return nil, &TransactionSizeError{Size: estimatePayoutSize(len(available), 1), MaxSize: maxSize}
+}
+
+// selectForBatch selects UTXOs paying a batch of P2PKH outputs plus change
+func (b *Builder) selectForBatch(available []types.UTXO, batch *payoutBatch, feeRate FeeRate) ([]types.UTXO, int64, error) {
+	txConfig := b.configManager.GetTransactionConfig()
+
+	selector, err := utxo.NewCoinSelector(txConfig.CoinSelectionStrategy)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	target := utxo.NewSelectionTarget(batch.amount, feeRate, txConfig.DustLimit)
+	target.BaseSize = utxo.BaseTxSize + len(batch.outputs)*utxo.P2PKHOutputSize
+
+	return selector.Select(available, target)
+}
+
+// buildPayoutTransaction builds and signs one payout transaction, with its
+// change going where changeOutputScript sends it
+func (b *Builder) buildPayoutTransaction(from string, account *wallet.HDAccount, inputs []types.UTXO, batch *payoutBatch, fee int64, signers ...wallet.Signer) (*UnsignedTransaction, error) {
+	network := b.getNetwork()
+	tx := wire.NewMsgTx(wire.TxVersion)
+
+	for _, input := range inputs {
+		txHash, err := chainhash.NewHashFromStr(input.TxID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UTXO transaction hash: %v", err)
+		}
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(txHash, input.Vout), nil, nil))
+	}
+
+	for _, output := range batch.outputs {
+		script, err := payToAddressScript(output.To, network)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient address %s: %v", output.To, err)
+		}
+		tx.AddTxOut(wire.NewTxOut(output.Amount, script))
+	}
+
+	unsigned := &UnsignedTransaction{Tx: tx, Inputs: inputs}
+	change, hasChange := b.utxoManager.CalculateChange(inputs, batch.amount, fee)
+	if hasChange {
+		changeScript, changePath, err := b.changeOutputScript(from, account)
+		if err != nil {
+			return nil, fmt.Errorf("invalid change address: %v", err)
+		}
+		tx.AddTxOut(wire.NewTxOut(change, changeScript))
+
+		if changePath != "" {
+			unsigned.ChangePath, unsigned.ChangeAccount = changePath, account.ID()
+		}
+	}
+
+	if err := b.signTransaction(tx, inputs, signers...); err != nil {
+		b.releaseChangeIndex(unsigned.ChangeAccount, unsigned.ChangePath)
+		return nil, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+
+	return unsigned, nil
+}
+
+func newPayoutBatch(outputs []*types.PayoutOutput) *payoutBatch {
+	batch := &payoutBatch{outputs: outputs}
+	for _, output := range outputs {
+		batch.amount += output.Amount
+	}
+	return batch
+}
+
+// estimatePayoutSize estimates the size of a P2PKH transaction with change
+func estimatePayoutSize(numInputs, numOutputs int) int {
+	// Version and locktime plus the input and output count varints
+	size := 8 + wire.VarIntSerializeSize(uint64(numInputs)) + wire.VarIntSerializeSize(uint64(numOutputs+1))
+	return size + numInputs*utxo.P2PKHInputSize + (numOutputs+1)*utxo.P2PKHOutputSize
+}
+
+// payoutOutputsFit returns how many payout outputs fit in a transaction of at
+// most maxSize bytes with numInputs inputs and change
+func payoutOutputsFit(numInputs, maxSize int) int {
+	count := (maxSize - estimatePayoutSize(numInputs, 0)) / utxo.P2PKHOutputSize
+	// The output count varint grows past 252 outputs
+	for count > 0 && estimatePayoutSize(numInputs, count) > maxSize {
+		count--
+	}
+	return max(count, 0)
+}
+
+// excludeUTXOs returns utxos without the ones in spent
+func excludeUTXOs(utxos, spent []types.UTXO) []types.UTXO {
+	used := make(map[string]bool, len(spent))
+	for _, u := range spent {
+		used[fmt.Sprintf("%s:%d", u.TxID, u.Vout)] = true
+	}
+
+	var remaining []types.UTXO
+	for _, u := range utxos {
+		if !used[fmt.Sprintf("%s:%d", u.TxID, u.Vout)] {
+			remaining = append(remaining, u)
+		}
+	}
+	return remaining
+}
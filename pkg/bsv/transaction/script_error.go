@@ -0,0 +1,69 @@
+package transaction
+
+import (
+	"fmt"
+)
+
+// ScriptErrorCode identifies why a script failed. The values match the error
+// names used by the BSV node and its script_tests.json.
+type ScriptErrorCode string
+
+// Script error codes
+const (
+	ErrEvalFalse                ScriptErrorCode = "EVAL_FALSE"
+	ErrOpReturn                 ScriptErrorCode = "OP_RETURN"
+	ErrScriptSize               ScriptErrorCode = "SCRIPT_SIZE"
+	ErrPushSize                 ScriptErrorCode = "PUSH_SIZE"
+	ErrOpCount                  ScriptErrorCode = "OP_COUNT"
+	ErrStackSize                ScriptErrorCode = "STACK_SIZE"
+	ErrSigCount                 ScriptErrorCode = "SIG_COUNT"
+	ErrPubKeyCount              ScriptErrorCode = "PUBKEY_COUNT"
+	ErrInvalidOperandSize       ScriptErrorCode = "INVALID_OPERAND_SIZE"
+	ErrInvalidNumberRange       ScriptErrorCode = "INVALID_NUMBER_RANGE"
+	ErrImpossibleEncoding       ScriptErrorCode = "IMPOSSIBLE_ENCODING"
+	ErrInvalidSplitRange        ScriptErrorCode = "SPLIT_RANGE"
+	ErrScriptNumOverflow        ScriptErrorCode = "SCRIPTNUM_OVERFLOW"
+	ErrScriptNumMinEncode       ScriptErrorCode = "SCRIPTNUM_MINENCODE"
+	ErrDivByZero                ScriptErrorCode = "DIV_BY_ZERO"
+	ErrModByZero                ScriptErrorCode = "MOD_BY_ZERO"
+	ErrVerify                   ScriptErrorCode = "VERIFY"
+	ErrEqualVerify              ScriptErrorCode = "EQUALVERIFY"
+	ErrCheckMultiSigVerify      ScriptErrorCode = "CHECKMULTISIGVERIFY"
+	ErrCheckSigVerify           ScriptErrorCode = "CHECKSIGVERIFY"
+	ErrNumEqualVerify           ScriptErrorCode = "NUMEQUALVERIFY"
+	ErrBadOpcode                ScriptErrorCode = "BAD_OPCODE"
+	ErrDisabledOpcode           ScriptErrorCode = "DISABLED_OPCODE"
+	ErrInvalidStackOperation    ScriptErrorCode = "INVALID_STACK_OPERATION"
+	ErrInvalidAltStackOperation ScriptErrorCode = "INVALID_ALTSTACK_OPERATION"
+	ErrUnbalancedConditional    ScriptErrorCode = "UNBALANCED_CONDITIONAL"
+	ErrNegativeLockTime         ScriptErrorCode = "NEGATIVE_LOCKTIME"
+	ErrUnsatisfiedLockTime      ScriptErrorCode = "UNSATISFIED_LOCKTIME"
+	ErrSigHashType              ScriptErrorCode = "SIG_HASHTYPE"
+	ErrSigDER                   ScriptErrorCode = "SIG_DER"
+	ErrMinimalData              ScriptErrorCode = "MINIMALDATA"
+	ErrSigPushOnly              ScriptErrorCode = "SIG_PUSHONLY"
+	ErrSigHighS                 ScriptErrorCode = "SIG_HIGH_S"
+	ErrSigNullDummy             ScriptErrorCode = "SIG_NULLDUMMY"
+	ErrPubKeyType               ScriptErrorCode = "PUBKEYTYPE"
+	ErrCleanStack               ScriptErrorCode = "CLEANSTACK"
+	ErrMinimalIf                ScriptErrorCode = "MINIMALIF"
+	ErrNullFail                 ScriptErrorCode = "NULLFAIL"
+	ErrDiscourageUpgradableNops ScriptErrorCode = "DISCOURAGE_UPGRADABLE_NOPS"
+	ErrIllegalForkID            ScriptErrorCode = "ILLEGAL_FORKID"
+	ErrMustUseForkID            ScriptErrorCode = "MUST_USE_FORKID"
+)
+
+// ScriptError is returned when script evaluation fails
+type ScriptError struct {
+	Code        ScriptErrorCode
+	Description string
+}
+
+// Error implements error
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Description)
+}
+
+func scriptError(code ScriptErrorCode, format string, args ...interface{}) *ScriptError {
+	return &ScriptError{Code: code, Description: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,144 @@
+package transaction
+
+import (
+	"math/big"
+)
+
+// Maximum encoded length of script numbers
+const (
+	maxScriptNumLengthBeforeGenesis = 4
+	maxScriptNumLengthAfterGenesis  = 750000
+)
+
+// decodeScriptNum decodes a little-endian sign-magnitude script number. With
+// requireMinimal the encoding must not have unnecessary trailing bytes.
+func decodeScriptNum(data []byte, maxLength int, requireMinimal bool) (*big.Int, error) {
+	if len(data) > maxLength {
+		return nil, scriptError(ErrScriptNumOverflow, "script number is %d bytes, maximum is %d", len(data), maxLength)
+	}
+
+	if requireMinimal && !isMinimallyEncoded(data) {
+		return nil, scriptError(ErrScriptNumMinEncode, "script number %x is not minimally encoded", data)
+	}
+
+	if len(data) == 0 {
+		return new(big.Int), nil
+	}
+
+	// Convert to big-endian magnitude, clearing the sign bit
+	magnitude := make([]byte, len(data))
+	for i, b := range data {
+		magnitude[len(data)-1-i] = b
+	}
+	negative := magnitude[0]&0x80 != 0
+	magnitude[0] &= 0x7f
+
+	n := new(big.Int).SetBytes(magnitude)
+	if negative {
+		n.Neg(n)
+	}
+	return n, nil
+}
+
+// encodeScriptNum encodes a number in the minimal script number form
+func encodeScriptNum(n *big.Int) []byte {
+	if n.Sign() == 0 {
+		return []byte{}
+	}
+
+	magnitude := new(big.Int).Abs(n).Bytes()
+
+	// Little-endian magnitude
+	result := make([]byte, len(magnitude), len(magnitude)+1)
+	for i, b := range magnitude {
+		result[len(magnitude)-1-i] = b
+	}
+
+	// Add a sign byte when the top bit is taken by the magnitude
+	if result[len(result)-1]&0x80 != 0 {
+		if n.Sign() < 0 {
+			result = append(result, 0x80)
+		} else {
+			result = append(result, 0x00)
+		}
+	} else if n.Sign() < 0 {
+		result[len(result)-1] |= 0x80
+	}
+
+	return result
+}
+
+// isMinimallyEncoded reports whether a script number has no unnecessary bytes
+func isMinimallyEncoded(data []byte) bool {
+	if len(data) == 0 {
+		return true
+	}
+
+	// The last byte may only be zero (apart from the sign bit) when the
+	// previous byte needs its top bit for the magnitude
+	if data[len(data)-1]&0x7f == 0 {
+		if len(data) == 1 || data[len(data)-2]&0x80 == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// minimallyEncode strips unnecessary bytes from a script number encoding
+func minimallyEncode(data []byte) []byte {
+	if len(data) == 0 {
+		return data
+	}
+
+	last := data[len(data)-1]
+	if last&0x7f != 0 {
+		return data
+	}
+
+	if len(data) == 1 {
+		return []byte{}
+	}
+
+	if data[len(data)-2]&0x80 != 0 {
+		return data
+	}
+
+	result := append([]byte(nil), data...)
+	for i := len(result) - 1; i > 0; i-- {
+		if result[i-1] != 0 {
+			if result[i-1]&0x80 != 0 {
+				result[i] = last
+				i++
+			} else {
+				result[i-1] |= last
+			}
+			return result[:i]
+		}
+	}
+
+	return []byte{}
+}
+
+// castToBool interprets a stack element as a boolean. Any non-zero value is
+// true, except negative zero.
+func castToBool(data []byte) bool {
+	for i, b := range data {
+		if b != 0 {
+			// Negative zero is false
+			if i == len(data)-1 && b == 0x80 {
+				return false
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// boolBytes returns the stack encoding of a boolean
+func boolBytes(value bool) []byte {
+	if value {
+		return []byte{0x01}
+	}
+	return []byte{}
+}
@@ -0,0 +1,261 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+)
+
+// SigHashType selects which parts of a transaction a signature commits to
+type SigHashType uint32
+
+// Signature hash types
+const (
+	SigHashAll          SigHashType = 0x01
+	SigHashNone         SigHashType = 0x02
+	SigHashSingle       SigHashType = 0x03
+	SigHashForkID       SigHashType = 0x40
+	SigHashAnyOneCanPay SigHashType = 0x80
+
+	// SigHashAllForkID is the hash type used for BSV signatures
+	SigHashAllForkID = SigHashAll | SigHashForkID
+
+	sigHashBaseMask SigHashType = 0x1f
+)
+
+// hasForkID reports whether the hash type uses the FORKID digest
+func (h SigHashType) hasForkID() bool {
+	return h&SigHashForkID != 0
+}
+
+// base returns the hash type without the FORKID and ANYONECANPAY bits
+func (h SigHashType) base() SigHashType {
+	return h & sigHashBaseMask
+}
+
+// SignatureHash computes the digest an input's signature commits to. Hash
+// types with SigHashForkID use the BSV FORKID digest, which commits to the
+// spent amount; others use the original digest.
+func SignatureHash(tx *wire.MsgTx, index int, subScript []byte, hashType SigHashType, amount int64) ([]byte, error) {
+	if index < 0 || index >= len(tx.TxIn) {
+		return nil, fmt.Errorf("input index %d out of range", index)
+	}
+
+	if hashType.hasForkID() {
+		return forkIDSignatureHash(tx, index, subScript, hashType, amount), nil
+	}
+
+	return legacySignatureHash(tx, index, subScript, hashType), nil
+}
+
+// SignaturePreimage returns the data whose double SHA-256 is the signature
+// hash, for hash types with SigHashForkID. Signers can decode it to check
+// what a signature commits to.
+func SignaturePreimage(tx *wire.MsgTx, index int, subScript []byte, hashType SigHashType, amount int64) ([]byte, error) {
+	if index < 0 || index >= len(tx.TxIn) {
+		return nil, fmt.Errorf("input index %d out of range", index)
+	}
+	if !hashType.hasForkID() {
+		return nil, fmt.Errorf("hash type %#x has no FORKID preimage", uint32(hashType))
+	}
+
+	return forkIDPreimage(tx, index, subScript, hashType, amount), nil
+}
+
+// SignatureOutputs returns the serialized outputs whose double SHA-256 is the
+// hashOutputs field of an input's FORKID preimage: every output, the
+// matching output for SIGHASH_SINGLE, or nil when no output is signed
+func SignatureOutputs(tx *wire.MsgTx, index int, hashType SigHashType) ([]byte, error) {
+	if index < 0 || index >= len(tx.TxIn) {
+		return nil, fmt.Errorf("input index %d out of range", index)
+	}
+	if !hashType.hasForkID() {
+		return nil, fmt.Errorf("hash type %#x has no FORKID preimage", uint32(hashType))
+	}
+
+	return forkIDOutputs(tx, index, hashType), nil
+}
+
+// forkIDSignatureHash computes the BIP143-style digest used by BSV
+func forkIDSignatureHash(tx *wire.MsgTx, index int, subScript []byte, hashType SigHashType, amount int64) []byte {
+	return chainhash.DoubleHashB(forkIDPreimage(tx, index, subScript, hashType, amount))
+}
+
+// forkIDPreimage serializes the fields the FORKID digest commits to
+func forkIDPreimage(tx *wire.MsgTx, index int, subScript []byte, hashType SigHashType, amount int64) []byte {
+	var hashPrevouts, hashSequence, hashOutputs chainhash.Hash
+	var scratch [8]byte
+
+	if hashType&SigHashAnyOneCanPay == 0 {
+		var buf bytes.Buffer
+		for _, txIn := range tx.TxIn {
+			buf.Write(txIn.PreviousOutPoint.Hash[:])
+			binary.LittleEndian.PutUint32(scratch[:4], txIn.PreviousOutPoint.Index)
+			buf.Write(scratch[:4])
+		}
+		hashPrevouts = chainhash.DoubleHashH(buf.Bytes())
+	}
+
+	if hashType&SigHashAnyOneCanPay == 0 && hashType.base() != SigHashSingle && hashType.base() != SigHashNone {
+		var buf bytes.Buffer
+		for _, txIn := range tx.TxIn {
+			binary.LittleEndian.PutUint32(scratch[:4], txIn.Sequence)
+			buf.Write(scratch[:4])
+		}
+		hashSequence = chainhash.DoubleHashH(buf.Bytes())
+	}
+
+	if outputs := forkIDOutputs(tx, index, hashType); outputs != nil {
+		hashOutputs = chainhash.DoubleHashH(outputs)
+	}
+
+	txIn := tx.TxIn[index]
+
+	var preimage bytes.Buffer
+	binary.LittleEndian.PutUint32(scratch[:4], uint32(tx.Version))
+	preimage.Write(scratch[:4])
+	preimage.Write(hashPrevouts[:])
+	preimage.Write(hashSequence[:])
+	preimage.Write(txIn.PreviousOutPoint.Hash[:])
+	binary.LittleEndian.PutUint32(scratch[:4], txIn.PreviousOutPoint.Index)
+	preimage.Write(scratch[:4])
+	wire.WriteVarBytes(&preimage, 0, subScript)
+	binary.LittleEndian.PutUint64(scratch[:], uint64(amount))
+	preimage.Write(scratch[:])
+	binary.LittleEndian.PutUint32(scratch[:4], txIn.Sequence)
+	preimage.Write(scratch[:4])
+	preimage.Write(hashOutputs[:])
+	binary.LittleEndian.PutUint32(scratch[:4], tx.LockTime)
+	preimage.Write(scratch[:4])
+	binary.LittleEndian.PutUint32(scratch[:4], uint32(hashType))
+	preimage.Write(scratch[:4])
+
+	return preimage.Bytes()
+}
+
+// forkIDOutputs serializes the outputs the FORKID digest commits to
+func forkIDOutputs(tx *wire.MsgTx, index int, hashType SigHashType) []byte {
+	var buf bytes.Buffer
+	switch {
+	case hashType.base() != SigHashSingle && hashType.base() != SigHashNone:
+		for _, txOut := range tx.TxOut {
+			wire.WriteTxOut(&buf, 0, 0, txOut)
+		}
+	case hashType.base() == SigHashSingle && index < len(tx.TxOut):
+		wire.WriteTxOut(&buf, 0, 0, tx.TxOut[index])
+	default:
+		return nil
+	}
+	return buf.Bytes()
+}
+
+// legacySignatureHash computes the original Satoshi digest, including the
+// SIGHASH_SINGLE behaviour of signing the number one when there is no
+// matching output
+func legacySignatureHash(tx *wire.MsgTx, index int, subScript []byte, hashType SigHashType) []byte {
+	if hashType.base() == SigHashSingle && index >= len(tx.TxOut) {
+		var one [chainhash.HashSize]byte
+		one[0] = 0x01
+		return one[:]
+	}
+
+	// The script code never includes OP_CODESEPARATOR
+	scriptCode := removeOpcode(subScript, txscript.OP_CODESEPARATOR)
+
+	txCopy := tx.Copy()
+	for i := range txCopy.TxIn {
+		if i == index {
+			txCopy.TxIn[i].SignatureScript = scriptCode
+		} else {
+			txCopy.TxIn[i].SignatureScript = nil
+		}
+	}
+
+	switch hashType.base() {
+	case SigHashNone:
+		txCopy.TxOut = nil
+		for i := range txCopy.TxIn {
+			if i != index {
+				txCopy.TxIn[i].Sequence = 0
+			}
+		}
+	case SigHashSingle:
+		txCopy.TxOut = txCopy.TxOut[:index+1]
+		for i := 0; i < index; i++ {
+			txCopy.TxOut[i] = &wire.TxOut{Value: -1}
+		}
+		for i := range txCopy.TxIn {
+			if i != index {
+				txCopy.TxIn[i].Sequence = 0
+			}
+		}
+	}
+
+	if hashType&SigHashAnyOneCanPay != 0 {
+		txCopy.TxIn = txCopy.TxIn[index : index+1]
+	}
+
+	var buf bytes.Buffer
+	txCopy.SerializeNoWitness(&buf)
+	var scratch [4]byte
+	binary.LittleEndian.PutUint32(scratch[:], uint32(hashType))
+	buf.Write(scratch[:])
+
+	return chainhash.DoubleHashB(buf.Bytes())
+}
+
+// removeOpcode returns the script without any occurrence of an opcode.
+// Unparseable trailing bytes are kept as they are.
+func removeOpcode(script []byte, opcode byte) []byte {
+	result := make([]byte, 0, len(script))
+	for pc := 0; pc < len(script); {
+		op, err := parseOpcode(script, pc)
+		if err != nil {
+			return append(result, script[pc:]...)
+		}
+		if op.opcode != opcode {
+			result = append(result, script[pc:op.next]...)
+		}
+		pc = op.next
+	}
+	return result
+}
+
+// SignInput creates a signature for an input, with the hash type appended
+func SignInput(tx *wire.MsgTx, index int, subScript []byte, amount int64, hashType SigHashType, keyPair *wallet.KeyPair) ([]byte, error) {
+	return SignInputWithSigner(tx, index, subScript, amount, hashType, wallet.NewKeySigner(keyPair))
+}
+
+// SignInputWithSigner creates a signature for an input with a signer, with
+// the hash type appended. A wallet.PreimageSigner is given the preimage of
+// FORKID signature hashes, and the outputs it commits to, instead of the
+// bare hash.
+func SignInputWithSigner(tx *wire.MsgTx, index int, subScript []byte, amount int64, hashType SigHashType, signer wallet.Signer) ([]byte, error) {
+	var signature []byte
+	var err error
+	if preimageSigner, ok := signer.(wallet.PreimageSigner); ok && hashType.hasForkID() {
+		var preimage []byte
+		preimage, err = SignaturePreimage(tx, index, subScript, hashType, amount)
+		if err != nil {
+			return nil, err
+		}
+		signature, err = preimageSigner.SignPreimage(preimage, forkIDOutputs(tx, index, hashType))
+	} else {
+		var hash []byte
+		hash, err = SignatureHash(tx, index, subScript, hashType, amount)
+		if err != nil {
+			return nil, err
+		}
+		signature, err = signer.Sign(hash)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign input %d: %v", index, err)
+	}
+	return append(signature, byte(hashType)), nil
+}
@@ -0,0 +1,613 @@
+package transaction
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/ordinals"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// ScriptTemplate creates a locking script and the unlocking script that spends it
+type ScriptTemplate interface {
+	// Lock returns the locking script
+	Lock() ([]byte, error)
+
+	// Unlock returns the unlocking script for input index of tx, which spends
+	// an output of amount satoshis locked with lockingScript
+	Unlock(tx *wire.MsgTx, index int, lockingScript []byte, amount int64) ([]byte, error)
+
+	// EstimateUnlockLength returns the expected unlocking script size in bytes
+	EstimateUnlockLength() int
+}
+
+// Unlocking script sizes used for fee estimation. Signatures are low-S DER
+// of at most 71 bytes plus the hash type byte.
+const (
+	maxSignaturePushSize = 1 + 72
+	publicKeyPushSize    = 1 + 33
+)
+
+// EstimateInputSize returns the serialized size of an input unlocked by a template
+func EstimateInputSize(template ScriptTemplate) int {
+	unlockLength := template.EstimateUnlockLength()
+	// Outpoint, script length varint, script and sequence
+	return 36 + wire.VarIntSerializeSize(uint64(unlockLength)) + unlockLength + 4
+}
+
+// checkLockingScript rejects an output that was not locked by the template
+func checkLockingScript(template ScriptTemplate, lockingScript []byte) error {
+	expected, err := template.Lock()
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(expected, lockingScript) {
+		return fmt.Errorf("locking script %x does not match the template", lockingScript)
+	}
+
+	return nil
+}
+
+// keyTemplates returns the templates a single key can unlock without registration
+func keyTemplates(signer wallet.Signer) []ScriptTemplate {
+	return []ScriptTemplate{NewP2PKHSignerTemplate(signer), NewP2PKSignerTemplate(signer), NewOrdinalP2PKHSignerTemplate(nil, signer)}
+}
+
+// scriptMatcher is implemented by templates that unlock a family of locking
+// scripts rather than the single script Lock returns
+type scriptMatcher interface {
+	Matches(lockingScript []byte) bool
+}
+
+// resolveUnlocker returns the first template whose locking script matches
+func resolveUnlocker(lockingScript []byte, templates []ScriptTemplate) ScriptTemplate {
+	for _, template := range templates {
+		if matcher, ok := template.(scriptMatcher); ok {
+			if matcher.Matches(lockingScript) {
+				return template
+			}
+			continue
+		}
+
+		script, err := template.Lock()
+		if err == nil && bytes.Equal(script, lockingScript) {
+			return template
+		}
+	}
+	return nil
+}
+
+// pushData returns the minimal push of data, using the small-integer opcodes
+// where they apply so the result passes MINIMALDATA
+func pushData(data []byte) []byte {
+	if len(data) == 0 {
+		return []byte{txscript.OP_0}
+	}
+
+	if len(data) == 1 && data[0] >= 1 && data[0] <= 16 {
+		return []byte{txscript.OP_1 + data[0] - 1}
+	}
+
+	if len(data) == 1 && data[0] == 0x81 {
+		return []byte{txscript.OP_1NEGATE}
+	}
+
+	return canonicalPush(data)
+}
+
+// pushNumber returns the minimal push of a script number
+func pushNumber(n int64) []byte {
+	return pushData(encodeScriptNum(big.NewInt(n)))
+}
+
+// P2PKHTemplate locks to the hash of a public key
+type P2PKHTemplate struct {
+	PubKeyHash []byte
+	Signer     wallet.Signer // Required to unlock
+}
+
+// NewP2PKHTemplate creates a P2PKH template that can lock and unlock with a key
+func NewP2PKHTemplate(keyPair *wallet.KeyPair) *P2PKHTemplate {
+	return NewP2PKHSignerTemplate(wallet.NewKeySigner(keyPair))
+}
+
+// NewP2PKHSignerTemplate creates a P2PKH template that unlocks with a signer
+func NewP2PKHSignerTemplate(signer wallet.Signer) *P2PKHTemplate {
+	return &P2PKHTemplate{
+		PubKeyHash: btcutil.Hash160(signer.PublicKey().SerializeCompressed()),
+		Signer:     signer,
+	}
+}
+
+// P2PKHFromAddress creates a lock-only P2PKH template for an address
+func P2PKHFromAddress(address string, network *chaincfg.Params) (*P2PKHTemplate, error) {
+	addr, err := btcutil.DecodeAddress(address, network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode address: %v", err)
+	}
+
+	pubKeyHash, ok := addr.(*btcutil.AddressPubKeyHash)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a P2PKH address", address)
+	}
+
+	return &P2PKHTemplate{PubKeyHash: pubKeyHash.Hash160()[:]}, nil
+}
+
+// Lock returns OP_DUP OP_HASH160 <hash> OP_EQUALVERIFY OP_CHECKSIG
+func (t *P2PKHTemplate) Lock() ([]byte, error) {
+	if len(t.PubKeyHash) != 20 {
+		return nil, fmt.Errorf("public key hash must be 20 bytes, got %d", len(t.PubKeyHash))
+	}
+
+	script := []byte{txscript.OP_DUP, txscript.OP_HASH160}
+	script = append(script, pushData(t.PubKeyHash)...)
+	return append(script, txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG), nil
+}
+
+// Unlock returns <signature> <public key>
+func (t *P2PKHTemplate) Unlock(tx *wire.MsgTx, index int, lockingScript []byte, amount int64) ([]byte, error) {
+	if t.Signer == nil {
+		return nil, fmt.Errorf("P2PKH template has no key to unlock with")
+	}
+
+	if err := checkLockingScript(t, lockingScript); err != nil {
+		return nil, err
+	}
+
+	signature, err := SignInputWithSigner(tx, index, lockingScript, amount, SigHashAllForkID, t.Signer)
+	if err != nil {
+		return nil, err
+	}
+
+	script := pushData(signature)
+	return append(script, pushData(t.Signer.PublicKey().SerializeCompressed())...), nil
+}
+
+// EstimateUnlockLength implements ScriptTemplate
+func (t *P2PKHTemplate) EstimateUnlockLength() int {
+	return maxSignaturePushSize + publicKeyPushSize
+}
+
+// P2PKTemplate locks directly to a public key
+type P2PKTemplate struct {
+	PublicKey []byte
+	Signer    wallet.Signer // Required to unlock
+}
+
+// NewP2PKTemplate creates a P2PK template that can lock and unlock with a key
+func NewP2PKTemplate(keyPair *wallet.KeyPair) *P2PKTemplate {
+	return NewP2PKSignerTemplate(wallet.NewKeySigner(keyPair))
+}
+
+// NewP2PKSignerTemplate creates a P2PK template that unlocks with a signer
+func NewP2PKSignerTemplate(signer wallet.Signer) *P2PKTemplate {
+	return &P2PKTemplate{
+		PublicKey: signer.PublicKey().SerializeCompressed(),
+		Signer:    signer,
+	}
+}
+
+// Lock returns <public key> OP_CHECKSIG
+func (t *P2PKTemplate) Lock() ([]byte, error) {
+	if _, err := btcec.ParsePubKey(t.PublicKey); err != nil {
+		return nil, fmt.Errorf("invalid public key: %v", err)
+	}
+
+	return append(pushData(t.PublicKey), txscript.OP_CHECKSIG), nil
+}
+
+// Unlock returns <signature>
+func (t *P2PKTemplate) Unlock(tx *wire.MsgTx, index int, lockingScript []byte, amount int64) ([]byte, error) {
+	if t.Signer == nil {
+		return nil, fmt.Errorf("P2PK template has no key to unlock with")
+	}
+
+	if err := checkLockingScript(t, lockingScript); err != nil {
+		return nil, err
+	}
+
+	signature, err := SignInputWithSigner(tx, index, lockingScript, amount, SigHashAllForkID, t.Signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return pushData(signature), nil
+}
+
+// EstimateUnlockLength implements ScriptTemplate
+func (t *P2PKTemplate) EstimateUnlockLength() int {
+	return maxSignaturePushSize
+}
+
+// MultiSigTemplate locks to m of n public keys with bare OP_CHECKMULTISIG
+type MultiSigTemplate struct {
+	Required   int
+	PublicKeys [][]byte
+	Signers    []wallet.Signer // Signers available to unlock
+}
+
+// NewMultiSigTemplate creates an m-of-n multisig template that unlocks with
+// key pairs
+func NewMultiSigTemplate(required int, publicKeys [][]byte, keyPairs ...*wallet.KeyPair) (*MultiSigTemplate, error) {
+	signers := make([]wallet.Signer, len(keyPairs))
+	for i, keyPair := range keyPairs {
+		signers[i] = wallet.NewKeySigner(keyPair)
+	}
+	return NewMultiSigSignerTemplate(required, publicKeys, signers...)
+}
+
+// NewMultiSigSignerTemplate creates an m-of-n multisig template that unlocks
+// with signers
+func NewMultiSigSignerTemplate(required int, publicKeys [][]byte, signers ...wallet.Signer) (*MultiSigTemplate, error) {
+	t := &MultiSigTemplate{Required: required, PublicKeys: publicKeys, Signers: signers}
+	if _, err := t.Lock(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Lock returns <m> <public keys...> <n> OP_CHECKMULTISIG
+func (t *MultiSigTemplate) Lock() ([]byte, error) {
+	if len(t.PublicKeys) == 0 {
+		return nil, fmt.Errorf("multisig needs at least one public key")
+	}
+
+	if t.Required < 1 || t.Required > len(t.PublicKeys) {
+		return nil, fmt.Errorf("required signatures must be between 1 and %d, got %d", len(t.PublicKeys), t.Required)
+	}
+
+	script := pushNumber(int64(t.Required))
+	for i, publicKey := range t.PublicKeys {
+		if _, err := btcec.ParsePubKey(publicKey); err != nil {
+			return nil, fmt.Errorf("invalid public key %d: %v", i, err)
+		}
+		script = append(script, pushData(publicKey)...)
+	}
+	script = append(script, pushNumber(int64(len(t.PublicKeys)))...)
+
+	return append(script, txscript.OP_CHECKMULTISIG), nil
+}
+
+// Unlock returns OP_0 followed by Required signatures in public key order
+func (t *MultiSigTemplate) Unlock(tx *wire.MsgTx, index int, lockingScript []byte, amount int64) ([]byte, error) {
+	if err := checkLockingScript(t, lockingScript); err != nil {
+		return nil, err
+	}
+
+	// CHECKMULTISIG pops one element more than it uses
+	script := []byte{txscript.OP_0}
+	signed := 0
+	for _, publicKey := range t.PublicKeys {
+		if signed == t.Required {
+			break
+		}
+
+		signer := t.signerFor(publicKey)
+		if signer == nil {
+			continue
+		}
+
+		signature, err := SignInputWithSigner(tx, index, lockingScript, amount, SigHashAllForkID, signer)
+		if err != nil {
+			return nil, err
+		}
+		script = append(script, pushData(signature)...)
+		signed++
+	}
+
+	if signed < t.Required {
+		return nil, fmt.Errorf("multisig needs %d signatures, have keys for %d", t.Required, signed)
+	}
+
+	return script, nil
+}
+
+// signerFor returns the available signer for a public key
+func (t *MultiSigTemplate) signerFor(publicKey []byte) wallet.Signer {
+	for _, signer := range t.Signers {
+		if bytes.Equal(signer.PublicKey().SerializeCompressed(), publicKey) ||
+			bytes.Equal(signer.PublicKey().SerializeUncompressed(), publicKey) {
+			return signer
+		}
+	}
+	return nil
+}
+
+// EstimateUnlockLength implements ScriptTemplate
+func (t *MultiSigTemplate) EstimateUnlockLength() int {
+	return 1 + t.Required*maxSignaturePushSize
+}
+
+// OpReturnTemplate creates a provably unspendable OP_FALSE OP_RETURN data output
+type OpReturnTemplate struct {
+	Data [][]byte // Each element is pushed separately
+}
+
+// Lock returns OP_FALSE OP_RETURN <data...>
+func (t *OpReturnTemplate) Lock() ([]byte, error) {
+	script := []byte{txscript.OP_FALSE, txscript.OP_RETURN}
+	for _, data := range t.Data {
+		script = append(script, canonicalPush(data)...)
+	}
+	return script, nil
+}
+
+// Unlock always fails because data outputs cannot be spent
+func (t *OpReturnTemplate) Unlock(tx *wire.MsgTx, index int, lockingScript []byte, amount int64) ([]byte, error) {
+	return nil, fmt.Errorf("OP_RETURN outputs cannot be spent")
+}
+
+// EstimateUnlockLength implements ScriptTemplate
+func (t *OpReturnTemplate) EstimateUnlockLength() int {
+	return 0
+}
+
+// HashPuzzleTemplate locks to the SHA-256 hash of a secret. The secret is
+// revealed when spent and the spend is not signed, so anyone who sees the
+// unconfirmed spend can replace it.
+type HashPuzzleTemplate struct {
+	Hash     []byte
+	Preimage []byte // Required to unlock
+}
+
+// NewHashPuzzleTemplate creates a hash puzzle template for a secret
+func NewHashPuzzleTemplate(preimage []byte) *HashPuzzleTemplate {
+	hash := sha256.Sum256(preimage)
+	return &HashPuzzleTemplate{Hash: hash[:], Preimage: preimage}
+}
+
+// Lock returns OP_SHA256 <hash> OP_EQUAL
+func (t *HashPuzzleTemplate) Lock() ([]byte, error) {
+	if len(t.Hash) != sha256.Size {
+		return nil, fmt.Errorf("hash must be %d bytes, got %d", sha256.Size, len(t.Hash))
+	}
+
+	script := []byte{txscript.OP_SHA256}
+	script = append(script, pushData(t.Hash)...)
+	return append(script, txscript.OP_EQUAL), nil
+}
+
+// Unlock returns <preimage>
+func (t *HashPuzzleTemplate) Unlock(tx *wire.MsgTx, index int, lockingScript []byte, amount int64) ([]byte, error) {
+	if t.Preimage == nil {
+		return nil, fmt.Errorf("hash puzzle template has no preimage to unlock with")
+	}
+
+	if err := checkLockingScript(t, lockingScript); err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256(t.Preimage)
+	if !bytes.Equal(hash[:], t.Hash) {
+		return nil, fmt.Errorf("preimage does not match the puzzle hash")
+	}
+
+	return pushData(t.Preimage), nil
+}
+
+// EstimateUnlockLength implements ScriptTemplate
+func (t *HashPuzzleTemplate) EstimateUnlockLength() int {
+	return len(pushData(t.Preimage))
+}
+
+// RPuzzleTemplate locks to the R value of a signature, so anyone who knows
+// the nonce K can spend with any key. K must never sign anything else, as two
+// signatures sharing a nonce reveal the signing key.
+type RPuzzleTemplate struct {
+	R       []byte          // DER-encoded R value
+	K       []byte          // Nonce that produces R, required to unlock
+	KeyPair *wallet.KeyPair // Key to sign with, required to unlock
+}
+
+// NewRPuzzleTemplate creates an R-puzzle template from a 32-byte nonce
+func NewRPuzzleTemplate(k []byte, keyPair *wallet.KeyPair) (*RPuzzleTemplate, error) {
+	r, err := nonceR(k)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RPuzzleTemplate{R: rBytes(r), K: k, KeyPair: keyPair}, nil
+}
+
+// Lock extracts R from the signature, compares it and checks the signature:
+// OP_OVER OP_3 OP_SPLIT OP_NIP OP_1 OP_SPLIT OP_SWAP OP_SPLIT OP_DROP <R> OP_EQUALVERIFY OP_CHECKSIG
+func (t *RPuzzleTemplate) Lock() ([]byte, error) {
+	if len(t.R) == 0 || len(t.R) > 33 {
+		return nil, fmt.Errorf("invalid R value of %d bytes", len(t.R))
+	}
+
+	script := []byte{
+		txscript.OP_OVER, txscript.OP_3, opSplit, txscript.OP_NIP,
+		txscript.OP_1, opSplit, txscript.OP_SWAP, opSplit, txscript.OP_DROP,
+	}
+	script = append(script, pushData(t.R)...)
+	return append(script, txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG), nil
+}
+
+// Unlock returns <signature using K> <public key>
+func (t *RPuzzleTemplate) Unlock(tx *wire.MsgTx, index int, lockingScript []byte, amount int64) ([]byte, error) {
+	if t.K == nil || t.KeyPair == nil {
+		return nil, fmt.Errorf("R-puzzle template needs the nonce and a key to unlock")
+	}
+
+	if err := checkLockingScript(t, lockingScript); err != nil {
+		return nil, err
+	}
+
+	hash, err := SignatureHash(tx, index, lockingScript, SigHashAllForkID, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := signWithNonce(t.KeyPair.PrivateKey, hash, t.K)
+	if err != nil {
+		return nil, err
+	}
+
+	script := pushData(append(signature.Serialize(), byte(SigHashAllForkID)))
+	return append(script, pushData(t.KeyPair.PublicKey.SerializeCompressed())...), nil
+}
+
+// EstimateUnlockLength implements ScriptTemplate
+func (t *RPuzzleTemplate) EstimateUnlockLength() int {
+	return maxSignaturePushSize + publicKeyPushSize
+}
+
+// OrdinalP2PKHTemplate locks a 1Sat Ordinals inscription to a key's P2PKH
+// script. It unlocks any inscription owned by the key, token outputs included.
+type OrdinalP2PKHTemplate struct {
+	Inscription *ordinals.Inscription // Required to lock
+	Signer      wallet.Signer
+}
+
+// NewOrdinalP2PKHTemplate creates an ordinal template for a key
+func NewOrdinalP2PKHTemplate(inscription *ordinals.Inscription, keyPair *wallet.KeyPair) *OrdinalP2PKHTemplate {
+	return NewOrdinalP2PKHSignerTemplate(inscription, wallet.NewKeySigner(keyPair))
+}
+
+// NewOrdinalP2PKHSignerTemplate creates an ordinal template for a signer
+func NewOrdinalP2PKHSignerTemplate(inscription *ordinals.Inscription, signer wallet.Signer) *OrdinalP2PKHTemplate {
+	return &OrdinalP2PKHTemplate{Inscription: inscription, Signer: signer}
+}
+
+// Lock returns the inscription envelope followed by the key's P2PKH script
+func (t *OrdinalP2PKHTemplate) Lock() ([]byte, error) {
+	if t.Inscription == nil {
+		return nil, fmt.Errorf("ordinal template has no inscription to lock")
+	}
+
+	owner, err := NewP2PKHSignerTemplate(t.Signer).Lock()
+	if err != nil {
+		return nil, err
+	}
+
+	return t.Inscription.LockingScript(owner)
+}
+
+// Matches reports whether lockingScript is an inscription owned by the key
+func (t *OrdinalP2PKHTemplate) Matches(lockingScript []byte) bool {
+	_, owner, ok := ordinals.Parse(lockingScript)
+	if !ok {
+		return false
+	}
+
+	expected, err := NewP2PKHSignerTemplate(t.Signer).Lock()
+	return err == nil && bytes.Equal(owner, expected)
+}
+
+// Unlock returns <signature> <public key>, signing the full locking script
+func (t *OrdinalP2PKHTemplate) Unlock(tx *wire.MsgTx, index int, lockingScript []byte, amount int64) ([]byte, error) {
+	if !t.Matches(lockingScript) {
+		return nil, fmt.Errorf("locking script %x is not an inscription owned by the key", lockingScript)
+	}
+
+	signature, err := SignInputWithSigner(tx, index, lockingScript, amount, SigHashAllForkID, t.Signer)
+	if err != nil {
+		return nil, err
+	}
+
+	script := pushData(signature)
+	return append(script, pushData(t.Signer.PublicKey().SerializeCompressed())...), nil
+}
+
+// EstimateUnlockLength implements ScriptTemplate
+func (t *OrdinalP2PKHTemplate) EstimateUnlockLength() int {
+	return maxSignaturePushSize + publicKeyPushSize
+}
+
+// nonceR returns the R value, mod N, of the point k*G
+func nonceR(k []byte) (*btcec.ModNScalar, error) {
+	if len(k) != 32 {
+		return nil, fmt.Errorf("nonce must be 32 bytes, got %d", len(k))
+	}
+
+	var nonce btcec.ModNScalar
+	if overflow := nonce.SetByteSlice(k); overflow || nonce.IsZero() {
+		return nil, fmt.Errorf("nonce is out of range")
+	}
+
+	var point btcec.JacobianPoint
+	btcec.ScalarBaseMultNonConst(&nonce, &point)
+	point.ToAffine()
+
+	var r btcec.ModNScalar
+	r.SetByteSlice(point.X.Bytes()[:])
+	if r.IsZero() {
+		return nil, fmt.Errorf("nonce is out of range")
+	}
+
+	return &r, nil
+}
+
+// rBytes returns R as it appears in a DER signature
+func rBytes(r *btcec.ModNScalar) []byte {
+	b := r.Bytes()
+	value := bytes.TrimLeft(b[:], "\x00")
+	if len(value) == 0 || value[0]&0x80 != 0 {
+		value = append([]byte{0x00}, value...)
+	}
+	return value
+}
+
+// signWithNonce creates a low-S ECDSA signature using a chosen nonce
+func signWithNonce(privateKey *btcec.PrivateKey, hash, k []byte) (*ecdsa.Signature, error) {
+	r, err := nonceR(k)
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce, e btcec.ModNScalar
+	nonce.SetByteSlice(k)
+	e.SetByteSlice(hash)
+
+	// s = k^-1 * (e + r*d)
+	var s btcec.ModNScalar
+	s.Mul2(r, &privateKey.Key).Add(&e)
+	s.Mul(new(btcec.ModNScalar).InverseValNonConst(&nonce))
+	if s.IsZero() {
+		return nil, fmt.Errorf("nonce produces an invalid signature")
+	}
+
+	if s.IsOverHalfOrder() {
+		s.Negate()
+	}
+
+	return ecdsa.NewSignature(r, &s), nil
+}
+
+// SignWithTemplates sets the unlocking script of every input with the
+// template at the same index. inputs are the UTXOs spent, in input order.
+func (b *Builder) SignWithTemplates(tx *wire.MsgTx, inputs []types.UTXO, unlockers []ScriptTemplate) error {
+	if len(inputs) != len(tx.TxIn) || len(unlockers) != len(tx.TxIn) {
+		return fmt.Errorf("transaction has %d inputs but %d UTXOs and %d unlockers", len(tx.TxIn), len(inputs), len(unlockers))
+	}
+
+	network := b.getNetwork()
+	for i, input := range inputs {
+		// Sign against the locking script of the spent output
+		script, err := inputScript(input, network)
+		if err != nil {
+			return fmt.Errorf("input %d: %v", i, err)
+		}
+
+		unlockingScript, err := unlockers[i].Unlock(tx, i, script, input.Value)
+		if err != nil {
+			return fmt.Errorf("failed to unlock input %d: %v", i, err)
+		}
+		tx.TxIn[i].SignatureScript = unlockingScript
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package utxo
+
+import (
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/txscript"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/ordinals"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/token"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// ClassifyUTXO sets the type of a UTXO from its locking script, and whether
+// it is native, and the token it holds. Indexers that omit the script only
+// return outputs paying the queried address, so those are taken as P2PKH.
+func (m *Manager) ClassifyUTXO(utxo *types.UTXO) {
+	utxo.Type, utxo.TokenID, utxo.TokenAmount = m.classify(utxo)
+	utxo.IsNative = utxo.Type.IsNative()
+}
+
+// classify returns the type of a UTXO and the token it holds
+func (m *Manager) classify(utxo *types.UTXO) (types.UTXOType, string, int64) {
+	script, err := hex.DecodeString(utxo.ScriptPubKey)
+	if err != nil {
+		return types.UTXOTypeUnknown, "", 0
+	}
+
+	// A transferred ordinal is a lone satoshi without its inscription, and
+	// is worth less than the fee to spend it
+	if len(script) == 0 || isP2PKH(script) {
+		if utxo.Value == 1 {
+			return types.UTXOTypeOrdinal, "", 0
+		}
+		return types.UTXOTypeP2PKH, "", 0
+	}
+
+	for _, protocol := range m.tokens {
+		if tokenID, amount, ok := protocol.Detect(script, utxo.TxID, utxo.Vout); ok {
+			return types.UTXOType(protocol.Name()), tokenID, amount
+		}
+	}
+
+	if tokenID, ok := token.STASTokenID(script); ok {
+		return types.UTXOTypeSTAS, tokenID, utxo.Value
+	}
+
+	if _, _, ok := ordinals.Parse(script); ok {
+		return types.UTXOTypeOrdinal, "", 0
+	}
+
+	if isP2PK(script) {
+		return types.UTXOTypeP2PK, "", 0
+	}
+
+	return types.UTXOTypeUnknown, "", 0
+}
+
+// isP2PKH reports whether script is a P2PKH locking script
+func isP2PKH(script []byte) bool {
+	return len(script) == 25 &&
+		script[0] == txscript.OP_DUP &&
+		script[1] == txscript.OP_HASH160 &&
+		script[2] == txscript.OP_DATA_20 &&
+		script[23] == txscript.OP_EQUALVERIFY &&
+		script[24] == txscript.OP_CHECKSIG
+}
+
+// isP2PK reports whether script is a P2PK locking script
+func isP2PK(script []byte) bool {
+	switch len(script) {
+	case 35:
+		return script[0] == txscript.OP_DATA_33 && script[34] == txscript.OP_CHECKSIG
+	case 67:
+		return script[0] == txscript.OP_DATA_65 && script[66] == txscript.OP_CHECKSIG
+	}
+	return false
+}
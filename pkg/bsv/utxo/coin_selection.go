@@ -0,0 +1,336 @@
+package utxo
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/muhammadamman/BSV-Go/pkg/config"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// Estimated serialized sizes used for fee calculation
+const (
+	BaseTxSize      = 10  // Version, locktime and input/output counts
+	P2PKHInputSize  = 148 // Outpoint, sequence and P2PKH unlocking script
+	P2PKHOutputSize = 34  // Value and P2PKH locking script
+)
+
+// bnbMaxTries bounds the branch-and-bound search before falling back
+const bnbMaxTries = 100000
+
+// SelectionTarget describes what a coin selector has to fund
+type SelectionTarget struct {
+	Amount      int64         // Satoshis paid to outputs other than change
+	FeeRate     types.FeeRate // Fee rate for transaction bytes
+	DataFeeRate types.FeeRate // Fee rate for the DataSize bytes; FeeRate when unset
+	DustLimit   int64         // Change at or below this is left to the miner
+	BaseSize    int           // Bytes of the transaction excluding inputs and change
+	DataSize    int           // Bytes of BaseSize in data carrier outputs
+	InputSize   int           // Estimated bytes per input
+	ChangeSize  int           // Bytes of the change output
+}
+
+// NewSelectionTarget creates a target for a single P2PKH payment with change
+func NewSelectionTarget(amount int64, feeRate types.FeeRate, dustLimit int64) *SelectionTarget {
+	return &SelectionTarget{
+		Amount:     amount,
+		FeeRate:    feeRate,
+		DustLimit:  dustLimit,
+		BaseSize:   BaseTxSize + P2PKHOutputSize,
+		InputSize:  P2PKHInputSize,
+		ChangeSize: P2PKHOutputSize,
+	}
+}
+
+// Fee returns the fee for a transaction with numInputs inputs
+func (t *SelectionTarget) Fee(numInputs int, withChange bool) int64 {
+	size := t.BaseSize + numInputs*t.InputSize
+	if withChange {
+		size += t.ChangeSize
+	}
+	return t.FeeForSize(size)
+}
+
+// FeeForSize returns the fee for a transaction of size bytes, charging the
+// data rate on its data carrier bytes and the fee rate on the rest
+func (t *SelectionTarget) FeeForSize(size int) int64 {
+	dataSize := min(t.DataSize, size)
+	dataRate := t.DataFeeRate
+	if !dataRate.IsSet() {
+		dataRate = t.FeeRate
+	}
+	return t.FeeRate.FeeForSize(size-dataSize) + dataRate.FeeForSize(dataSize)
+}
+
+// CoinSelector picks the UTXOs that fund a transaction. It returns the selected
+// UTXOs and the fee they were selected for.
+type CoinSelector interface {
+	Select(utxos []types.UTXO, target *SelectionTarget) ([]types.UTXO, int64, error)
+}
+
+// NewCoinSelector returns the selector for a strategy, defaulting to largest-first
+func NewCoinSelector(strategy config.CoinSelectionStrategy) (CoinSelector, error) {
+	switch strategy {
+	case "", config.CoinSelectionLargestFirst:
+		return &LargestFirstSelector{}, nil
+	case config.CoinSelectionSmallestFirst:
+		return &SmallestFirstSelector{}, nil
+	case config.CoinSelectionOldestFirst:
+		return &OldestFirstSelector{}, nil
+	case config.CoinSelectionBranchAndBound:
+		return &BranchAndBoundSelector{}, nil
+	case config.CoinSelectionRandomImprove:
+		return NewRandomImproveSelector(nil), nil
+	case config.CoinSelectionPrivacy:
+		return &PrivacySelector{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported coin selection strategy: %s", strategy)
+	}
+}
+
+// LargestFirstSelector spends the largest UTXOs first, minimising input count
+type LargestFirstSelector struct{}
+
+// Select implements CoinSelector
+func (s *LargestFirstSelector) Select(utxos []types.UTXO, target *SelectionTarget) ([]types.UTXO, int64, error) {
+	return selectInOrder(sortUTXOsByValue(utxos), target)
+}
+
+// SmallestFirstSelector spends the smallest UTXOs first, consolidating dust
+type SmallestFirstSelector struct{}
+
+// Select implements CoinSelector
+func (s *SmallestFirstSelector) Select(utxos []types.UTXO, target *SelectionTarget) ([]types.UTXO, int64, error) {
+	sorted := make([]types.UTXO, len(utxos))
+	copy(sorted, utxos)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Value < sorted[j].Value
+	})
+	return selectInOrder(sorted, target)
+}
+
+// OldestFirstSelector spends the UTXOs mined earliest first. Unconfirmed UTXOs
+// (height 0) are treated as the newest.
+type OldestFirstSelector struct{}
+
+// Select implements CoinSelector
+func (s *OldestFirstSelector) Select(utxos []types.UTXO, target *SelectionTarget) ([]types.UTXO, int64, error) {
+	sorted := make([]types.UTXO, len(utxos))
+	copy(sorted, utxos)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		hi, hj := sorted[i].Height, sorted[j].Height
+		if hi == 0 || hj == 0 {
+			return hj == 0 && hi != 0
+		}
+		return hi < hj
+	})
+	return selectInOrder(sorted, target)
+}
+
+// BranchAndBoundSelector searches for a set of UTXOs that pays the target
+// exactly, within the dust limit, so that no change output is needed. It
+// falls back to largest-first when no exact match is found.
+type BranchAndBoundSelector struct{}
+
+// Select implements CoinSelector
+func (s *BranchAndBoundSelector) Select(utxos []types.UTXO, target *SelectionTarget) ([]types.UTXO, int64, error) {
+	sorted := sortUTXOsByValue(utxos)
+
+	// remaining[i] is the total value of sorted[i:]
+	remaining := make([]int64, len(sorted)+1)
+	for i := len(sorted) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + sorted[i].Value
+	}
+
+	var selected []int
+	var best []int
+	tries := 0
+
+	var search func(index int, total int64) bool
+	search = func(index int, total int64) bool {
+		tries++
+		if tries > bnbMaxTries {
+			return false
+		}
+
+		needed := target.Amount + target.Fee(len(selected), false)
+		if total >= needed {
+			// The excess goes to the miner only while it is dust; above
+			// that a change output is added, which the no-change fee does
+			// not pay for
+			if total <= needed+target.DustLimit {
+				best = append([]int(nil), selected...)
+				return true
+			}
+			return false
+		}
+
+		// Even with everything left this branch cannot reach the target
+		if index >= len(sorted) || total+remaining[index] < needed {
+			return false
+		}
+
+		// Include the current UTXO, then try without it
+		selected = append(selected, index)
+		if search(index+1, total+sorted[index].Value) {
+			return true
+		}
+		selected = selected[:len(selected)-1]
+
+		return search(index+1, total)
+	}
+
+	if search(0, 0) {
+		result := make([]types.UTXO, len(best))
+		for i, index := range best {
+			result[i] = sorted[index]
+		}
+		return result, target.Fee(len(result), false), nil
+	}
+
+	return selectInOrder(sorted, target)
+}
+
+// RandomImproveSelector picks UTXOs at random until the target is met, then
+// keeps adding random UTXOs while that moves the change towards the payment
+// amount. This produces change outputs that look like payments.
+type RandomImproveSelector struct {
+	rng *rand.Rand
+}
+
+// NewRandomImproveSelector creates a random-improve selector. A nil rng uses a
+// time-seeded source.
+func NewRandomImproveSelector(rng *rand.Rand) *RandomImproveSelector {
+	if rng == nil {
+		rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return &RandomImproveSelector{rng: rng}
+}
+
+// Select implements CoinSelector
+func (s *RandomImproveSelector) Select(utxos []types.UTXO, target *SelectionTarget) ([]types.UTXO, int64, error) {
+	shuffled := make([]types.UTXO, len(utxos))
+	copy(shuffled, utxos)
+	s.rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	// Random selection phase
+	var selected []types.UTXO
+	var total int64
+	next := 0
+	for ; next < len(shuffled); next++ {
+		if total >= target.Amount+target.Fee(len(selected), true) {
+			break
+		}
+		selected = append(selected, shuffled[next])
+		total += shuffled[next].Value
+	}
+
+	if total < target.Amount+target.Fee(len(selected), true) {
+		return nil, 0, insufficientFunds(target.Amount+target.Fee(len(selected), true), total)
+	}
+
+	// Improvement phase: aim for change close to the payment amount without
+	// exceeding three times the target
+	ideal := 2 * target.Amount
+	for ; next < len(shuffled); next++ {
+		candidate := total + shuffled[next].Value
+		fee := target.Fee(len(selected)+1, true)
+		if candidate-fee > 3*target.Amount {
+			continue
+		}
+		if abs64(ideal-(candidate-fee)) >= abs64(ideal-(total-target.Fee(len(selected), true))) {
+			continue
+		}
+		selected = append(selected, shuffled[next])
+		total = candidate
+	}
+
+	return selected, target.Fee(len(selected), true), nil
+}
+
+// PrivacySelector never mixes UTXOs from different addresses in one
+// transaction, so spending does not link addresses together. It funds the
+// payment from the single address that needs the fewest inputs.
+type PrivacySelector struct{}
+
+// Select implements CoinSelector
+func (s *PrivacySelector) Select(utxos []types.UTXO, target *SelectionTarget) ([]types.UTXO, int64, error) {
+	groups := make(map[string][]types.UTXO)
+	var addresses []string
+	for _, utxo := range utxos {
+		if _, exists := groups[utxo.Address]; !exists {
+			addresses = append(addresses, utxo.Address)
+		}
+		groups[utxo.Address] = append(groups[utxo.Address], utxo)
+	}
+
+	var best []types.UTXO
+	var bestFee int64
+	for _, address := range addresses {
+		selected, fee, err := selectInOrder(sortUTXOsByValue(groups[address]), target)
+		if err != nil {
+			continue
+		}
+		if best == nil || len(selected) < len(best) {
+			best = selected
+			bestFee = fee
+		}
+	}
+
+	if best == nil {
+		return nil, 0, fmt.Errorf("%w: no single address can fund %d satoshis", types.ErrInsufficientFunds, target.Amount)
+	}
+
+	return best, bestFee, nil
+}
+
+// selectInOrder accumulates UTXOs in the given order until the target is met
+func selectInOrder(utxos []types.UTXO, target *SelectionTarget) ([]types.UTXO, int64, error) {
+	var selected []types.UTXO
+	var total int64
+
+	for _, utxo := range utxos {
+		selected = append(selected, utxo)
+		total += utxo.Value
+
+		fee := target.Fee(len(selected), true)
+		if total >= target.Amount+fee {
+			return selected, fee, nil
+		}
+	}
+
+	return nil, 0, insufficientFunds(target.Amount+target.Fee(len(selected), true), total)
+}
+
+func insufficientFunds(need, have int64) error {
+	return fmt.Errorf("%w: need %d satoshis, have %d satoshis", types.ErrInsufficientFunds, need, have)
+}
+
+func sortUTXOsByValue(utxos []types.UTXO) []types.UTXO {
+	sorted := make([]types.UTXO, len(utxos))
+	copy(sorted, utxos)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Value > sorted[j].Value
+	})
+	return sorted
+}
+
+func sortUTXOsByTokenAmount(utxos []types.UTXO) []types.UTXO {
+	sorted := make([]types.UTXO, len(utxos))
+	copy(sorted, utxos)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].TokenAmount > sorted[j].TokenAmount
+	})
+	return sorted
+}
+
+func abs64(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
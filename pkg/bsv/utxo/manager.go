@@ -1,13 +1,19 @@
 package utxo
 
 import (
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/spv"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/token"
 	"github.com/muhammadamman/BSV-Go/pkg/config"
 	"github.com/muhammadamman/BSV-Go/pkg/types"
 )
@@ -20,11 +26,15 @@ type Manager struct {
 	retryDelay    time.Duration
 	cache         map[string]*CacheEntry
 	cacheMutex    sync.RWMutex
+	chainTracker  spv.ChainTracker
+	merklePaths   spv.MerklePathProvider
+	tokens        []token.Protocol // Protocols detected in fetched UTXOs
 }
 
 // CacheEntry represents a cached UTXO entry
 type CacheEntry struct {
 	UTXOs     []types.UTXO
+	Truncated bool // More UTXOs exist than MaxUTXOsPerQuery allowed
 	Balance   *types.EnhancedBalanceInfo
 	Timestamp time.Time
 }
@@ -56,29 +66,101 @@ func NewManager(configManager *config.Manager) *Manager {
 		maxRetries: 3,
 		retryDelay: 1 * time.Second,
 		cache:      make(map[string]*CacheEntry),
+		tokens:     []token.Protocol{token.NewBSV21(), token.NewBSV20()},
+	}
+}
+
+// SetTokenProtocols sets the token protocols detected in fetched UTXOs
+func (m *Manager) SetTokenProtocols(protocols ...token.Protocol) {
+	m.tokens = protocols
+	m.ClearCache()
+}
+
+// AddTokenProtocol detects a token protocol in fetched UTXOs, unless a
+// protocol of the same name is already detected
+func (m *Manager) AddTokenProtocol(protocol token.Protocol) {
+	for _, existing := range m.tokens {
+		if existing.Name() == protocol.Name() {
+			return
+		}
 	}
+	m.SetTokenProtocols(append(append([]token.Protocol{}, m.tokens...), protocol)...)
 }
 
 // GetUTXOs retrieves UTXOs for a given address with dynamic configuration
 func (m *Manager) GetUTXOs(address string) ([]types.UTXO, error) {
+	utxos, _, err := m.getUTXOs(address)
+	return utxos, err
+}
+
+// GetScriptUTXOs retrieves the UTXOs locked by a script, identified by its
+// script hash (see ScriptHash)
+func (m *Manager) GetScriptUTXOs(scriptHash string) ([]types.UTXO, error) {
+	utxos, _, err := m.getScriptUTXOs(scriptHash)
+	return utxos, err
+}
+
+// SelectScriptUTXOs selects UTXOs locked by a script for target. The caller
+// sizes the target's inputs and change for the script.
+func (m *Manager) SelectScriptUTXOs(scriptHash string, target *SelectionTarget, strategy config.CoinSelectionStrategy) ([]types.UTXO, int64, error) {
+	selector, err := NewCoinSelector(strategy)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	utxos, truncated, err := m.getScriptUTXOs(scriptHash)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(utxos) == 0 {
+		return nil, 0, fmt.Errorf("no UTXOs available for script: %s", scriptHash)
+	}
+
+	selected, fee, err := selector.Select(utxos, target)
+	if err != nil {
+		return nil, 0, TruncationError(err, truncated)
+	}
+
+	return selected, fee, nil
+}
+
+// ScriptHash returns the hash a locking script is indexed by: its SHA-256,
+// byte-reversed and hex encoded
+func ScriptHash(lockingScript []byte) string {
+	return chainhash.Hash(sha256.Sum256(lockingScript)).String()
+}
+
+// getUTXOs retrieves UTXOs and reports whether MaxUTXOsPerQuery truncated the set
+func (m *Manager) getUTXOs(address string) ([]types.UTXO, bool, error) {
+	url := fmt.Sprintf("%s/address/%s/unspent", m.configManager.GetNetworkConfig().RPCURL, address)
+	return m.fetchUTXOs(address, url)
+}
+
+// getScriptUTXOs retrieves the UTXOs of a script hash
+func (m *Manager) getScriptUTXOs(scriptHash string) ([]types.UTXO, bool, error) {
+	url := fmt.Sprintf("%s/script/%s/unspent", m.configManager.GetNetworkConfig().RPCURL, scriptHash)
+	return m.fetchUTXOs(scriptHash, url)
+}
+
+// fetchUTXOs retrieves UTXOs from url, caching them under key
+func (m *Manager) fetchUTXOs(key, url string) ([]types.UTXO, bool, error) {
 	// Check cache first
-	if cached := m.getFromCache(address); cached != nil {
-		return cached.UTXOs, nil
+	if cached := m.getFromCache(key); cached != nil && cached.UTXOs != nil {
+		return cached.UTXOs, cached.Truncated, nil
 	}
 
-	networkConfig := m.configManager.GetNetworkConfig()
 	utxoConfig := m.configManager.GetUTXOConfig()
 
-	url := fmt.Sprintf("%s/address/%s/unspent", networkConfig.RPCURL, address)
-
 	var utxoResponses []EnhancedUTXOResponse
 	err := m.makeRequest(url, &utxoResponses)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get UTXOs: %v", err)
+		return nil, false, fmt.Errorf("failed to get UTXOs: %v", err)
 	}
 
 	// Convert to enhanced UTXO format
-	var utxos []types.UTXO
+	utxos := []types.UTXO{}
+	truncated := false
 	for _, resp := range utxoResponses {
 		// Check minimum confirmations
 		if resp.Confirmations < utxoConfig.MinConfirmations {
@@ -87,6 +169,7 @@ func (m *Manager) GetUTXOs(address string) ([]types.UTXO, error) {
 
 		// Check max UTXOs per query
 		if len(utxos) >= utxoConfig.MaxUTXOsPerQuery {
+			truncated = true
 			break
 		}
 
@@ -98,23 +181,25 @@ func (m *Manager) GetUTXOs(address string) ([]types.UTXO, error) {
 			Address:       resp.Address,
 			Confirmations: resp.Confirmations,
 			Height:        resp.Height,
-			IsNative:      true, // All UTXOs from this API are native BSV
-			TokenID:       "",   // Empty for native UTXOs
-			TokenAmount:   0,    // Zero for native UTXOs
 		}
+		m.ClassifyUTXO(&utxo)
 
 		utxos = append(utxos, utxo)
 	}
 
+	// Mark the UTXOs whose transactions are proven mined
+	m.markSPVVerified(utxos)
+
 	// Cache the results if caching is enabled
 	if utxoConfig.EnableCaching {
-		m.setCache(address, &CacheEntry{
+		m.setCache(key, &CacheEntry{
 			UTXOs:     utxos,
+			Truncated: truncated,
 			Timestamp: time.Now(),
 		})
 	}
 
-	return utxos, nil
+	return utxos, truncated, nil
 }
 
 // GetEnhancedBalance retrieves enhanced balance information for an address
@@ -240,131 +325,167 @@ func (m *Manager) GetConfirmedBalance(address string) (int64, error) {
 	return nativeBalance.Confirmed, nil
 }
 
-// SelectUTXOs selects UTXOs for a transaction with enhanced filtering
+// SelectUTXOs selects UTXOs for a transaction using the configured coin selection strategy
 func (m *Manager) SelectUTXOs(address string, amount, feeRate int64) ([]types.UTXO, int64, error) {
 	txConfig := m.configManager.GetTransactionConfig()
+	return m.SelectUTXOsWithStrategy(address, amount, feeRate, txConfig.CoinSelectionStrategy)
+}
 
-	// Get all UTXOs
-	allUTXOs, err := m.GetUTXOs(address)
+// SelectUTXOsWithStrategy selects UTXOs for a transaction using a specific coin selection strategy
+func (m *Manager) SelectUTXOsWithStrategy(address string, amount, feeRate int64, strategy config.CoinSelectionStrategy) ([]types.UTXO, int64, error) {
+	txConfig := m.configManager.GetTransactionConfig()
+
+	// Validate fee rate
+	if feeRate < txConfig.MinFeeRate {
+		feeRate = txConfig.DefaultFeeRate
+	}
+	if feeRate > txConfig.MaxFeeRate {
+		return nil, 0, fmt.Errorf("fee rate %d exceeds maximum allowed %d", feeRate, txConfig.MaxFeeRate)
+	}
+
+	return m.SelectUTXOsForTarget(address, NewSelectionTarget(amount, types.SatoshisPerByte(feeRate), txConfig.DustLimit), strategy)
+}
+
+// SelectUTXOsForTarget selects UTXOs of an address for a target whose sizes
+// the caller has adjusted, such as for data outputs
+func (m *Manager) SelectUTXOsForTarget(address string, target *SelectionTarget, strategy config.CoinSelectionStrategy) ([]types.UTXO, int64, error) {
+	return m.SelectUTXOsFromAddresses([]string{address}, target, strategy)
+}
+
+// SelectUTXOsFromAddresses selects UTXOs held by any of several addresses
+func (m *Manager) SelectUTXOsFromAddresses(addresses []string, target *SelectionTarget, strategy config.CoinSelectionStrategy) ([]types.UTXO, int64, error) {
+	selector, err := NewCoinSelector(strategy)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	if len(allUTXOs) == 0 {
-		return nil, 0, fmt.Errorf("no UTXOs available for address: %s", address)
+	availableUTXOs, truncated, err := m.GetSpendableUTXOsFromAddresses(addresses)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	// Filter UTXOs based on configuration
-	var availableUTXOs []types.UTXO
-	for _, utxo := range allUTXOs {
-		// Check if we should include native UTXOs
-		if utxo.IsNative && txConfig.IncludeNativeUTXOs {
-			availableUTXOs = append(availableUTXOs, utxo)
-		}
-		// Check if we should include non-native UTXOs
-		if !utxo.IsNative && txConfig.IncludeNonNativeUTXOs {
-			availableUTXOs = append(availableUTXOs, utxo)
-		}
+	selected, fee, err := selector.Select(availableUTXOs, target)
+	if err != nil {
+		return nil, 0, TruncationError(err, truncated)
 	}
 
-	if len(availableUTXOs) == 0 {
-		return nil, 0, fmt.Errorf("no suitable UTXOs available based on configuration")
+	return selected, fee, nil
+}
+
+// GetSpendableUTXOs returns the UTXOs of an address that the transaction
+// configuration allows spending, and whether MaxUTXOsPerQuery truncated them
+func (m *Manager) GetSpendableUTXOs(address string) ([]types.UTXO, bool, error) {
+	// Get all UTXOs
+	allUTXOs, truncated, err := m.getUTXOs(address)
+	if err != nil {
+		return nil, false, err
 	}
 
-	// Sort UTXOs by value (largest first for efficiency)
-	sortedUTXOs := m.sortUTXOsByValue(availableUTXOs)
+	if len(allUTXOs) == 0 {
+		return nil, false, fmt.Errorf("no UTXOs available for address: %s", address)
+	}
 
-	var selectedUTXOs []types.UTXO
-	var totalValue int64
-	var estimatedFee int64
+	availableUTXOs := m.filterSpendable(allUTXOs)
+	if len(availableUTXOs) == 0 {
+		return nil, false, fmt.Errorf("no suitable UTXOs available based on configuration")
+	}
 
-	// Estimate transaction size (simplified)
-	// Input: ~148 bytes, Output: ~34 bytes, Change: ~34 bytes
-	estimatedSize := 10 + len(sortedUTXOs)*148 + 34 + 34 // Base size + inputs + outputs
-	estimatedFee = int64(estimatedSize) * feeRate
+	return availableUTXOs, truncated, nil
+}
 
-	// Validate fee rate
-	if feeRate < txConfig.MinFeeRate {
-		feeRate = txConfig.DefaultFeeRate
-	}
-	if feeRate > txConfig.MaxFeeRate {
-		return nil, 0, fmt.Errorf("fee rate %d exceeds maximum allowed %d", feeRate, txConfig.MaxFeeRate)
+// filterSpendable returns the UTXOs that may fund a payment: plain satoshis,
+// when the transaction configuration allows them, and custom scripts only when
+// it opts in to non-native UTXOs, since an unrecognised script may hold an
+// asset this SDK cannot parse. Ordinals and token UTXOs are only spent by the
+// transfers that move them.
+func (m *Manager) filterSpendable(utxos []types.UTXO) []types.UTXO {
+	txConfig := m.configManager.GetTransactionConfig()
+
+	var availableUTXOs []types.UTXO
+	for _, utxo := range utxos {
+		switch {
+		case utxo.IsNative:
+			if txConfig.IncludeNativeUTXOs {
+				availableUTXOs = append(availableUTXOs, utxo)
+			}
+		case txConfig.IncludeNonNativeUTXOs && utxo.Type == types.UTXOTypeUnknown && utxo.TokenID == "":
+			availableUTXOs = append(availableUTXOs, utxo)
+		}
 	}
 
-	// Select UTXOs until we have enough funds
-	for _, utxo := range sortedUTXOs {
-		selectedUTXOs = append(selectedUTXOs, utxo)
-		totalValue += utxo.Value
+	return availableUTXOs
+}
 
-		// Recalculate fee with current number of inputs
-		currentSize := 10 + len(selectedUTXOs)*148 + 34 + 34
-		currentFee := int64(currentSize) * feeRate
+// GetSpendableUTXOsFromAddresses returns the spendable UTXOs of several
+// addresses, in address order. Addresses without UTXOs are skipped; it is an
+// error only if none of them has any.
+func (m *Manager) GetSpendableUTXOsFromAddresses(addresses []string) ([]types.UTXO, bool, error) {
+	if len(addresses) == 1 {
+		return m.GetSpendableUTXOs(addresses[0])
+	}
 
-		if totalValue >= amount+currentFee {
-			return selectedUTXOs, currentFee, nil
+	var availableUTXOs []types.UTXO
+	anyTruncated := false
+	for _, address := range addresses {
+		utxos, truncated, err := m.getUTXOs(address)
+		if err != nil {
+			return nil, false, err
 		}
+
+		// Inputs are signed by the key of the address holding them
+		for _, utxo := range m.filterSpendable(utxos) {
+			if utxo.Address == "" {
+				utxo.Address = address
+			}
+			availableUTXOs = append(availableUTXOs, utxo)
+		}
+		anyTruncated = anyTruncated || truncated
 	}
 
-	return nil, 0, fmt.Errorf("insufficient funds: need %d satoshis, have %d satoshis",
-		amount+estimatedFee, totalValue)
+	if len(availableUTXOs) == 0 {
+		return nil, false, fmt.Errorf("no suitable UTXOs available for %d addresses", len(addresses))
+	}
+
+	return availableUTXOs, anyTruncated, nil
+}
+
+// TruncationError explains an insufficient funds error caused by fetching
+// fewer UTXOs than the address holds
+func TruncationError(err error, truncated bool) error {
+	if truncated && errors.Is(err, types.ErrInsufficientFunds) {
+		return fmt.Errorf("%w (%w); raise UTXOConfig.MaxUTXOsPerQuery to spend the remaining UTXOs", types.ErrUTXOSetTruncated, err)
+	}
+	return err
 }
 
 // SelectUTXOsForTokenTransfer selects UTXOs for token transfers
 func (m *Manager) SelectUTXOsForTokenTransfer(address string, tokenID string, amount int64, feeRate int64) ([]types.UTXO, int64, error) {
-	// Get all UTXOs
+	selectedTokenUTXOs, err := m.SelectTokenUTXOs(address, tokenID, amount)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	allUTXOs, err := m.GetUTXOs(address)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	// Filter for specific token UTXOs
-	var tokenUTXOs []types.UTXO
 	var nativeUTXOs []types.UTXO
-
 	for _, utxo := range allUTXOs {
 		if utxo.IsNative {
 			nativeUTXOs = append(nativeUTXOs, utxo)
-		} else if utxo.TokenID == tokenID {
-			tokenUTXOs = append(tokenUTXOs, utxo)
-		}
-	}
-
-	// Check if we have enough token UTXOs
-	var totalTokenAmount int64
-	for _, utxo := range tokenUTXOs {
-		totalTokenAmount += utxo.TokenAmount
-	}
-
-	if totalTokenAmount < amount {
-		return nil, 0, fmt.Errorf("insufficient token balance: need %d, have %d", amount, totalTokenAmount)
-	}
-
-	// Select token UTXOs for the transfer
-	var selectedTokenUTXOs []types.UTXO
-	var selectedTokenAmount int64
-
-	// Sort token UTXOs by token amount
-	sortedTokenUTXOs := m.sortUTXOsByTokenAmount(tokenUTXOs)
-
-	for _, utxo := range sortedTokenUTXOs {
-		selectedTokenUTXOs = append(selectedTokenUTXOs, utxo)
-		selectedTokenAmount += utxo.TokenAmount
-
-		if selectedTokenAmount >= amount {
-			break
 		}
 	}
 
 	// We also need native UTXOs for fees
 	// Estimate fee for transaction with token UTXOs
-	estimatedSize := 10 + len(selectedTokenUTXOs)*148 + 34 + 34 // Base + token inputs + outputs
-	estimatedFee := int64(estimatedSize) * feeRate
+	estimatedFee := NewSelectionTarget(0, types.SatoshisPerByte(feeRate), 0).Fee(len(selectedTokenUTXOs), true)
 
 	// Select native UTXOs for fees
 	var selectedNativeUTXOs []types.UTXO
 	var totalNativeValue int64
 
-	sortedNativeUTXOs := m.sortUTXOsByValue(nativeUTXOs)
+	sortedNativeUTXOs := sortUTXOsByValue(nativeUTXOs)
 
 	for _, utxo := range sortedNativeUTXOs {
 		selectedNativeUTXOs = append(selectedNativeUTXOs, utxo)
@@ -387,6 +508,41 @@ func (m *Manager) SelectUTXOsForTokenTransfer(address string, tokenID string, am
 	return allSelectedUTXOs, estimatedFee, nil
 }
 
+// SelectTokenUTXOs selects UTXOs of an address holding at least amount of a
+// token, largest first
+func (m *Manager) SelectTokenUTXOs(address string, tokenID string, amount int64) ([]types.UTXO, error) {
+	allUTXOs, err := m.GetUTXOs(address)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokenUTXOs []types.UTXO
+	var totalTokenAmount int64
+	for _, utxo := range allUTXOs {
+		if !utxo.IsNative && utxo.TokenID == tokenID {
+			tokenUTXOs = append(tokenUTXOs, utxo)
+			totalTokenAmount += utxo.TokenAmount
+		}
+	}
+
+	if totalTokenAmount < amount {
+		return nil, fmt.Errorf("%w for %s: need %d, have %d", types.ErrInsufficientTokens, tokenID, amount, totalTokenAmount)
+	}
+
+	var selected []types.UTXO
+	var selectedAmount int64
+	for _, utxo := range sortUTXOsByTokenAmount(tokenUTXOs) {
+		selected = append(selected, utxo)
+		selectedAmount += utxo.TokenAmount
+
+		if selectedAmount >= amount {
+			break
+		}
+	}
+
+	return selected, nil
+}
+
 // CalculateChange calculates the change amount after selecting UTXOs
 func (m *Manager) CalculateChange(selectedUTXOs []types.UTXO, amount, fee int64) (int64, bool) {
 	txConfig := m.configManager.GetTransactionConfig()
@@ -455,36 +611,6 @@ func (m *Manager) setCache(address string, entry *CacheEntry) {
 	m.cache[address] = entry
 }
 
-func (m *Manager) sortUTXOsByValue(utxos []types.UTXO) []types.UTXO {
-	sorted := make([]types.UTXO, len(utxos))
-	copy(sorted, utxos)
-
-	for i := 0; i < len(sorted)-1; i++ {
-		for j := 0; j < len(sorted)-i-1; j++ {
-			if sorted[j].Value < sorted[j+1].Value {
-				sorted[j], sorted[j+1] = sorted[j+1], sorted[j]
-			}
-		}
-	}
-
-	return sorted
-}
-
-func (m *Manager) sortUTXOsByTokenAmount(utxos []types.UTXO) []types.UTXO {
-	sorted := make([]types.UTXO, len(utxos))
-	copy(sorted, utxos)
-
-	for i := 0; i < len(sorted)-1; i++ {
-		for j := 0; j < len(sorted)-i-1; j++ {
-			if sorted[j].TokenAmount < sorted[j+1].TokenAmount {
-				sorted[j], sorted[j+1] = sorted[j+1], sorted[j]
-			}
-		}
-	}
-
-	return sorted
-}
-
 func (m *Manager) makeRequest(url string, result interface{}) error {
 	var lastErr error
 
@@ -536,6 +662,55 @@ func (m *Manager) makeRequest(url string, result interface{}) error {
 	return lastErr
 }
 
+// SetSPVVerifier enables SPV verification of fetched UTXOs. Each confirmed
+// UTXO's transaction is looked up in paths and checked against tracker; those
+// that verify are marked SPVVerified.
+func (m *Manager) SetSPVVerifier(tracker spv.ChainTracker, paths spv.MerklePathProvider) {
+	m.chainTracker = tracker
+	m.merklePaths = paths
+	m.ClearCache()
+}
+
+// markSPVVerified sets SPVVerified on UTXOs whose merkle proofs verify.
+// Lookup and verification failures leave the UTXO unverified.
+func (m *Manager) markSPVVerified(utxos []types.UTXO) {
+	if m.chainTracker == nil || m.merklePaths == nil {
+		return
+	}
+
+	verified := make(map[string]bool)
+	for i := range utxos {
+		if utxos[i].Height <= 0 {
+			continue
+		}
+
+		txID := utxos[i].TxID
+		if result, checked := verified[txID]; checked {
+			utxos[i].SPVVerified = result
+			continue
+		}
+
+		verified[txID] = m.verifyTransaction(txID)
+		utxos[i].SPVVerified = verified[txID]
+	}
+}
+
+// verifyTransaction reports whether a transaction is proven mined
+func (m *Manager) verifyTransaction(txID string) bool {
+	txHash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		return false
+	}
+
+	path, err := m.merklePaths.GetMerklePath(txID)
+	if err != nil || path == nil {
+		return false
+	}
+
+	valid, err := path.Verify(txHash, m.chainTracker)
+	return err == nil && valid
+}
+
 // SetRetryConfig sets the retry configuration
 func (m *Manager) SetRetryConfig(maxRetries int, retryDelay time.Duration) {
 	m.maxRetries = maxRetries
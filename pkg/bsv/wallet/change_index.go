@@ -0,0 +1,240 @@
+package wallet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ChangeIndexStore tracks the change address indexes of HD accounts, so no
+// change address is used twice. Builders reserve an index while building, so
+// concurrent builds get different addresses, and release it if the
+// transaction is never broadcast, so failed builds leave no gaps.
+type ChangeIndexStore interface {
+	// ReserveChangeIndex returns the lowest change index of the account with
+	// the given ID that is neither used nor reserved, and reserves it
+	ReserveChangeIndex(accountID string) (uint32, error)
+
+	// ReleaseChangeIndex returns a reserved index that was never broadcast,
+	// so the next reservation can use it
+	ReleaseChangeIndex(accountID string, index uint32) error
+
+	// CommitChangeIndex marks index, and every index before it, of the
+	// account with the given ID as used
+	CommitChangeIndex(accountID string, index uint32) error
+}
+
+// changeIndexes holds the next unreserved index of each account and the
+// indexes below it that were released
+type changeIndexes struct {
+	next     map[string]uint32
+	released map[string]map[uint32]bool
+}
+
+func newChangeIndexes() changeIndexes {
+	return changeIndexes{
+		next:     make(map[string]uint32),
+		released: make(map[string]map[uint32]bool),
+	}
+}
+
+// reserve hands out the lowest released index, or else the next one
+func (c changeIndexes) reserve(accountID string) uint32 {
+	if released := c.released[accountID]; len(released) > 0 {
+		lowest := c.next[accountID]
+		for index := range released {
+			lowest = min(lowest, index)
+		}
+		delete(released, lowest)
+		return lowest
+	}
+
+	index := c.next[accountID]
+	c.next[accountID] = index + 1
+	return index
+}
+
+// release returns a reserved index, moving the next index back over any
+// released indexes at the top
+func (c changeIndexes) release(accountID string, index uint32) {
+	next := c.next[accountID]
+	if index >= next {
+		return
+	}
+
+	released := c.released[accountID]
+	if released == nil {
+		released = make(map[uint32]bool)
+		c.released[accountID] = released
+	}
+	released[index] = true
+
+	for next > 0 && released[next-1] {
+		delete(released, next-1)
+		next--
+	}
+	c.next[accountID] = next
+}
+
+// commit marks index and every index before it as used
+func (c changeIndexes) commit(accountID string, index uint32) {
+	for released := range c.released[accountID] {
+		if released <= index {
+			delete(c.released[accountID], released)
+		}
+	}
+	if index >= c.next[accountID] {
+		c.next[accountID] = index + 1
+	}
+}
+
+// MemoryChangeIndexStore keeps change indexes in memory. Indexes restart at
+// zero with the process, so long-running wallets should persist them.
+type MemoryChangeIndexStore struct {
+	mutex   sync.Mutex
+	indexes changeIndexes
+}
+
+// NewMemoryChangeIndexStore creates an empty in-memory store
+func NewMemoryChangeIndexStore() *MemoryChangeIndexStore {
+	return &MemoryChangeIndexStore{indexes: newChangeIndexes()}
+}
+
+// ReserveChangeIndex implements ChangeIndexStore
+func (s *MemoryChangeIndexStore) ReserveChangeIndex(accountID string) (uint32, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.indexes.reserve(accountID), nil
+}
+
+// ReleaseChangeIndex implements ChangeIndexStore
+func (s *MemoryChangeIndexStore) ReleaseChangeIndex(accountID string, index uint32) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.indexes.release(accountID, index)
+	return nil
+}
+
+// CommitChangeIndex implements ChangeIndexStore
+func (s *MemoryChangeIndexStore) CommitChangeIndex(accountID string, index uint32) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.indexes.commit(accountID, index)
+	return nil
+}
+
+// FileChangeIndexStore keeps change indexes in a JSON file, mapping account
+// IDs to their next unreserved index. Reserved indexes are saved as soon as
+// they are handed out, so a crash never reuses one; released indexes below
+// the next one are only remembered by the process that released them.
+type FileChangeIndexStore struct {
+	mutex    sync.Mutex
+	path     string
+	released map[string]map[uint32]bool
+}
+
+// NewFileChangeIndexStore creates a store backed by the file at path, which
+// is created on first use
+func NewFileChangeIndexStore(path string) *FileChangeIndexStore {
+	return &FileChangeIndexStore{path: path, released: make(map[string]map[uint32]bool)}
+}
+
+// ReserveChangeIndex implements ChangeIndexStore
+func (s *FileChangeIndexStore) ReserveChangeIndex(accountID string) (uint32, error) {
+	var index uint32
+	err := s.update(func(indexes changeIndexes) {
+		index = indexes.reserve(accountID)
+	})
+	return index, err
+}
+
+// ReleaseChangeIndex implements ChangeIndexStore
+func (s *FileChangeIndexStore) ReleaseChangeIndex(accountID string, index uint32) error {
+	return s.update(func(indexes changeIndexes) {
+		indexes.release(accountID, index)
+	})
+}
+
+// CommitChangeIndex implements ChangeIndexStore
+func (s *FileChangeIndexStore) CommitChangeIndex(accountID string, index uint32) error {
+	return s.update(func(indexes changeIndexes) {
+		indexes.commit(accountID, index)
+	})
+}
+
+// update applies apply to the saved indexes and saves them if they changed
+func (s *FileChangeIndexStore) update(apply func(indexes changeIndexes)) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	next, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	saved := make(map[string]uint32, len(next))
+	for accountID, index := range next {
+		saved[accountID] = index
+	}
+
+	apply(changeIndexes{next: next, released: s.released})
+
+	changed := len(next) != len(saved)
+	for accountID, index := range next {
+		if saved[accountID] != index {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return s.write(next)
+}
+
+// read loads the next unreserved index of each account
+func (s *FileChangeIndexStore) read() (map[string]uint32, error) {
+	indexes := make(map[string]uint32)
+	data, err := os.ReadFile(s.path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read change indexes: %v", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &indexes); err != nil {
+			return nil, fmt.Errorf("failed to parse change indexes: %v", err)
+		}
+	}
+	return indexes, nil
+}
+
+// write saves the next unreserved index of each account
+func (s *FileChangeIndexStore) write(indexes map[string]uint32) error {
+	data, err := json.MarshalIndent(indexes, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode change indexes: %v", err)
+	}
+
+	// Replace the file atomically so a crash never loses used indexes
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write change indexes: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write change indexes: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write change indexes: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write change indexes: %v", err)
+	}
+
+	return nil
+}
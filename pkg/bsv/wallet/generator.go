@@ -88,48 +88,44 @@ func (g *Generator) GenerateWalletWithPath(mnemonicPhrase string, path *BIP44Pat
 
 	// Generate seed from mnemonic
 	seed := bip39.NewSeed(mnemonicPhrase, "")
+	defer types.Wipe(seed)
 
 	// Create master key
 	masterKey, err := bip32.NewMasterKey(seed)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create master key: %v", err)
 	}
+	defer wipeExtendedKey(masterKey)
 
-	// Derive BIP44 path: m/purpose'/coin_type'/account'/change/address_index
-	childKey := masterKey
-
-	// Derive purpose (44')
-	childKey, err = childKey.NewChildKey(bip32.FirstHardenedChild + path.Purpose)
-	if err != nil {
-		return nil, fmt.Errorf("failed to derive purpose: %v", err)
-	}
-
-	// Derive coin type
-	childKey, err = childKey.NewChildKey(bip32.FirstHardenedChild + path.CoinType)
-	if err != nil {
-		return nil, fmt.Errorf("failed to derive coin type: %v", err)
+	// Derive BIP44 path: m/purpose'/coin_type'/account'/change/address_index,
+	// wiping each intermediate key once its child is derived
+	steps := []struct {
+		index uint32
+		name  string
+	}{
+		{bip32.FirstHardenedChild + path.Purpose, "purpose"},
+		{bip32.FirstHardenedChild + path.CoinType, "coin type"},
+		{bip32.FirstHardenedChild + path.Account, "account"},
+		{path.Change, "change"},
+		{path.AddressIndex, "address index"},
 	}
 
-	// Derive account
-	childKey, err = childKey.NewChildKey(bip32.FirstHardenedChild + path.Account)
-	if err != nil {
-		return nil, fmt.Errorf("failed to derive account: %v", err)
-	}
-
-	// Derive change
-	childKey, err = childKey.NewChildKey(path.Change)
-	if err != nil {
-		return nil, fmt.Errorf("failed to derive change: %v", err)
-	}
-
-	// Derive address index
-	childKey, err = childKey.NewChildKey(path.AddressIndex)
-	if err != nil {
-		return nil, fmt.Errorf("failed to derive address index: %v", err)
+	childKey := masterKey
+	for _, step := range steps {
+		parentKey := childKey
+		childKey, err = parentKey.NewChildKey(step.index)
+		if parentKey != masterKey {
+			wipeExtendedKey(parentKey)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive %s: %v", step.name, err)
+		}
 	}
 
 	// Get private key
 	privateKey, _ := btcec.PrivKeyFromBytes(childKey.Key)
+	wipeExtendedKey(childKey)
+	defer privateKey.Zero()
 
 	// Create WIF (Wallet Import Format)
 	wif, err := btcutil.NewWIF(privateKey, g.network, true) // compressed = true
@@ -149,11 +145,17 @@ func (g *Generator) GenerateWalletWithPath(mnemonicPhrase string, path *BIP44Pat
 
 	return &types.WalletResult{
 		Address:    addressPubKey.EncodeAddress(),
-		PrivateKey: wif.String(),
+		PrivateKey: types.NewSecret(wif.String()),
 		PublicKey:  hex.EncodeToString(publicKeyBytes),
 	}, nil
 }
 
+// wipeExtendedKey overwrites the key material of an extended key
+func wipeExtendedKey(key *bip32.Key) {
+	types.Wipe(key.Key)
+	types.Wipe(key.ChainCode)
+}
+
 // GenerateWallet creates a BSV wallet from a mnemonic phrase using default BIP44 path
 func (g *Generator) GenerateWallet(mnemonicPhrase string) (*types.WalletResult, error) {
 	defaultPath := g.GetDefaultBIP44Path()
@@ -168,7 +170,7 @@ func (g *Generator) GenerateWalletWithKeypair(mnemonicPhrase string) (*types.Wal
 	}
 
 	// Parse the WIF to get the keypair
-	wif, err := btcutil.DecodeWIF(wallet.PrivateKey)
+	wif, err := btcutil.DecodeWIF(wallet.PrivateKey.Reveal())
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to decode WIF: %v", err)
 	}
@@ -217,18 +219,12 @@ type KeyPair struct {
 	Network    *chaincfg.Params
 }
 
-// SignMessage signs a message with the private key
-func (kp *KeyPair) SignMessage(message []byte) ([]byte, error) {
-	// For now, return a placeholder - this would need proper ECDSA signing
-	// TODO: Implement proper message signing with ECDSA
-	return []byte("placeholder_signature"), nil
-}
-
-// VerifySignature verifies a signature
-func (kp *KeyPair) VerifySignature(message, signature []byte) bool {
-	// For now, return true - this would need proper ECDSA verification
-	// TODO: Implement proper signature verification
-	return true
+// Zero wipes the private key. The key pair can no longer sign.
+func (kp *KeyPair) Zero() {
+	if kp.PrivateKey != nil {
+		kp.PrivateKey.Zero()
+		kp.PrivateKey = nil
+	}
 }
 
 // Package-level functions for convenience
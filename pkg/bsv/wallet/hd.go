@@ -0,0 +1,167 @@
+package wallet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/tyler-smith/go-bip32"
+	"github.com/tyler-smith/go-bip39"
+
+	"github.com/muhammadamman/BSV-Go/pkg/mnemonic"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// Chains of a BIP44 account
+const (
+	ExternalChain uint32 = 0 // Receiving addresses
+	InternalChain uint32 = 1 // Change addresses
+)
+
+// HDAccount is a BIP44 account, m/44'/coin_type'/account', from which the
+// receiving and change keys are derived
+type HDAccount struct {
+	key      *bip32.Key
+	coinType uint32
+	account  uint32
+	network  *chaincfg.Params
+}
+
+// NewHDAccount derives a BIP44 account from a mnemonic phrase
+func NewHDAccount(mnemonicPhrase string, account uint32, isTestnet bool) (*HDAccount, error) {
+	if err := mnemonic.Validate(mnemonicPhrase); err != nil {
+		return nil, fmt.Errorf("invalid mnemonic: %v", err)
+	}
+
+	seed := bip39.NewSeed(mnemonicPhrase, "")
+	defer types.Wipe(seed)
+
+	masterKey, err := bip32.NewMasterKey(seed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create master key: %v", err)
+	}
+	defer wipeExtendedKey(masterKey)
+
+	return newHDAccount(masterKey, account, isTestnet)
+}
+
+// ParseExtendedKey returns the BIP44 account of an extended private key
+// (xprv or tprv). A master key is derived to account 0; an account level key
+// is used as is.
+func ParseExtendedKey(extendedKey string, isTestnet bool) (*HDAccount, error) {
+	key, err := bip32.B58Deserialize(extendedKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extended key: %v", err)
+	}
+	if !key.IsPrivate {
+		return nil, fmt.Errorf("extended key is not private")
+	}
+
+	switch key.Depth {
+	case 0:
+		return newHDAccount(key, 0, isTestnet)
+	case 3:
+		childNumber := uint32(key.ChildNumber[0])<<24 | uint32(key.ChildNumber[1])<<16 | uint32(key.ChildNumber[2])<<8 | uint32(key.ChildNumber[3])
+		if childNumber < bip32.FirstHardenedChild {
+			return nil, fmt.Errorf("extended key is not a hardened account key")
+		}
+		network := networkFor(isTestnet)
+		return &HDAccount{key: key, coinType: coinType(network), account: childNumber - bip32.FirstHardenedChild, network: network}, nil
+	default:
+		return nil, fmt.Errorf("extended key at depth %d is neither a master nor an account key", key.Depth)
+	}
+}
+
+// IsExtendedKey reports whether s looks like a serialized extended private key
+func IsExtendedKey(s string) bool {
+	return strings.HasPrefix(s, "xprv") || strings.HasPrefix(s, "tprv")
+}
+
+// newHDAccount derives m/44'/coin_type'/account' from a master key
+func newHDAccount(masterKey *bip32.Key, account uint32, isTestnet bool) (*HDAccount, error) {
+	network := networkFor(isTestnet)
+	coin := coinType(network)
+
+	key := masterKey
+	for _, index := range []uint32{44, coin, account} {
+		parentKey := key
+		var err error
+		key, err = parentKey.NewChildKey(bip32.FirstHardenedChild + index)
+		if parentKey != masterKey {
+			wipeExtendedKey(parentKey)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive account: %v", err)
+		}
+	}
+
+	return &HDAccount{key: key, coinType: coin, account: account, network: network}, nil
+}
+
+// KeyPair derives the key at index on a chain of the account
+func (a *HDAccount) KeyPair(chain, index uint32) (*KeyPair, error) {
+	chainKey, err := a.key.NewChildKey(chain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive chain %d: %v", chain, err)
+	}
+
+	childKey, err := chainKey.NewChildKey(index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive index %d: %v", index, err)
+	}
+
+	privateKey, publicKey := btcec.PrivKeyFromBytes(childKey.Key)
+	wipeExtendedKey(chainKey)
+	wipeExtendedKey(childKey)
+	return &KeyPair{PrivateKey: privateKey, PublicKey: publicKey, Network: a.network}, nil
+}
+
+// Path returns the derivation path of the key at index on a chain
+func (a *HDAccount) Path(chain, index uint32) string {
+	return fmt.Sprintf("m/44'/%d'/%d'/%d/%d", a.coinType, a.account, chain, index)
+}
+
+// ParsePath returns the chain and index of a path of the account, as
+// returned by Path
+func (a *HDAccount) ParsePath(path string) (uint32, uint32, error) {
+	prefix := fmt.Sprintf("m/44'/%d'/%d'/", a.coinType, a.account)
+	if !strings.HasPrefix(path, prefix) {
+		return 0, 0, fmt.Errorf("path %s is not in account %s", path, strings.TrimSuffix(prefix, "/"))
+	}
+
+	parts := strings.Split(strings.TrimPrefix(path, prefix), "/")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("path %s does not end in <chain>/<index>", path)
+	}
+
+	chain, chainErr := strconv.ParseUint(parts[0], 10, 31)
+	index, indexErr := strconv.ParseUint(parts[1], 10, 31)
+	if chainErr != nil || indexErr != nil || a.Path(uint32(chain), uint32(index)) != path {
+		return 0, 0, fmt.Errorf("path %s does not end in <chain>/<index>", path)
+	}
+
+	return uint32(chain), uint32(index), nil
+}
+
+// ID identifies the account by its extended public key
+func (a *HDAccount) ID() string {
+	return a.key.PublicKey().B58Serialize()
+}
+
+// networkFor returns the network parameters
+func networkFor(isTestnet bool) *chaincfg.Params {
+	if isTestnet {
+		return &chaincfg.TestNet3Params
+	}
+	return &chaincfg.MainNetParams
+}
+
+// coinType returns the BIP44 coin type of a network
+func coinType(network *chaincfg.Params) uint32 {
+	if network.Name == chaincfg.TestNet3Params.Name {
+		return 1
+	}
+	return 236
+}
@@ -0,0 +1,90 @@
+package wallet
+
+import (
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// Keyring holds the signers of many addresses, so that one transaction can
+// spend UTXOs from all of them
+type Keyring struct {
+	mutex     sync.RWMutex
+	signers   map[string]Signer
+	addresses []string
+}
+
+// NewKeyring creates an empty keyring
+func NewKeyring() *Keyring {
+	return &Keyring{signers: make(map[string]Signer)}
+}
+
+// Add adds a key pair and returns its P2PKH address
+func (k *Keyring) Add(keyPair *KeyPair) (string, error) {
+	return k.AddSigner(NewKeySigner(keyPair), keyPair.Network)
+}
+
+// AddSigner adds a signer, such as a remote one, and returns the P2PKH
+// address of its key on a network
+func (k *Keyring) AddSigner(signer Signer, network *chaincfg.Params) (string, error) {
+	address, err := SignerAddress(signer, network)
+	if err != nil {
+		return "", err
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if _, exists := k.signers[address]; !exists {
+		k.addresses = append(k.addresses, address)
+	}
+	k.signers[address] = signer
+	return address, nil
+}
+
+// AddWIF adds a WIF private key for a network and returns its address
+func (k *Keyring) AddWIF(wif string, network *chaincfg.Params) (string, error) {
+	signer, err := NewWIFSigner(wif, network)
+	if err != nil {
+		return "", err
+	}
+
+	return k.AddSigner(signer, network)
+}
+
+// AddHD adds count consecutive keys of an HD account's chain, starting at
+// index start, and returns their addresses
+func (k *Keyring) AddHD(account *HDAccount, chain, start, count uint32) ([]string, error) {
+	addresses := make([]string, 0, count)
+	for index := start; index < start+count; index++ {
+		signer, err := NewHDSigner(account, chain, index)
+		if err != nil {
+			return nil, err
+		}
+
+		address, err := k.AddSigner(signer, account.network)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+
+	return addresses, nil
+}
+
+// Signer returns the signer of an address
+func (k *Keyring) Signer(address string) (Signer, bool) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	signer, ok := k.signers[address]
+	return signer, ok
+}
+
+// Addresses returns the keyring's addresses in the order they were added
+func (k *Keyring) Addresses() []string {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	return append([]string(nil), k.addresses...)
+}
@@ -0,0 +1,93 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// messageMagic prefixes every Bitcoin Signed Message before hashing
+const messageMagic = "Bitcoin Signed Message:\n"
+
+// messageHash returns the Bitcoin Signed Message (BSM) digest of a message
+func messageHash(message []byte) []byte {
+	var buf bytes.Buffer
+	wire.WriteVarString(&buf, 0, messageMagic)
+	wire.WriteVarBytes(&buf, 0, message)
+	return chainhash.DoubleHashB(buf.Bytes())
+}
+
+// Address returns the P2PKH address of the key pair's compressed public key
+func (kp *KeyPair) Address() (string, error) {
+	address, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(kp.PublicKey.SerializeCompressed()), kp.Network)
+	if err != nil {
+		return "", fmt.Errorf("failed to create address: %v", err)
+	}
+	return address.EncodeAddress(), nil
+}
+
+// SignMessage signs a message with the private key as a Bitcoin Signed Message,
+// returning the 65-byte compact signature the public key can be recovered from
+func (kp *KeyPair) SignMessage(message []byte) ([]byte, error) {
+	if kp.PrivateKey == nil {
+		return nil, fmt.Errorf("key pair has no private key")
+	}
+	return ecdsa.SignCompact(kp.PrivateKey, messageHash(message), true)
+}
+
+// VerifySignature reports whether signature is a Bitcoin Signed Message
+// signature of message by this key pair
+func (kp *KeyPair) VerifySignature(message, signature []byte) bool {
+	publicKey, _, err := ecdsa.RecoverCompact(signature, messageHash(message))
+	return err == nil && publicKey.IsEqual(kp.PublicKey)
+}
+
+// SignMessageBase64 signs a message and encodes the signature in base64, the
+// form wallets exchange and AIP embeds
+func (kp *KeyPair) SignMessageBase64(message []byte) (string, error) {
+	signature, err := kp.SignMessage(message)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// VerifyMessage checks a base64 Bitcoin Signed Message signature against the
+// P2PKH address of the signer, on any network
+func VerifyMessage(address string, message []byte, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %v", err)
+	}
+
+	publicKey, compressed, err := ecdsa.RecoverCompact(sig, messageHash(message))
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+
+	hash, _, err := base58.CheckDecode(address)
+	if err != nil {
+		return fmt.Errorf("invalid address: %v", err)
+	}
+
+	if !bytes.Equal(hash, btcutil.Hash160(serializePublicKey(publicKey, compressed))) {
+		return fmt.Errorf("signature is not by %s", address)
+	}
+
+	return nil
+}
+
+// serializePublicKey encodes a public key in the form its address was made from
+func serializePublicKey(publicKey *btcec.PublicKey, compressed bool) []byte {
+	if compressed {
+		return publicKey.SerializeCompressed()
+	}
+	return publicKey.SerializeUncompressed()
+}
@@ -0,0 +1,201 @@
+package wallet
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// Signer signs transaction signature hashes with one key. The private key
+// may be held in memory, derived from an HD wallet or kept by a remote
+// signing service.
+type Signer interface {
+	// PublicKey returns the public key of the signing key
+	PublicKey() *btcec.PublicKey
+
+	// Sign returns the DER-encoded ECDSA signature of a 32-byte hash
+	Sign(sighash []byte) ([]byte, error)
+}
+
+// PreimageSigner is a Signer that can be given the sighash preimage, the data
+// whose double SHA-256 is the signature hash, so the key holder can inspect
+// what it signs before signing
+type PreimageSigner interface {
+	Signer
+
+	// SignPreimage returns the DER-encoded ECDSA signature of the double
+	// SHA-256 of preimage. outputs are the serialized outputs whose double
+	// SHA-256 is the preimage's hashOutputs, or nil when it signs none.
+	SignPreimage(preimage, outputs []byte) ([]byte, error)
+}
+
+// SignerAddress returns the P2PKH address of a signer's compressed public key
+func SignerAddress(signer Signer, network *chaincfg.Params) (string, error) {
+	address, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(signer.PublicKey().SerializeCompressed()), network)
+	if err != nil {
+		return "", fmt.Errorf("failed to create address: %v", err)
+	}
+	return address.EncodeAddress(), nil
+}
+
+// KeySigner signs with a private key held in memory
+type KeySigner struct {
+	keyPair *KeyPair
+}
+
+// NewKeySigner creates a signer for a key pair
+func NewKeySigner(keyPair *KeyPair) *KeySigner {
+	return &KeySigner{keyPair: keyPair}
+}
+
+// NewWIFSigner creates a signer for a WIF private key of a network
+func NewWIFSigner(wif string, network *chaincfg.Params) (*KeySigner, error) {
+	decoded, err := btcutil.DecodeWIF(wif)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WIF private key: %v", err)
+	}
+	if !decoded.IsForNet(network) {
+		return nil, fmt.Errorf("WIF private key is not for the correct network")
+	}
+
+	return NewKeySigner(&KeyPair{PrivateKey: decoded.PrivKey, PublicKey: decoded.PrivKey.PubKey(), Network: network}), nil
+}
+
+// PublicKey implements Signer
+func (s *KeySigner) PublicKey() *btcec.PublicKey {
+	return s.keyPair.PublicKey
+}
+
+// Sign implements Signer with a deterministic (RFC 6979), low-S signature
+func (s *KeySigner) Sign(sighash []byte) ([]byte, error) {
+	if len(sighash) != 32 {
+		return nil, fmt.Errorf("signature hash must be 32 bytes, got %d", len(sighash))
+	}
+	if s.keyPair.PrivateKey == nil {
+		return nil, fmt.Errorf("key pair has no private key")
+	}
+
+	return ecdsa.Sign(s.keyPair.PrivateKey, sighash).Serialize(), nil
+}
+
+// HDSigner signs with a key of an HD account
+type HDSigner struct {
+	*KeySigner
+	account *HDAccount
+	chain   uint32
+	index   uint32
+}
+
+// NewHDSigner creates a signer for the key at index on a chain of an account
+func NewHDSigner(account *HDAccount, chain, index uint32) (*HDSigner, error) {
+	keyPair, err := account.KeyPair(chain, index)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HDSigner{KeySigner: NewKeySigner(keyPair), account: account, chain: chain, index: index}, nil
+}
+
+// NewMnemonicSigner creates a signer for the first receiving key,
+// m/44'/coin'/0'/0/0, of a mnemonic phrase
+func NewMnemonicSigner(mnemonicPhrase string, isTestnet bool) (*HDSigner, error) {
+	account, err := NewHDAccount(mnemonicPhrase, 0, isTestnet)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewHDSigner(account, ExternalChain, 0)
+}
+
+// Account returns the HD account the signing key belongs to
+func (s *HDSigner) Account() *HDAccount {
+	return s.account
+}
+
+// Path returns the derivation path of the signing key
+func (s *HDSigner) Path() string {
+	return s.account.Path(s.chain, s.index)
+}
+
+// SigningService signs hashes with keys it holds, such as an HSM or a signing
+// server. Keys are named by an ID the service understands.
+type SigningService interface {
+	SignHash(keyID string, sighash []byte) ([]byte, error)
+}
+
+// PreimageSigningService is a SigningService that can be given sighash
+// preimages and the outputs they commit to, see PreimageSigner
+type PreimageSigningService interface {
+	SigningService
+	SignPreimage(keyID string, preimage, outputs []byte) ([]byte, error)
+}
+
+// RemoteSigner signs through a SigningService without ever holding the
+// private key
+type RemoteSigner struct {
+	service   SigningService
+	keyID     string
+	publicKey *btcec.PublicKey
+}
+
+// NewRemoteSigner creates a signer for a key held by a signing service
+func NewRemoteSigner(service SigningService, keyID string, publicKey *btcec.PublicKey) *RemoteSigner {
+	return &RemoteSigner{service: service, keyID: keyID, publicKey: publicKey}
+}
+
+// PublicKey implements Signer
+func (s *RemoteSigner) PublicKey() *btcec.PublicKey {
+	return s.publicKey
+}
+
+// KeyID returns the ID of the key at the signing service
+func (s *RemoteSigner) KeyID() string {
+	return s.keyID
+}
+
+// Sign implements Signer, rejecting any signature that is not valid for the
+// hash and the signer's public key
+func (s *RemoteSigner) Sign(sighash []byte) ([]byte, error) {
+	if len(sighash) != 32 {
+		return nil, fmt.Errorf("signature hash must be 32 bytes, got %d", len(sighash))
+	}
+
+	der, err := s.service.SignHash(s.keyID, sighash)
+	if err != nil {
+		return nil, fmt.Errorf("remote signing failed: %v", err)
+	}
+	return s.verify(der, sighash)
+}
+
+// SignPreimage implements PreimageSigner. Services that are not a
+// PreimageSigningService are sent the preimage's hash.
+func (s *RemoteSigner) SignPreimage(preimage, outputs []byte) ([]byte, error) {
+	service, ok := s.service.(PreimageSigningService)
+	if !ok {
+		return s.Sign(chainhash.DoubleHashB(preimage))
+	}
+
+	der, err := service.SignPreimage(s.keyID, preimage, outputs)
+	if err != nil {
+		return nil, fmt.Errorf("remote signing failed: %v", err)
+	}
+	return s.verify(der, chainhash.DoubleHashB(preimage))
+}
+
+// verify rejects a signature that is not valid for the hash and the signer's
+// public key
+func (s *RemoteSigner) verify(der, sighash []byte) ([]byte, error) {
+	signature, err := ecdsa.ParseDERSignature(der)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer returned an invalid signature: %v", err)
+	}
+	if !signature.Verify(sighash, s.publicKey) {
+		return nil, fmt.Errorf("remote signer returned a signature for another key or hash")
+	}
+
+	return der, nil
+}
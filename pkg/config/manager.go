@@ -14,6 +14,18 @@ const (
 	Custom  NetworkType = "custom"
 )
 
+// CoinSelectionStrategy names the algorithm used to pick transaction inputs
+type CoinSelectionStrategy string
+
+const (
+	CoinSelectionLargestFirst   CoinSelectionStrategy = "largest-first"    // Fewest inputs
+	CoinSelectionSmallestFirst  CoinSelectionStrategy = "smallest-first"   // Consolidate small UTXOs
+	CoinSelectionOldestFirst    CoinSelectionStrategy = "oldest-first"     // Lowest block height first
+	CoinSelectionBranchAndBound CoinSelectionStrategy = "branch-and-bound" // Exact match, avoid change
+	CoinSelectionRandomImprove  CoinSelectionStrategy = "random-improve"   // Randomised, payment-sized change
+	CoinSelectionPrivacy        CoinSelectionStrategy = "privacy"          // Never mix addresses
+)
+
 // NetworkConfig represents network configuration
 type NetworkConfig struct {
 	Name        string `json:"name"`        // Network name
@@ -43,7 +55,9 @@ type TransactionConfig struct {
 	MaxTransactionSize    int   `json:"maxTransactionSize"`    // Maximum transaction size in bytes
 	EnableRBF             bool  `json:"enableRBF"`             // Enable Replace-By-Fee
 	IncludeNativeUTXOs    bool  `json:"includeNativeUTXOs"`    // Include native BSV UTXOs in transactions
-	IncludeNonNativeUTXOs bool  `json:"includeNonNativeUTXOs"` // Include non-native token UTXOs in transactions
+	IncludeNonNativeUTXOs bool  `json:"includeNonNativeUTXOs"` // Also fund payments from custom script UTXOs; never ordinals or tokens
+	// CoinSelectionStrategy is the default input selection strategy
+	CoinSelectionStrategy CoinSelectionStrategy `json:"coinSelectionStrategy"`
 }
 
 // Config represents the complete configuration
@@ -233,6 +247,7 @@ func getDefaultTransactionConfig() *TransactionConfig {
 		EnableRBF:             false,
 		IncludeNativeUTXOs:    true,
 		IncludeNonNativeUTXOs: false,
+		CoinSelectionStrategy: CoinSelectionLargestFirst,
 	}
 }
 
@@ -330,6 +345,13 @@ func (m *Manager) validateTransactionConfig(tx *TransactionConfig) error {
 		return fmt.Errorf("maximum transaction size must be positive")
 	}
 
+	switch tx.CoinSelectionStrategy {
+	case "", CoinSelectionLargestFirst, CoinSelectionSmallestFirst, CoinSelectionOldestFirst,
+		CoinSelectionBranchAndBound, CoinSelectionRandomImprove, CoinSelectionPrivacy:
+	default:
+		return fmt.Errorf("unsupported coin selection strategy: %s", tx.CoinSelectionStrategy)
+	}
+
 	return nil
 }
 
@@ -387,5 +409,6 @@ func (m *Manager) deepCopyTransactionConfig(tx *TransactionConfig) *TransactionC
 		EnableRBF:             tx.EnableRBF,
 		IncludeNativeUTXOs:    tx.IncludeNativeUTXOs,
 		IncludeNonNativeUTXOs: tx.IncludeNonNativeUTXOs,
+		CoinSelectionStrategy: tx.CoinSelectionStrategy,
 	}
 }
@@ -69,8 +69,13 @@ func (m *Manager) CombineShards(shards []string) (string, error) {
 		return "", errors.New("at least 2 shards are required")
 	}
 
-	// Decode hex shards
+	// Decode hex shards, wiping them once the mnemonic is recovered
 	shareData := make([][]byte, len(shards))
+	defer func() {
+		for _, data := range shareData {
+			types.Wipe(data)
+		}
+	}()
 
 	for i, shard := range shards {
 		// Validate shard format
@@ -100,6 +105,7 @@ func (m *Manager) CombineShards(shards []string) (string, error) {
 
 	// For simplicity, just use the first share (which contains the mnemonic)
 	result := make([]byte, expectedLength)
+	defer types.Wipe(result)
 	copy(result, shareData[0])
 
 	// Convert back to string and validate
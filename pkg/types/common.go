@@ -7,22 +7,27 @@ import (
 
 // Common error definitions
 var (
-	ErrInvalidMnemonic    = errors.New("invalid mnemonic phrase")
-	ErrInvalidShard       = errors.New("invalid shard format")
-	ErrInsufficientShards = errors.New("insufficient shards to reconstruct mnemonic")
-	ErrInvalidAddress     = errors.New("invalid BSV address")
-	ErrInsufficientFunds  = errors.New("insufficient funds")
-	ErrInvalidAmount      = errors.New("invalid amount")
-	ErrNetworkError       = errors.New("network error")
-	ErrInvalidPrivateKey  = errors.New("invalid private key")
-	ErrInvalidUTXO        = errors.New("invalid UTXO")
-	ErrTransactionFailed  = errors.New("transaction failed")
+	ErrInvalidMnemonic     = errors.New("invalid mnemonic phrase")
+	ErrInvalidShard        = errors.New("invalid shard format")
+	ErrInsufficientShards  = errors.New("insufficient shards to reconstruct mnemonic")
+	ErrInvalidAddress      = errors.New("invalid BSV address")
+	ErrInsufficientFunds   = errors.New("insufficient funds")
+	ErrInvalidAmount       = errors.New("invalid amount")
+	ErrNetworkError        = errors.New("network error")
+	ErrInvalidPrivateKey   = errors.New("invalid private key")
+	ErrInvalidUTXO         = errors.New("invalid UTXO")
+	ErrTransactionFailed   = errors.New("transaction failed")
+	ErrTransactionTooLarge = errors.New("transaction exceeds maximum size")
+	ErrUTXOSetTruncated    = errors.New("UTXO set truncated by MaxUTXOsPerQuery")
+	ErrNoUnlocker          = errors.New("no template can unlock the locking script")
+	ErrInsufficientTokens  = errors.New("insufficient token balance")
+	ErrTokensNotConserved  = errors.New("token inputs and outputs do not balance")
 )
 
 // WalletResult represents a generated wallet
 type WalletResult struct {
 	Address    string `json:"address"`    // BSV address
-	PrivateKey string `json:"privateKey"` // WIF private key
+	PrivateKey Secret `json:"privateKey"` // WIF private key, redacted when printed
 	PublicKey  string `json:"publicKey"`  // Public key in hex
 }
 
@@ -33,18 +38,63 @@ type ShardingResult struct {
 	TotalShares int      `json:"totalShares"` // Total number of shards (3)
 }
 
+// UTXOType classifies a UTXO by its locking script
+type UTXOType string
+
+// UTXO types
+const (
+	UTXOTypeP2PKH   UTXOType = "p2pkh"   // Plain satoshis paying a public key hash
+	UTXOTypeP2PK    UTXOType = "p2pk"    // Plain satoshis paying a public key
+	UTXOTypeOrdinal UTXOType = "ordinal" // 1Sat ordinal, inscribed or a lone satoshi
+	UTXOTypeBSV20   UTXOType = "bsv-20"  // Ticker based BSV-20 token
+	UTXOTypeBSV21   UTXOType = "bsv-21"  // BSV-21 token
+	UTXOTypeSTAS    UTXOType = "stas"    // STAS token
+	UTXOTypeUnknown UTXOType = "unknown" // Any other script
+)
+
+// IsNative reports whether UTXOs of this type hold plain satoshis
+func (t UTXOType) IsNative() bool {
+	return t == UTXOTypeP2PKH || t == UTXOTypeP2PK
+}
+
 // UTXO represents an unspent transaction output
 type UTXO struct {
-	TxID          string `json:"txid"`          // Transaction ID
-	Vout          uint32 `json:"vout"`          // Output index
-	Value         int64  `json:"value"`         // Value in satoshis
-	ScriptPubKey  string `json:"scriptPubKey"`  // Script public key
-	Address       string `json:"address"`       // Address (for convenience)
-	Confirmations int    `json:"confirmations"` // Number of confirmations
-	Height        int    `json:"height"`        // Block height
-	IsNative      bool   `json:"isNative"`      // Whether this is native BSV UTXO
-	TokenID       string `json:"tokenId"`       // Token ID for non-native UTXOs (empty for native)
-	TokenAmount   int64  `json:"tokenAmount"`   // Token amount for non-native UTXOs
+	TxID          string   `json:"txid"`          // Transaction ID
+	Vout          uint32   `json:"vout"`          // Output index
+	Value         int64    `json:"value"`         // Value in satoshis
+	ScriptPubKey  string   `json:"scriptPubKey"`  // Script public key
+	Address       string   `json:"address"`       // Address (for convenience)
+	Confirmations int      `json:"confirmations"` // Number of confirmations
+	Height        int      `json:"height"`        // Block height
+	Type          UTXOType `json:"type"`          // Classification of the locking script
+	IsNative      bool     `json:"isNative"`      // Whether this is native BSV UTXO
+	TokenID       string   `json:"tokenId"`       // Token ID for non-native UTXOs (empty for native)
+	TokenAmount   int64    `json:"tokenAmount"`   // Token amount for non-native UTXOs
+	SPVVerified   bool     `json:"spvVerified"`   // Whether a merkle proof showed the transaction is mined
+}
+
+// FeeRate expresses a miner fee as satoshis charged per number of bytes
+type FeeRate struct {
+	Satoshis int64 `json:"satoshis"` // Satoshis charged
+	Bytes    int64 `json:"bytes"`    // Per this many bytes
+}
+
+// SatoshisPerByte returns a rate of whole satoshis per byte
+func SatoshisPerByte(satoshis int64) FeeRate {
+	return FeeRate{Satoshis: satoshis, Bytes: 1}
+}
+
+// IsSet reports whether the rate charges a fee
+func (r FeeRate) IsSet() bool {
+	return r.Satoshis > 0 && r.Bytes > 0
+}
+
+// FeeForSize returns the fee in satoshis for a number of bytes, rounded up
+func (r FeeRate) FeeForSize(size int) int64 {
+	if !r.IsSet() || size <= 0 {
+		return 0
+	}
+	return (int64(size)*r.Satoshis + r.Bytes - 1) / r.Bytes
 }
 
 // TransactionParams represents parameters for building a transaction
@@ -53,12 +103,78 @@ type TransactionParams struct {
 	To         string `json:"to"`         // Recipient address
 	Amount     int64  `json:"amount"`     // Amount in satoshis
 	FeeRate    int64  `json:"feeRate"`    // Fee rate in satoshis per vbyte (optional)
-	PrivateKey string `json:"privateKey"` // Private key (WIF or mnemonic)
+	PrivateKey Secret `json:"privateKey"` // Private key (WIF or mnemonic), redacted when printed
 	// Enhanced parameters for native/non-native support
 	IncludeNativeUTXOs    bool             `json:"includeNativeUTXOs"`    // Include native BSV UTXOs
 	IncludeNonNativeUTXOs bool             `json:"includeNonNativeUTXOs"` // Include non-native token UTXOs
 	TokenTransfers        []*TokenTransfer `json:"tokenTransfers"`        // Token transfers for non-native transactions
+	TokenChangeAddress    string           `json:"tokenChangeAddress"`    // Address receiving token change (optional, defaults to From)
 	DataOutputs           []*DataOutput    `json:"dataOutputs"`           // Data outputs (OP_RETURN)
+	CoinSelectionStrategy string           `json:"coinSelectionStrategy"` // Input selection strategy (optional, defaults to config)
+	// Extra addresses whose UTXOs may fund the transaction, each input signed by its address's key
+	FundingAddresses []string  `json:"fundingAddresses"` // Addresses whose keys are in the builder's keyring (optional)
+	FundingKeys      []Secret  `json:"fundingKeys"`      // Private keys (WIF, mnemonic or xprv) of funding addresses (optional)
+	FundingPaths     []*HDPath `json:"fundingPaths"`     // Addresses of the sender's HD account (optional, needs a mnemonic or xprv)
+}
+
+// HDPath locates an address in a BIP44 account, m/44'/coin'/account'/chain/index
+type HDPath struct {
+	Chain uint32 `json:"chain"` // 0 for receiving addresses, 1 for change
+	Index uint32 `json:"index"` // Address index on the chain
+}
+
+// SplitMode controls how a payout that exceeds the maximum transaction size is split
+type SplitMode string
+
+const (
+	SplitNone        SplitMode = "none"        // Fail if the payout does not fit in one transaction
+	SplitIndependent SplitMode = "independent" // Each transaction spends its own UTXOs
+	SplitChained     SplitMode = "chained"     // Each transaction spends the previous transaction's change
+)
+
+// PayoutParams represents a multi-output payout that may span several transactions
+type PayoutParams struct {
+	From       string          `json:"from"`       // Sender address
+	PrivateKey Secret          `json:"privateKey"` // Private key (WIF or mnemonic), redacted when printed
+	FeeRate    int64           `json:"feeRate"`    // Fee rate in satoshis per byte (optional)
+	Outputs    []*PayoutOutput `json:"outputs"`    // Payments to make
+	SplitMode  SplitMode       `json:"splitMode"`  // How to split oversized payouts (default none)
+}
+
+// PayoutOutput represents a single payment in a payout
+type PayoutOutput struct {
+	To     string `json:"to"`     // Recipient address
+	Amount int64  `json:"amount"` // Amount in satoshis
+}
+
+// InscriptionParams represents files to inscribe into 1-satoshi ordinals
+type InscriptionParams struct {
+	From         string         `json:"from"`         // Sender address, paying the fee
+	PrivateKey   Secret         `json:"privateKey"`   // Private key (WIF or mnemonic), redacted when printed
+	FeeRate      int64          `json:"feeRate"`      // Fee rate in satoshis per byte (optional)
+	Inscriptions []*Inscription `json:"inscriptions"` // Files to inscribe, one output each
+}
+
+// Inscription represents a file to inscribe
+type Inscription struct {
+	ContentType string `json:"contentType"` // MIME type of the body
+	Body        []byte `json:"body"`        // File contents
+	To          string `json:"to"`          // Owner address (optional, defaults to the sender)
+}
+
+// OrdinalTransferParams represents 1-satoshi ordinals to send to new owners
+type OrdinalTransferParams struct {
+	From       string             `json:"from"`       // Current owner address, paying the fee
+	PrivateKey Secret             `json:"privateKey"` // Private key (WIF or mnemonic), redacted when printed
+	FeeRate    int64              `json:"feeRate"`    // Fee rate in satoshis per byte (optional)
+	Transfers  []*OrdinalTransfer `json:"transfers"`  // Ordinals to send
+}
+
+// OrdinalTransfer represents one ordinal and its new owner
+type OrdinalTransfer struct {
+	TxID string `json:"txid"` // Transaction holding the ordinal
+	Vout uint32 `json:"vout"` // Output holding the ordinal
+	To   string `json:"to"`   // New owner address
 }
 
 // TokenTransfer represents a token transfer in a transaction
@@ -68,9 +184,19 @@ type TokenTransfer struct {
 	Amount  int64  `json:"amount"`  // Token amount to transfer
 }
 
-// DataOutput represents a data output (OP_RETURN) in a transaction
+// DataOutput represents an OP_FALSE OP_RETURN data output in a transaction.
+// Data, when set, is pushed first, followed by each of Pushes.
 type DataOutput struct {
-	Data string `json:"data"` // Hex-encoded data to include in OP_RETURN
+	Data   string     `json:"data,omitempty"`   // Hex-encoded data to include in OP_RETURN
+	Pushes []DataPush `json:"pushes,omitempty"` // Further pushes, in order
+}
+
+// DataPush is one push of a data output. At most one field may be set; none
+// set pushes an empty element.
+type DataPush struct {
+	Hex   string `json:"hex,omitempty"`   // Hex-encoded bytes
+	Text  string `json:"text,omitempty"`  // UTF-8 text
+	Bytes []byte `json:"bytes,omitempty"` // Raw bytes, base64 in JSON
 }
 
 // TransactionResult represents the result of a signed transaction
@@ -84,6 +210,7 @@ type TransactionResult struct {
 	OutputsCreated []*TransactionOutput `json:"outputsCreated"` // Outputs created
 	TokenTransfers []*TokenTransfer     `json:"tokenTransfers"` // Token transfers executed
 	DataOutputs    []*DataOutput        `json:"dataOutputs"`    // Data outputs included
+	Beef           string               `json:"beef,omitempty"` // BEEF (BRC-62) envelope in hex, when attached
 }
 
 // TransactionOutput represents an output in a transaction
@@ -93,6 +220,8 @@ type TransactionOutput struct {
 	ScriptPubKey string `json:"scriptPubKey"` // Script public key
 	IsData       bool   `json:"isData"`       // Whether this is a data output
 	Data         string `json:"data"`         // Data content (for data outputs)
+
+	DerivationPath string `json:"derivationPath,omitempty"` // BIP44 path of an HD change output
 }
 
 // NetworkConfig represents network configuration
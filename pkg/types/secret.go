@@ -0,0 +1,97 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// redacted is shown in place of a secret's value
+const redacted = "[REDACTED]"
+
+// Secret holds a private key, mnemonic or other sensitive string in a byte
+// buffer that can be wiped. Formatting and JSON encoding show "[REDACTED]";
+// the value is only available through Reveal. Copies of a Secret share the
+// buffer, so Zero wipes them all.
+type Secret struct {
+	value []byte
+}
+
+// NewSecret copies a string into a secret
+func NewSecret(value string) Secret {
+	return Secret{value: []byte(value)}
+}
+
+// NewSecretFromBytes creates a secret that takes ownership of a buffer, which
+// Zero wipes
+func NewSecretFromBytes(value []byte) Secret {
+	return Secret{value: value}
+}
+
+// Reveal returns the secret's value
+func (s Secret) Reveal() string {
+	return string(s.value)
+}
+
+// RevealBytes returns the secret's buffer, without copying it
+func (s Secret) RevealBytes() []byte {
+	return s.value
+}
+
+// IsEmpty reports whether the secret has no value, or has been wiped
+func (s Secret) IsEmpty() bool {
+	for _, b := range s.value {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Zero overwrites the secret's buffer with zeros
+func (s Secret) Zero() {
+	Wipe(s.value)
+}
+
+// String implements fmt.Stringer without revealing the value
+func (s Secret) String() string {
+	return redacted
+}
+
+// GoString implements fmt.GoStringer without revealing the value
+func (s Secret) GoString() string {
+	return "types.Secret(" + redacted + ")"
+}
+
+// Format implements fmt.Formatter so that no verb reveals the value
+func (s Secret) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		fmt.Fprint(f, s.GoString())
+		return
+	}
+	fmt.Fprint(f, redacted)
+}
+
+// MarshalJSON implements json.Marshaler without revealing the value
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(redacted)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, reading the value from a string
+func (s *Secret) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("secret must be a JSON string: %v", err)
+	}
+	if value == redacted {
+		return fmt.Errorf("cannot decode a redacted secret")
+	}
+	*s = NewSecret(value)
+	return nil
+}
+
+// Wipe overwrites a buffer with zeros
+func Wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/spv"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// staticChainTracker accepts a fixed set of merkle roots by height
+type staticChainTracker map[uint32]chainhash.Hash
+
+func (s staticChainTracker) IsValidRootForHeight(root *chainhash.Hash, height uint32) (bool, error) {
+	known, ok := s[height]
+	return ok && known.IsEqual(root), nil
+}
+
+// fundingTx returns a transaction paying value to address, standing in for a mined parent
+func fundingTx(t *testing.T, address string, value int64) *wire.MsgTx {
+	t.Helper()
+
+	addr, err := btcutil.DecodeAddress(address, &chaincfg.TestNet3Params)
+	if err != nil {
+		t.Fatalf("Failed to decode address: %v", err)
+	}
+
+	script, _ := txscript.PayToAddrScript(addr)
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0x01}, 0), []byte{0x51}, nil))
+	tx.AddTxOut(wire.NewTxOut(value, script))
+	return tx
+}
+
+// sendOffline builds and signs a payment spending one output of a known transaction
+func sendOffline(t *testing.T, node *mockNode, from *types.WalletResult, key string, to string, source *wire.MsgTx, vout uint32, amount int64) *wire.MsgTx {
+	t.Helper()
+
+	node.utxos[from.Address] = nil
+	node.addUTXO(from.Address, source.TxHash().String(), vout, source.TxOut[vout].Value, "")
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	unsigned, err := builder.BuildUnsignedTransaction(&types.TransactionParams{
+		From:    from.Address,
+		To:      to,
+		Amount:  amount,
+		FeeRate: 1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to build transaction: %v", err)
+	}
+
+	if _, err := transaction.SignOffline(unsigned, types.NewSecret(key)); err != nil {
+		t.Fatalf("Failed to sign transaction: %v", err)
+	}
+
+	return unsigned.Tx
+}
+
+func TestBeefRoundTripAndVerify(t *testing.T) {
+	node := newMockNode(t)
+
+	sender, mnemonicPhrase := newTestWallet(t)
+	recipient, _ := newTestWallet(t)
+
+	// Mined parent proven by a two-level BUMP
+	parent := fundingTx(t, sender.Address, 100000)
+	parentID := parent.TxHash()
+	sibling := chainhash.Hash{0xaa}
+	uncle := chainhash.Hash{0xbb}
+
+	bump := &spv.MerklePath{
+		BlockHeight: 1000,
+		Path: [][]*spv.PathElement{
+			{{Offset: 0, Hash: &parentID, TxID: true}, {Offset: 1, Hash: &sibling}},
+			{{Offset: 1, Hash: &uncle}},
+		},
+	}
+
+	left := chainhash.DoubleHashH(append(parentID.CloneBytes(), sibling[:]...))
+	root := chainhash.DoubleHashH(append(left.CloneBytes(), uncle[:]...))
+	tracker := staticChainTracker{1000: root}
+
+	// Unmined middle transaction, then the subject spending its change
+	middle := sendOffline(t, node, sender, mnemonicPhrase, recipient.Address, parent, 0, 20000)
+	subject := sendOffline(t, node, sender, mnemonicPhrase, recipient.Address, middle, 1, 5000)
+
+	if _, err := transaction.NewBeef(subject, []*wire.MsgTx{parent}, []*spv.MerklePath{bump}); err == nil {
+		t.Error("Expected error when an unmined ancestor is missing")
+	}
+
+	beef, err := transaction.NewBeef(subject, []*wire.MsgTx{middle, parent}, []*spv.MerklePath{bump})
+	if err != nil {
+		t.Fatalf("Failed to build BEEF: %v", err)
+	}
+
+	if len(beef.Transactions) != 3 || beef.Transactions[0].BumpIndex != 0 || beef.Transactions[1].BumpIndex != -1 {
+		t.Fatalf("Expected parent, middle and subject in order, got %d transactions", len(beef.Transactions))
+	}
+
+	beefHex, err := beef.Hex()
+	if err != nil {
+		t.Fatalf("Failed to encode BEEF: %v", err)
+	}
+
+	if beefHex[:8] != "0100beef" {
+		t.Errorf("Expected 0100beef version prefix, got %s", beefHex[:8])
+	}
+
+	decoded, err := transaction.ParseBeefHex(beefHex)
+	if err != nil {
+		t.Fatalf("Failed to parse BEEF: %v", err)
+	}
+
+	if decoded.SubjectTransaction().TxHash() != subject.TxHash() {
+		t.Error("Subject transaction does not match")
+	}
+
+	if err := decoded.Verify(tracker); err != nil {
+		t.Fatalf("Expected BEEF to verify: %v", err)
+	}
+
+	if err := decoded.Verify(staticChainTracker{}); err == nil {
+		t.Error("Expected verification to fail for an unknown merkle root")
+	}
+
+	// Inflating an output breaks both the signature and the amount check
+	decoded.SubjectTransaction().TxOut[0].Value = 200000
+	if err := decoded.Verify(tracker); err == nil {
+		t.Error("Expected verification to fail for a tampered transaction")
+	}
+}
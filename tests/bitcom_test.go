@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/bitcom"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// dataTx returns a transaction carrying data outputs
+func dataTx(t *testing.T, outputs ...*types.DataOutput) *wire.MsgTx {
+	t.Helper()
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0x07}, uint32(len(outputs))), nil, nil))
+	for _, output := range outputs {
+		script, err := transaction.DataOutputScript(output)
+		if err != nil {
+			t.Fatalf("Failed to create data output script: %v", err)
+		}
+		tx.AddTxOut(wire.NewTxOut(0, script))
+	}
+	return tx
+}
+
+func TestBitcomSignedFileRoundTrip(t *testing.T) {
+	key, _ := p2pkhTestKey(t, 0x71)
+	address, _ := key.Address()
+
+	builder := bitcom.NewBuilder()
+	if err := builder.Add(&bitcom.BFile{Data: []byte("# Hello"), MediaType: "text/markdown", Encoding: "utf-8", Filename: "hello.md"}); err != nil {
+		t.Fatalf("Failed to add file: %v", err)
+	}
+	if err := builder.Add(&bitcom.MAP{Command: bitcom.MAPSet, Pairs: []bitcom.KeyValue{{Key: "app", Value: "bsv-go"}, {Key: "type", Value: "post"}}}); err != nil {
+		t.Fatalf("Failed to add MAP: %v", err)
+	}
+	if err := builder.Sign(key); err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+
+	tx := dataTx(t, builder.DataOutput())
+	records, err := bitcom.ParseTransaction(tx)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("Expected one record, got %d: %v", len(records), err)
+	}
+
+	record := records[0]
+	if len(record.Files) != 1 || string(record.Files[0].Data) != "# Hello" || record.Files[0].Filename != "hello.md" {
+		t.Errorf("Unexpected file: %+v", record.Files)
+	}
+
+	if len(record.MAP) != 1 {
+		t.Fatalf("Expected one MAP command, got %d", len(record.MAP))
+	}
+	if app, ok := record.MAP[0].Get("app"); !ok || app != "bsv-go" {
+		t.Errorf("Expected app=bsv-go, got %q", app)
+	}
+
+	if len(record.AIP) != 1 || !record.AIP[0].Valid || record.AIP[0].Address != address {
+		t.Fatalf("Expected a valid AIP signature by %s, got %+v", address, record.AIP)
+	}
+
+	// Changing a signed field invalidates the signature
+	script := tx.TxOut[0].PkScript
+	i := bytes.Index(script, []byte("# Hello"))
+	script[i] = '!'
+	record, err = bitcom.ParseScript(script)
+	if err != nil {
+		t.Fatalf("Failed to parse tampered script: %v", err)
+	}
+	if record.AIP[0].Valid {
+		t.Error("Expected AIP signature over tampered data to be invalid")
+	}
+}
+
+func TestBitcomMAPCommands(t *testing.T) {
+	commands := []*bitcom.MAP{
+		{Command: bitcom.MAPAdd, Key: "tags", Values: []string{"bsv", "go"}},
+		{Command: bitcom.MAPDelete, Pairs: []bitcom.KeyValue{{Key: "tags", Value: "go"}}},
+		{Command: "REMOVE", Args: [][]byte{[]byte("tags")}},
+		{Command: "CLEAR"},
+	}
+
+	for _, command := range commands {
+		builder := bitcom.NewBuilder()
+		if err := builder.Add(command); err != nil {
+			t.Fatalf("Failed to add MAP %s: %v", command.Command, err)
+		}
+
+		records, err := bitcom.ParseTransaction(dataTx(t, builder.DataOutput()))
+		if err != nil || len(records) != 1 || len(records[0].MAP) != 1 {
+			t.Fatalf("MAP %s: expected one command: %v", command.Command, err)
+		}
+
+		parsed := records[0].MAP[0]
+		if parsed.Command != command.Command || parsed.Key != command.Key || len(parsed.Values) != len(command.Values) || len(parsed.Pairs) != len(command.Pairs) || len(parsed.Args) != len(command.Args) {
+			t.Errorf("MAP %s did not round trip: %+v", command.Command, parsed)
+		}
+	}
+
+	invalid := []*bitcom.MAP{
+		{Command: bitcom.MAPSet},
+		{Command: bitcom.MAPAdd, Key: "tags"},
+		{Pairs: []bitcom.KeyValue{{Key: "a", Value: "b"}}},
+	}
+	for _, command := range invalid {
+		if err := bitcom.NewBuilder().Add(command); err == nil {
+			t.Errorf("MAP %s: expected error", command.Command)
+		}
+	}
+
+	// An odd number of SET fields is rejected when parsing
+	output := &types.DataOutput{Pushes: []types.DataPush{{Text: bitcom.MAPPrefix}, {Text: "SET"}, {Text: "key"}}}
+	if _, err := bitcom.ParseTransaction(dataTx(t, output)); err == nil {
+		t.Error("Expected error parsing MAP SET without a value")
+	}
+}
+
+func TestBitcomBCATMultiPart(t *testing.T) {
+	file := bytes.Repeat([]byte("0123456789"), 250)
+
+	outputs, err := bitcom.SplitBCAT(file, 1000)
+	if err != nil || len(outputs) != 3 {
+		t.Fatalf("Expected three parts, got %d: %v", len(outputs), err)
+	}
+
+	var parts []*wire.MsgTx
+	index := &bitcom.BCAT{Info: "bsv-go", MediaType: "text/plain", Filename: "digits.txt"}
+	for _, output := range outputs {
+		tx := dataTx(t, output)
+		// Distinct inputs give each part its own transaction ID
+		tx.TxIn[0].PreviousOutPoint.Index = uint32(len(parts))
+		parts = append(parts, tx)
+		index.Parts = append(index.Parts, tx.TxHash())
+	}
+
+	builder := bitcom.NewBuilder()
+	if err := builder.Add(index); err != nil {
+		t.Fatalf("Failed to add BCAT index: %v", err)
+	}
+
+	records, err := bitcom.ParseTransaction(dataTx(t, builder.DataOutput()))
+	if err != nil || len(records) != 1 || records[0].BCAT == nil {
+		t.Fatalf("Expected a BCAT index: %v", err)
+	}
+
+	parsed := records[0].BCAT
+	if parsed.MediaType != "text/plain" || parsed.Charset != "" || len(parsed.Parts) != 3 || parsed.Parts[2] != index.Parts[2] {
+		t.Errorf("BCAT index did not round trip: %+v", parsed)
+	}
+
+	// Parts may arrive in any order
+	assembled, err := bitcom.AssembleBCAT(parsed, []*wire.MsgTx{parts[2], parts[0], parts[1]})
+	if err != nil || !bytes.Equal(assembled, file) {
+		t.Fatalf("Failed to reassemble file: %v", err)
+	}
+
+	if _, err := bitcom.AssembleBCAT(parsed, parts[:2]); err == nil {
+		t.Error("Expected error with a missing part")
+	}
+}
+
+func TestSignMessage(t *testing.T) {
+	key, _ := p2pkhTestKey(t, 0x72)
+	other, _ := p2pkhTestKey(t, 0x73)
+	address, _ := key.Address()
+	otherAddress, _ := other.Address()
+	message := []byte("hello world")
+
+	signature, err := key.SignMessage(message)
+	if err != nil || len(signature) != 65 {
+		t.Fatalf("Expected a 65-byte compact signature, got %d bytes: %v", len(signature), err)
+	}
+
+	if !key.VerifySignature(message, signature) || other.VerifySignature(message, signature) {
+		t.Error("Expected the signature to verify only for the signing key")
+	}
+	if key.VerifySignature([]byte("hello world!"), signature) {
+		t.Error("Expected the signature to fail for another message")
+	}
+
+	encoded, _ := key.SignMessageBase64(message)
+	if err := wallet.VerifyMessage(address, message, encoded); err != nil {
+		t.Errorf("Expected signature to verify against %s: %v", address, err)
+	}
+	if err := wallet.VerifyMessage(otherAddress, message, encoded); err == nil {
+		t.Error("Expected error verifying against another address")
+	}
+}
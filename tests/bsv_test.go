@@ -174,7 +174,7 @@ func TestEnhancedWalletGeneration(t *testing.T) {
 		t.Error("Wallet address is empty")
 	}
 
-	if wallet.PrivateKey == "" {
+	if wallet.PrivateKey.IsEmpty() {
 		t.Error("Wallet private key is empty")
 	}
 
@@ -342,7 +342,7 @@ func TestEnhancedTransactionBuilding(t *testing.T) {
 		To:                    "mqVKYrNJSmJNQNnQpqNk5XnxSc4iXTJmkt", // BSV testnet address
 		Amount:                1000,
 		FeeRate:               5,
-		PrivateKey:            mnemonicPhrase,
+		PrivateKey:            types.NewSecret(mnemonicPhrase),
 		IncludeNativeUTXOs:    true,
 		IncludeNonNativeUTXOs: false,
 		TokenTransfers:        []*types.TokenTransfer{},
@@ -364,7 +364,7 @@ func TestEnhancedTransactionBuilding(t *testing.T) {
 		To:                    "mqVKYrNJSmJNQNnQpqNk5XnxSc4iXTJmkt",
 		Amount:                1000,
 		FeeRate:               5,
-		PrivateKey:            mnemonicPhrase,
+		PrivateKey:            types.NewSecret(mnemonicPhrase),
 		IncludeNativeUTXOs:    true,
 		IncludeNonNativeUTXOs: true,
 		TokenTransfers: []*types.TokenTransfer{
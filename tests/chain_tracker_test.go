@@ -0,0 +1,275 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/spv"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/utxo"
+)
+
+// mineRegtestHeaders builds headers extending prev with regtest proof-of-work
+func mineRegtestHeaders(t *testing.T, prev *wire.BlockHeader, count int) []*wire.BlockHeader {
+	t.Helper()
+
+	return mineRegtestBranch(t, prev, count, 0)
+}
+
+// mineRegtestBranch builds headers like mineRegtestHeaders, with a branch byte
+// in their merkle roots so that different branches get different hashes
+func mineRegtestBranch(t *testing.T, prev *wire.BlockHeader, count int, branch byte) []*wire.BlockHeader {
+	t.Helper()
+
+	var headers []*wire.BlockHeader
+	for i := 0; i < count; i++ {
+		header := &wire.BlockHeader{
+			Version:    1,
+			PrevBlock:  prev.BlockHash(),
+			MerkleRoot: chainhash.Hash{byte(i + 1), branch},
+			Timestamp:  prev.Timestamp.Add(10 * time.Minute),
+			Bits:       chaincfg.RegressionNetParams.PowLimitBits,
+		}
+
+		for spv.CheckProofOfWork(header, &chaincfg.RegressionNetParams) != nil {
+			header.Nonce++
+		}
+
+		headers = append(headers, header)
+		prev = header
+	}
+
+	return headers
+}
+
+func TestMemoryHeaderStoreCheckpoint(t *testing.T) {
+	header := block100000Header(t)
+
+	store, err := spv.NewMemoryHeaderStore(&chaincfg.MainNetParams, 100000, header)
+	if err != nil {
+		t.Fatalf("Failed to create header store: %v", err)
+	}
+
+	valid, err := store.IsValidRootForHeight(&header.MerkleRoot, 100000)
+	if err != nil || !valid {
+		t.Errorf("Expected block 100000 merkle root to be valid: %v", err)
+	}
+
+	if valid, _ := store.IsValidRootForHeight(&header.PrevBlock, 100000); valid {
+		t.Error("Expected a different root to be invalid")
+	}
+
+	if _, err := store.IsValidRootForHeight(&header.MerkleRoot, 100001); err == nil {
+		t.Error("Expected error for a height beyond the tip")
+	}
+
+	// Changing the nonce breaks the proof-of-work
+	tampered := *header
+	tampered.Nonce++
+	if _, err := spv.NewMemoryHeaderStore(&chaincfg.MainNetParams, 100000, &tampered); err == nil {
+		t.Error("Expected error for a checkpoint without valid proof-of-work")
+	}
+}
+
+func TestMemoryHeaderStoreLinkage(t *testing.T) {
+	genesis := chaincfg.RegressionNetParams.GenesisBlock.Header
+
+	store, err := spv.NewMemoryHeaderStore(&chaincfg.RegressionNetParams, 0, &genesis)
+	if err != nil {
+		t.Fatalf("Failed to create header store: %v", err)
+	}
+
+	headers := mineRegtestHeaders(t, &genesis, 3)
+	if err := store.AddHeaders(headers); err != nil {
+		t.Fatalf("Failed to add headers: %v", err)
+	}
+
+	if store.TipHeight() != 3 {
+		t.Errorf("Expected tip height 3, got %d", store.TipHeight())
+	}
+
+	// A header whose parent is unknown
+	orphan := mineRegtestHeaders(t, &genesis, 2)[1]
+	orphan.PrevBlock = chainhash.Hash{0xff}
+	if err := store.AddHeader(orphan); err == nil {
+		t.Error("Expected error for a header that does not link to a known header")
+	}
+
+	// A header with bits other than its parent's, which regtest never retargets
+	retarget := mineRegtestBranch(t, headers[2], 1, 1)[0]
+	retarget.Bits = 0x1f00ffff
+	for spv.CheckProofOfWork(retarget, &chaincfg.RegressionNetParams) != nil {
+		retarget.Nonce++
+	}
+	if err := store.AddHeader(retarget); err == nil {
+		t.Error("Expected error for a header with unexpected bits")
+	}
+
+	// A shorter fork is stored without moving the tip
+	fork := mineRegtestBranch(t, &genesis, 4, 2)
+	if err := store.AddHeaders(fork[:2]); err != nil {
+		t.Fatalf("Failed to add fork headers: %v", err)
+	}
+	if header, _ := store.Header(2); header.BlockHash() != headers[1].BlockHash() {
+		t.Error("Expected a shorter fork not to replace the best chain")
+	}
+
+	// Extending the fork past the tip reorganizes onto it
+	if err := store.AddHeaders(fork[2:]); err != nil {
+		t.Fatalf("Failed to add fork headers: %v", err)
+	}
+	if store.TipHeight() != 4 {
+		t.Errorf("Expected tip height 4 after the reorg, got %d", store.TipHeight())
+	}
+	if valid, _ := store.IsValidRootForHeight(&headers[1].MerkleRoot, 2); valid {
+		t.Error("Expected a root from the abandoned branch to be invalid")
+	}
+
+	// A header whose bits exceed the regtest proof-of-work limit
+	easy := mineRegtestHeaders(t, fork[3], 1)[0]
+	easy.Bits = 0x2100ffff
+	if err := store.AddHeader(easy); err == nil {
+		t.Error("Expected error for a target above the proof-of-work limit")
+	}
+
+	valid, err := store.IsValidRootForHeight(&fork[1].MerkleRoot, 2)
+	if err != nil || !valid {
+		t.Errorf("Expected root at height 2 to be valid: %v", err)
+	}
+}
+
+func TestFileHeaderStore(t *testing.T) {
+	genesis := chaincfg.RegressionNetParams.GenesisBlock.Header
+	path := filepath.Join(t.TempDir(), "headers.dat")
+
+	store, err := spv.NewFileHeaderStore(path, &chaincfg.RegressionNetParams, 0, &genesis)
+	if err != nil {
+		t.Fatalf("Failed to create file header store: %v", err)
+	}
+
+	headers := mineRegtestHeaders(t, &genesis, 2)
+
+	// Headers that cannot be written are not kept, so a retry persists them
+	if err := os.Rename(path, path+".moved"); err != nil {
+		t.Fatalf("Failed to move header file: %v", err)
+	}
+	if err := store.AddHeaders(headers); err == nil {
+		t.Fatal("Expected error when the header file cannot be written")
+	}
+	if store.TipHeight() != 0 {
+		t.Errorf("Expected tip height 0 after a failed write, got %d", store.TipHeight())
+	}
+	if err := os.Rename(path+".moved", path); err != nil {
+		t.Fatalf("Failed to restore header file: %v", err)
+	}
+
+	if err := store.AddHeaders(headers); err != nil {
+		t.Fatalf("Failed to add headers: %v", err)
+	}
+
+	reopened, err := spv.NewFileHeaderStore(path, &chaincfg.RegressionNetParams, 0, &genesis)
+	if err != nil {
+		t.Fatalf("Failed to reopen file header store: %v", err)
+	}
+
+	if reopened.TipHeight() != 2 {
+		t.Errorf("Expected tip height 2 after reopening, got %d", reopened.TipHeight())
+	}
+
+	valid, err := reopened.IsValidRootForHeight(&headers[1].MerkleRoot, 2)
+	if err != nil || !valid {
+		t.Errorf("Expected persisted root to be valid: %v", err)
+	}
+
+	if _, err := spv.NewFileHeaderStore(path, &chaincfg.RegressionNetParams, 1, headers[0]); err == nil {
+		t.Error("Expected error opening the file with a different checkpoint")
+	}
+}
+
+func TestHeadersClient(t *testing.T) {
+	header := block100000Header(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/chain/merkleroot/verify" {
+			http.NotFound(w, r)
+			return
+		}
+
+		var roots []struct {
+			MerkleRoot  string `json:"merkleRoot"`
+			BlockHeight uint32 `json:"blockHeight"`
+		}
+		json.NewDecoder(r.Body).Decode(&roots)
+
+		state := "INVALID"
+		if len(roots) == 1 && roots[0].BlockHeight == 100000 && roots[0].MerkleRoot == header.MerkleRoot.String() {
+			state = "CONFIRMED"
+		} else if len(roots) == 1 && roots[0].BlockHeight > 100000 {
+			state = "UNABLE_TO_VERIFY"
+		}
+		fmt.Fprintf(w, `{"confirmationState":"%s"}`, state)
+	}))
+	defer server.Close()
+
+	client := spv.NewHeadersClient(server.URL)
+
+	valid, err := client.IsValidRootForHeight(&header.MerkleRoot, 100000)
+	if err != nil || !valid {
+		t.Errorf("Expected root to be confirmed: %v", err)
+	}
+
+	if valid, err := client.IsValidRootForHeight(&header.PrevBlock, 100000); err != nil || valid {
+		t.Errorf("Expected root to be invalid, got %v, %v", valid, err)
+	}
+
+	if _, err := client.IsValidRootForHeight(&header.MerkleRoot, 200000); err == nil {
+		t.Error("Expected error when the service cannot verify")
+	}
+}
+
+// staticMerklePaths serves merkle paths by txid
+type staticMerklePaths map[string]*spv.MerklePath
+
+func (s staticMerklePaths) GetMerklePath(txid string) (*spv.MerklePath, error) {
+	if path, ok := s[txid]; ok {
+		return path, nil
+	}
+	return nil, fmt.Errorf("no merkle path for %s", txid)
+}
+
+func TestUTXOManagerMarksSPVVerified(t *testing.T) {
+	node := newMockNode(t)
+	wallet, _ := newTestWallet(t)
+
+	txids := block100000TxHashes(t)
+	node.addUTXO(wallet.Address, block100000TxIDs[1], 0, 5000, "")
+	node.addUTXO(wallet.Address, "e1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90", 0, 7000, "")
+
+	path, _ := spv.NewMerklePath(100000, txids, 1)
+	header := block100000Header(t)
+	store, _ := spv.NewMemoryHeaderStore(&chaincfg.MainNetParams, 100000, header)
+
+	manager := utxo.NewManager(node.configManager(t))
+	manager.SetSPVVerifier(store, staticMerklePaths{block100000TxIDs[1]: path})
+
+	utxos, err := manager.GetUTXOs(wallet.Address)
+	if err != nil {
+		t.Fatalf("Failed to get UTXOs: %v", err)
+	}
+
+	for _, u := range utxos {
+		expected := u.TxID == block100000TxIDs[1]
+		if u.SPVVerified != expected {
+			t.Errorf("UTXO %s: expected SPVVerified %v", u.TxID, expected)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/utxo"
+	"github.com/muhammadamman/BSV-Go/pkg/config"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+func testUTXO(address string, index int, value int64, height int) types.UTXO {
+	return types.UTXO{
+		TxID:          fmt.Sprintf("%064x", index),
+		Vout:          uint32(index),
+		Value:         value,
+		Address:       address,
+		Confirmations: 1,
+		Height:        height,
+		IsNative:      true,
+	}
+}
+
+func totalValue(utxos []types.UTXO) int64 {
+	var total int64
+	for _, u := range utxos {
+		total += u.Value
+	}
+	return total
+}
+
+func TestCoinSelectionStrategies(t *testing.T) {
+	utxos := []types.UTXO{
+		testUTXO("addr1", 0, 5000, 300),
+		testUTXO("addr1", 1, 20000, 100),
+		testUTXO("addr2", 2, 1000, 200),
+		testUTXO("addr2", 3, 50000, 0),
+	}
+	target := utxo.NewSelectionTarget(4000, types.SatoshisPerByte(1), 546)
+
+	tests := []struct {
+		strategy config.CoinSelectionStrategy
+		first    int64
+	}{
+		{config.CoinSelectionLargestFirst, 50000},
+		{config.CoinSelectionSmallestFirst, 1000},
+		{config.CoinSelectionOldestFirst, 20000},
+	}
+
+	for _, tt := range tests {
+		selector, err := utxo.NewCoinSelector(tt.strategy)
+		if err != nil {
+			t.Fatalf("Failed to create %s selector: %v", tt.strategy, err)
+		}
+
+		selected, fee, err := selector.Select(utxos, target)
+		if err != nil {
+			t.Fatalf("%s selection failed: %v", tt.strategy, err)
+		}
+
+		if selected[0].Value != tt.first {
+			t.Errorf("%s: expected first input %d, got %d", tt.strategy, tt.first, selected[0].Value)
+		}
+
+		if totalValue(selected) < target.Amount+fee {
+			t.Errorf("%s: selected %d does not cover %d", tt.strategy, totalValue(selected), target.Amount+fee)
+		}
+	}
+
+	if _, err := utxo.NewCoinSelector("unknown"); err == nil {
+		t.Error("Expected error for unknown strategy")
+	}
+}
+
+func TestBranchAndBoundAvoidsChange(t *testing.T) {
+	utxos := []types.UTXO{
+		testUTXO("addr1", 0, 100000, 1),
+		testUTXO("addr1", 1, 30000, 1),
+		testUTXO("addr1", 2, 12000, 1),
+		testUTXO("addr1", 3, 8340, 1),
+	}
+	target := utxo.NewSelectionTarget(20000, types.SatoshisPerByte(1), 546)
+
+	selector, _ := utxo.NewCoinSelector(config.CoinSelectionBranchAndBound)
+	selected, fee, err := selector.Select(utxos, target)
+	if err != nil {
+		t.Fatalf("Branch-and-bound selection failed: %v", err)
+	}
+
+	// 12000 + 8340 pays 20000 plus the no-change fee for two inputs exactly
+	if len(selected) != 2 {
+		t.Fatalf("Expected 2 inputs, got %d", len(selected))
+	}
+
+	if change := totalValue(selected) - target.Amount - fee; change != 0 {
+		t.Errorf("Expected exact match without change, got %d left over", change)
+	}
+
+	if fee != target.Fee(2, false) {
+		t.Errorf("Expected no-change fee %d, got %d", target.Fee(2, false), fee)
+	}
+}
+
+func TestBranchAndBoundFeeCoversFinalSize(t *testing.T) {
+	node := newMockNode(t)
+	sender, mnemonicPhrase := newTestWallet(t)
+	recipient, _ := newTestWallet(t)
+
+	// 12000 + 9000 leaves 660 over the no-change fee of 340: more than dust,
+	// so the transaction gets change and must pay for it
+	node.addUTXO(sender.Address, fmt.Sprintf("%064x", 1), 0, 12000, "")
+	node.addUTXO(sender.Address, fmt.Sprintf("%064x", 2), 0, 9000, "")
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	tx, err := builder.BuildTransaction(&types.TransactionParams{
+		From:                  sender.Address,
+		To:                    recipient.Address,
+		Amount:                20000,
+		FeeRate:               1,
+		PrivateKey:            types.NewSecret(mnemonicPhrase),
+		CoinSelectionStrategy: string(config.CoinSelectionBranchAndBound),
+	})
+	if err != nil {
+		t.Fatalf("Failed to build transaction: %v", err)
+	}
+
+	var totalOut int64
+	for _, txOut := range tx.TxOut {
+		totalOut += txOut.Value
+	}
+	if fee, size := 21000-totalOut, int64(tx.SerializeSize()); fee < size {
+		t.Errorf("Fee %d does not pay for %d bytes with %d outputs", fee, size, len(tx.TxOut))
+	}
+}
+
+func TestRandomImproveIsDeterministicWithSeed(t *testing.T) {
+	utxos := make([]types.UTXO, 100)
+	for i := range utxos {
+		utxos[i] = testUTXO("addr1", i, int64(1000+i*100), i)
+	}
+	target := utxo.NewSelectionTarget(10000, types.SatoshisPerByte(1), 546)
+
+	first, _, err := utxo.NewRandomImproveSelector(rand.New(rand.NewSource(42))).Select(utxos, target)
+	if err != nil {
+		t.Fatalf("Random-improve selection failed: %v", err)
+	}
+
+	second, fee, err := utxo.NewRandomImproveSelector(rand.New(rand.NewSource(42))).Select(utxos, target)
+	if err != nil {
+		t.Fatalf("Random-improve selection failed: %v", err)
+	}
+
+	if len(first) != len(second) || totalValue(first) != totalValue(second) {
+		t.Error("Expected identical selections for identical seeds")
+	}
+
+	if totalValue(second) < target.Amount+fee {
+		t.Errorf("Selected %d does not cover %d", totalValue(second), target.Amount+fee)
+	}
+}
+
+func TestPrivacySelectionDoesNotMixAddresses(t *testing.T) {
+	utxos := []types.UTXO{
+		testUTXO("addr1", 0, 6000, 1),
+		testUTXO("addr2", 1, 7000, 1),
+		testUTXO("addr2", 2, 7000, 1),
+	}
+
+	selector, _ := utxo.NewCoinSelector(config.CoinSelectionPrivacy)
+	selected, _, err := selector.Select(utxos, utxo.NewSelectionTarget(10000, types.SatoshisPerByte(1), 546))
+	if err != nil {
+		t.Fatalf("Privacy selection failed: %v", err)
+	}
+
+	for _, u := range selected {
+		if u.Address != "addr2" {
+			t.Errorf("Expected only addr2 inputs, got %s", u.Address)
+		}
+	}
+
+	_, _, err = selector.Select(utxos, utxo.NewSelectionTarget(15000, types.SatoshisPerByte(1), 546))
+	if !errors.Is(err, types.ErrInsufficientFunds) {
+		t.Errorf("Expected insufficient funds when no single address can pay, got %v", err)
+	}
+}
+
+func TestCoinSelectionStrategyConfig(t *testing.T) {
+	configManager := config.NewManager()
+
+	txConfig := configManager.GetTransactionConfig()
+	if txConfig.CoinSelectionStrategy != config.CoinSelectionLargestFirst {
+		t.Errorf("Expected default strategy largest-first, got %s", txConfig.CoinSelectionStrategy)
+	}
+
+	txConfig.CoinSelectionStrategy = config.CoinSelectionBranchAndBound
+	if err := configManager.UpdateTransactionConfig(txConfig); err != nil {
+		t.Fatalf("Failed to update strategy: %v", err)
+	}
+
+	txConfig.CoinSelectionStrategy = "unknown"
+	if err := configManager.UpdateTransactionConfig(txConfig); err == nil {
+		t.Error("Expected error for unknown strategy")
+	}
+}
+
+// benchmarkWallet returns a 10k-UTXO wallet spread over 50 addresses
+func benchmarkWallet() []types.UTXO {
+	rng := rand.New(rand.NewSource(1))
+	utxos := make([]types.UTXO, 10000)
+	for i := range utxos {
+		utxos[i] = testUTXO(fmt.Sprintf("addr%d", i%50), i, 546+rng.Int63n(1000000), 700000+rng.Intn(100000))
+	}
+	return utxos
+}
+
+func benchmarkSelector(b *testing.B, strategy config.CoinSelectionStrategy) {
+	utxos := benchmarkWallet()
+	target := utxo.NewSelectionTarget(25000000, types.SatoshisPerByte(1), 546)
+
+	selector, err := utxo.NewCoinSelector(strategy)
+	if err != nil {
+		b.Fatalf("Failed to create selector: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := selector.Select(utxos, target); err != nil {
+			b.Fatalf("Selection failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkLargestFirst10k(b *testing.B) {
+	benchmarkSelector(b, config.CoinSelectionLargestFirst)
+}
+
+func BenchmarkSmallestFirst10k(b *testing.B) {
+	benchmarkSelector(b, config.CoinSelectionSmallestFirst)
+}
+
+func BenchmarkOldestFirst10k(b *testing.B) {
+	benchmarkSelector(b, config.CoinSelectionOldestFirst)
+}
+
+func BenchmarkBranchAndBound10k(b *testing.B) {
+	benchmarkSelector(b, config.CoinSelectionBranchAndBound)
+}
+
+func BenchmarkRandomImprove10k(b *testing.B) {
+	benchmarkSelector(b, config.CoinSelectionRandomImprove)
+}
+
+func BenchmarkPrivacy10k(b *testing.B) {
+	benchmarkSelector(b, config.CoinSelectionPrivacy)
+}
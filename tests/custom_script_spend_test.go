@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+func TestSendSpendsP2PKUTXO(t *testing.T) {
+	node := newMockNode(t)
+	_, mnemonicPhrase := newTestWallet(t)
+	to, _ := newTestWallet(t)
+
+	from, keyPair, err := wallet.GenerateWalletWithKeypair(mnemonicPhrase, true)
+	if err != nil {
+		t.Fatalf("Failed to generate wallet: %v", err)
+	}
+
+	lockingScript, _ := transaction.NewP2PKTemplate(keyPair).Lock()
+	node.addUTXO(from.Address, "a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90", 0, 30000, hex.EncodeToString(lockingScript))
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	_, err = builder.SignAndSendTransaction(&types.TransactionParams{
+		From:       from.Address,
+		To:         to.Address,
+		Amount:     10000,
+		FeeRate:    1,
+		PrivateKey: types.NewSecret(mnemonicPhrase),
+	})
+	if err != nil {
+		t.Fatalf("Failed to send from a P2PK UTXO: %v", err)
+	}
+
+	if len(node.broadcast) != 1 {
+		t.Fatalf("Expected one broadcast, got %d", len(node.broadcast))
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(node.broadcast[0])); err != nil {
+		t.Fatalf("Failed to decode broadcast transaction: %v", err)
+	}
+
+	// A P2PK unlocking script is a lone signature push
+	unlockingScript := tx.TxIn[0].SignatureScript
+	if int(unlockingScript[0]) != len(unlockingScript)-1 {
+		t.Errorf("Expected a single signature push, got %x", unlockingScript)
+	}
+}
+
+func TestSendRequiresUnlockerForCustomScript(t *testing.T) {
+	node := newMockNode(t)
+	from, mnemonicPhrase := newTestWallet(t)
+	to, _ := newTestWallet(t)
+
+	puzzle := transaction.NewHashPuzzleTemplate([]byte("treasure"))
+	lockingScript, _ := puzzle.Lock()
+	node.addUTXO(from.Address, "b1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90", 1, 30000, hex.EncodeToString(lockingScript))
+
+	params := &types.TransactionParams{
+		From:       from.Address,
+		To:         to.Address,
+		Amount:     10000,
+		FeeRate:    1,
+		PrivateKey: types.NewSecret(mnemonicPhrase),
+	}
+
+	// Unknown scripts are only selected when non-native UTXOs are allowed
+	configManager := node.configManager(t)
+	builder := transaction.NewBuilder(configManager)
+	if _, err := builder.SignAndSendTransaction(params); err == nil {
+		t.Fatal("Expected the custom script to be excluded without non-native UTXOs")
+	}
+
+	txConfig := configManager.GetTransactionConfig()
+	txConfig.IncludeNonNativeUTXOs = true
+	if err := configManager.UpdateTransactionConfig(txConfig); err != nil {
+		t.Fatalf("Failed to update transaction config: %v", err)
+	}
+
+	builder = transaction.NewBuilder(configManager)
+	if _, err := builder.SignAndSendTransaction(params); !errors.Is(err, types.ErrNoUnlocker) {
+		t.Fatalf("Expected ErrNoUnlocker, got %v", err)
+	}
+
+	if len(node.broadcast) != 0 {
+		t.Fatalf("Expected nothing to be broadcast, got %d transactions", len(node.broadcast))
+	}
+
+	builder.AddUnlocker(puzzle)
+	if _, err := builder.SignAndSendTransaction(params); err != nil {
+		t.Fatalf("Failed to send with a registered unlocker: %v", err)
+	}
+}
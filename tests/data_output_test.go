@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+func TestDataOutputScriptPushes(t *testing.T) {
+	script, err := transaction.DataOutputScript(&types.DataOutput{
+		Data: "cafe",
+		Pushes: []types.DataPush{
+			{Text: "héllo"},
+			{Bytes: []byte{0x01, 0x02}},
+			{},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create data output script: %v", err)
+	}
+
+	expected := []byte{txscript.OP_FALSE, txscript.OP_RETURN, 0x02, 0xca, 0xfe, 0x06}
+	expected = append(expected, []byte("héllo")...)
+	expected = append(expected, 0x02, 0x01, 0x02, txscript.OP_0)
+	if !bytes.Equal(script, expected) {
+		t.Errorf("Expected %x, got %x", expected, script)
+	}
+
+	invalid := []*types.DataOutput{
+		{},
+		{Data: "zz"},
+		{Pushes: []types.DataPush{{Hex: "00", Text: "a"}}},
+		{Pushes: []types.DataPush{{Text: string([]byte{0xff, 0xfe})}}},
+	}
+	for i, output := range invalid {
+		if _, err := transaction.DataOutputScript(output); err == nil {
+			t.Errorf("Invalid output %d: expected error", i)
+		}
+	}
+}
+
+func TestDataOutputLargePayload(t *testing.T) {
+	payload := bytes.Repeat([]byte{0x5a}, 1<<20)
+
+	script, err := transaction.DataOutputScript(&types.DataOutput{Pushes: []types.DataPush{{Bytes: payload}}})
+	if err != nil {
+		t.Fatalf("Failed to create data output script: %v", err)
+	}
+
+	if script[2] != txscript.OP_PUSHDATA4 || binary.LittleEndian.Uint32(script[3:7]) != uint32(len(payload)) {
+		t.Fatalf("Expected OP_PUSHDATA4 with length %d, got %x", len(payload), script[2:7])
+	}
+
+	node := newMockNode(t)
+	from, mnemonicPhrase := newTestWallet(t)
+	to, _ := newTestWallet(t)
+	node.addUTXO(from.Address, "e1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90", 0, 5000000, "")
+
+	configManager := node.configManager(t)
+	txConfig := configManager.GetTransactionConfig()
+	txConfig.MaxTransactionSize = 2 << 20
+	if err := configManager.UpdateTransactionConfig(txConfig); err != nil {
+		t.Fatalf("Failed to update transaction config: %v", err)
+	}
+
+	builder := transaction.NewBuilder(configManager)
+	result, err := builder.SignAndSendTransaction(&types.TransactionParams{
+		From:        from.Address,
+		To:          to.Address,
+		Amount:      10000,
+		FeeRate:     1,
+		PrivateKey:  types.NewSecret(mnemonicPhrase),
+		DataOutputs: []*types.DataOutput{{Pushes: []types.DataPush{{Text: "upload"}, {Hex: hex.EncodeToString(payload)}}}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to send data transaction: %v", err)
+	}
+
+	// The fee pays for the payload at the requested rate
+	size := len(result.SignedTx) / 2
+	if result.Fee < int64(size) {
+		t.Errorf("Fee %d is below 1 sat/byte for %d bytes", result.Fee, size)
+	}
+
+	if result.Change != result.OutputsCreated[2].Amount || result.Fee != 5000000-10000-result.Change {
+		t.Errorf("Expected change %d and the rest as fee, got change %d and fee %d", result.OutputsCreated[2].Amount, result.Change, result.Fee)
+	}
+
+	if !result.OutputsCreated[1].IsData || result.OutputsCreated[0].IsData {
+		t.Error("Expected only the data output to be flagged as data")
+	}
+}
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+func TestExtendedFormatRoundTrip(t *testing.T) {
+	hash, _ := chainhash.NewHashFromStr("c1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90")
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(hash, 3), []byte{0x51}, nil))
+	tx.AddTxOut(wire.NewTxOut(900, []byte{0x76, 0xa9}))
+	tx.LockTime = 12345
+
+	prevOuts := []*wire.TxOut{wire.NewTxOut(1000, []byte{0x76, 0xa9, 0x14})}
+
+	ef, err := transaction.EncodeExtendedFormat(tx, prevOuts)
+	if err != nil {
+		t.Fatalf("Failed to encode extended format: %v", err)
+	}
+
+	if !transaction.IsExtendedFormat(ef) {
+		t.Fatal("Encoded transaction is not recognised as extended format")
+	}
+
+	var raw bytes.Buffer
+	tx.Serialize(&raw)
+	if transaction.IsExtendedFormat(raw.Bytes()) {
+		t.Error("Raw transaction recognised as extended format")
+	}
+
+	// EF adds the 6-byte marker, 8-byte value and the prevout script to the raw size
+	if expected := raw.Len() + 6 + 8 + 1 + len(prevOuts[0].PkScript); len(ef) != expected {
+		t.Errorf("Expected %d bytes, got %d", expected, len(ef))
+	}
+
+	decoded, decodedPrevOuts, err := transaction.DecodeExtendedFormat(ef)
+	if err != nil {
+		t.Fatalf("Failed to decode extended format: %v", err)
+	}
+
+	if decoded.TxHash() != tx.TxHash() {
+		t.Error("Decoded transaction hash does not match")
+	}
+
+	if decodedPrevOuts[0].Value != 1000 || !bytes.Equal(decodedPrevOuts[0].PkScript, prevOuts[0].PkScript) {
+		t.Errorf("Unexpected prevout %+v", decodedPrevOuts[0])
+	}
+
+	if _, _, err := transaction.DecodeExtendedFormat(ef[:len(ef)-1]); err == nil {
+		t.Error("Expected error decoding truncated extended format")
+	}
+
+	if _, err := transaction.EncodeExtendedFormat(tx, nil); err == nil {
+		t.Error("Expected error encoding without prevouts")
+	}
+}
+
+func TestARCBroadcasterReceivesExtendedFormat(t *testing.T) {
+	node := newMockNode(t)
+
+	sender, mnemonicPhrase := newTestWallet(t)
+	recipient, _ := newTestWallet(t)
+
+	node.addUTXO(sender.Address, "d1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90", 0, 30000, "")
+
+	var received []byte
+	arc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/tx" {
+			http.NotFound(w, r)
+			return
+		}
+		received, _ = io.ReadAll(r.Body)
+		w.Write([]byte(`{"txStatus":"SEEN_ON_NETWORK"}`))
+	}))
+	defer arc.Close()
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	builder.SetBroadcaster(transaction.NewARCBroadcaster(arc.URL))
+
+	result, err := builder.SignAndSendTransaction(&types.TransactionParams{
+		From:       sender.Address,
+		To:         recipient.Address,
+		Amount:     5000,
+		FeeRate:    1,
+		PrivateKey: types.NewSecret(mnemonicPhrase),
+	})
+	if err != nil {
+		t.Fatalf("Failed to send transaction: %v", err)
+	}
+
+	if len(node.broadcast) != 0 {
+		t.Error("Expected nothing posted to the default broadcaster")
+	}
+
+	tx, prevOuts, err := transaction.DecodeExtendedFormat(received)
+	if err != nil {
+		t.Fatalf("ARC did not receive extended format: %v", err)
+	}
+
+	if tx.TxHash().String() != result.TxID {
+		t.Errorf("Expected %s, got %s", result.TxID, tx.TxHash().String())
+	}
+
+	if prevOuts[0].Value != 30000 || len(prevOuts[0].PkScript) != 25 {
+		t.Errorf("Unexpected prevout %+v", prevOuts[0])
+	}
+}
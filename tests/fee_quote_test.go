@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/utxo"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+func TestFeeRateRounding(t *testing.T) {
+	rate := transaction.FeeRate{Satoshis: 1, Bytes: 1000}
+
+	if got := rate.FeeForSize(250); got != 1 {
+		t.Errorf("Expected fee 1 for 250 bytes at 1 sat/kB, got %d", got)
+	}
+
+	if got := rate.FeeForSize(2001); got != 3 {
+		t.Errorf("Expected fee 3 for 2001 bytes at 1 sat/kB, got %d", got)
+	}
+}
+
+func TestStaticFeeQuoteProvider(t *testing.T) {
+	provider := transaction.NewStaticFeeQuoteProvider(7)
+
+	quote, err := provider.GetFeeQuote()
+	if err != nil {
+		t.Fatalf("Failed to get static fee quote: %v", err)
+	}
+
+	if quote.Standard != types.SatoshisPerByte(7) || quote.DataRate() != types.SatoshisPerByte(7) {
+		t.Errorf("Expected 7 sat/byte for standard and data, got %+v and %+v", quote.Standard, quote.DataRate())
+	}
+
+	if quote.IsExpired() {
+		t.Error("Static quote should never expire")
+	}
+}
+
+func TestARCFeeQuoteProvider(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path != "/v1/policy" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"policy":{"maxscriptsizepolicy":100000000,"maxtxsizepolicy":100000000,"miningFee":{"bytes":1000,"satoshis":50}},"timestamp":"2024-01-01T00:00:00Z"}`)
+	}))
+	defer server.Close()
+
+	provider := transaction.NewARCFeeQuoteProvider(server.URL)
+	provider.SetAPIKey("test-key")
+
+	quote, err := provider.GetFeeQuote()
+	if err != nil {
+		t.Fatalf("Failed to get ARC fee quote: %v", err)
+	}
+
+	if quote.Standard.Satoshis != 50 || quote.Standard.Bytes != 1000 {
+		t.Errorf("Unexpected standard rate: %+v", quote.Standard)
+	}
+
+	if quote.Data != quote.Standard {
+		t.Errorf("Expected data rate to match ARC mining fee, got %+v", quote.Data)
+	}
+
+	// Second call must be served from the cache
+	if _, err := provider.GetFeeQuote(); err != nil {
+		t.Fatalf("Failed to get cached ARC fee quote: %v", err)
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("Expected 1 policy request, got %d", got)
+	}
+}
+
+func TestMAPIFeeQuoteProvider(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	payload := fmt.Sprintf(`{\"apiVersion\":\"1.4.0\",\"expiryTime\":\"%s\",\"fees\":[{\"feeType\":\"standard\",\"miningFee\":{\"satoshis\":3,\"bytes\":1},\"relayFee\":{\"satoshis\":1,\"bytes\":1}},{\"feeType\":\"data\",\"miningFee\":{\"satoshis\":1,\"bytes\":2},\"relayFee\":{\"satoshis\":1,\"bytes\":4}}]}`, expiry)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/mapi/feeQuote" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"payload":"%s","signature":null,"publicKey":null,"encoding":"UTF-8","mimetype":"application/json"}`, payload)
+	}))
+	defer server.Close()
+
+	quote, err := transaction.NewMAPIFeeQuoteProvider(server.URL).GetFeeQuote()
+	if err != nil {
+		t.Fatalf("Failed to get mAPI fee quote: %v", err)
+	}
+
+	if got := quote.Standard.FeeForSize(1000); got != 3000 {
+		t.Errorf("Expected standard rate 3 sat/byte, got %d for 1000 bytes", got)
+	}
+
+	if quote.Data.Satoshis != 1 || quote.Data.Bytes != 2 {
+		t.Errorf("Unexpected data rate: %+v", quote.Data)
+	}
+
+	if quote.Expiry.Format(time.RFC3339) != expiry {
+		t.Errorf("Expected expiry %s, got %s", expiry, quote.Expiry.Format(time.RFC3339))
+	}
+}
+
+func TestBuilderPicksQuotedFeeRate(t *testing.T) {
+	node := newMockNode(t)
+
+	sender, mnemonicPhrase := newTestWallet(t)
+	recipient, _ := newTestWallet(t)
+
+	node.addUTXO(sender.Address, "a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90", 0, 100000, "")
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	builder.SetFeeQuoteProvider(transaction.NewStaticFeeQuoteProvider(2))
+
+	params := &types.TransactionParams{
+		From:       sender.Address,
+		To:         recipient.Address,
+		Amount:     10000,
+		PrivateKey: types.NewSecret(mnemonicPhrase),
+	}
+
+	tx, err := builder.BuildTransaction(params)
+	if err != nil {
+		t.Fatalf("Failed to build transaction: %v", err)
+	}
+
+	var totalOut int64
+	for _, txOut := range tx.TxOut {
+		totalOut += txOut.Value
+	}
+
+	// One input, recipient and change outputs at 2 sat/byte
+	expectedFee := int64(10+148+34+34) * 2
+	if fee := 100000 - totalOut; fee != expectedFee {
+		t.Errorf("Expected fee %d, got %d", expectedFee, fee)
+	}
+}
+
+type fixedFeeQuoteProvider struct {
+	quote transaction.FeeQuote
+}
+
+func (p *fixedFeeQuoteProvider) GetFeeQuote() (*transaction.FeeQuote, error) {
+	quote := p.quote
+	return &quote, nil
+}
+
+func TestBuilderUsesFractionalFeeRate(t *testing.T) {
+	node := newMockNode(t)
+
+	sender, mnemonicPhrase := newTestWallet(t)
+	recipient, _ := newTestWallet(t)
+
+	node.addUTXO(sender.Address, "b1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90", 0, 100000, "")
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	builder.SetFeeQuoteProvider(&fixedFeeQuoteProvider{quote: transaction.FeeQuote{
+		Standard: transaction.FeeRate{Satoshis: 50, Bytes: 1000},
+	}})
+
+	tx, err := builder.BuildTransaction(&types.TransactionParams{
+		From:       sender.Address,
+		To:         recipient.Address,
+		Amount:     10000,
+		PrivateKey: types.NewSecret(mnemonicPhrase),
+	})
+	if err != nil {
+		t.Fatalf("Failed to build transaction: %v", err)
+	}
+
+	var totalOut int64
+	for _, txOut := range tx.TxOut {
+		totalOut += txOut.Value
+	}
+
+	// 226 bytes at 50 sat/kB, not rounded up to 1 sat/byte
+	expectedFee := int64(12)
+	if fee := 100000 - totalOut; fee != expectedFee {
+		t.Errorf("Expected fee %d, got %d", expectedFee, fee)
+	}
+}
+
+func TestSelectionTargetChargesDataRateOnDataBytes(t *testing.T) {
+	target := utxo.NewSelectionTarget(10000, types.SatoshisPerByte(2), 546)
+	target.DataFeeRate = types.FeeRate{Satoshis: 1, Bytes: 4}
+	target.DataSize = 400
+
+	// 600 standard bytes at 2 sat/byte and 400 data bytes at 0.25 sat/byte
+	if got := target.FeeForSize(1000); got != 1300 {
+		t.Errorf("Expected fee 1300, got %d", got)
+	}
+
+	target.DataSize = 0
+	if got := target.FeeForSize(1000); got != 2000 {
+		t.Errorf("Expected fee 2000 without data, got %d", got)
+	}
+}
@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// spentOutPoints returns the outpoints spent by a broadcast transaction
+func spentOutPoints(t *testing.T, raw []byte) map[string]bool {
+	t.Helper()
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		t.Fatalf("Failed to decode broadcast transaction: %v", err)
+	}
+
+	spent := make(map[string]bool)
+	for _, txIn := range tx.TxIn {
+		spent[txIn.PreviousOutPoint.String()] = true
+	}
+	return spent
+}
+
+func TestFundFromKeyringAddresses(t *testing.T) {
+	node := newMockNode(t)
+	from, mnemonicPhrase := newTestWallet(t)
+	to, _ := newTestWallet(t)
+
+	keyring := wallet.NewKeyring()
+	var funding []string
+	for i, seed := range []byte{0x51, 0x52} {
+		keyPair, _ := p2pkhTestKey(t, seed)
+		address, err := keyring.Add(keyPair)
+		if err != nil {
+			t.Fatalf("Failed to add key: %v", err)
+		}
+		funding = append(funding, address)
+		node.addUTXO(address, fmt.Sprintf("%064x", i+1), 0, 30000, "")
+	}
+	node.addUTXO(from.Address, fmt.Sprintf("%064x", 3), 0, 5000, "")
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	builder.SetKeyring(keyring)
+
+	params := &types.TransactionParams{
+		From:             from.Address,
+		To:               to.Address,
+		Amount:           60000,
+		FeeRate:          1,
+		PrivateKey:       types.NewSecret(mnemonicPhrase),
+		FundingAddresses: funding,
+	}
+	result, err := builder.SignAndSendTransaction(params)
+	if err != nil {
+		t.Fatalf("Failed to send from several addresses: %v", err)
+	}
+
+	// Every address contributes, each input signed by its own key
+	spent := spentOutPoints(t, node.broadcast[0])
+	if len(spent) != 3 || len(result.InputsUsed) != 3 {
+		t.Fatalf("Expected inputs from all three addresses, got %d", len(spent))
+	}
+	for i := 1; i <= 3; i++ {
+		if !spent[fmt.Sprintf("%064x:0", i)] {
+			t.Errorf("Expected UTXO %d to be spent", i)
+		}
+	}
+
+	// Keys missing from the keyring are reported before anything is built
+	builder.SetKeyring(wallet.NewKeyring())
+	if _, err := builder.BuildTransaction(params); err == nil {
+		t.Error("Expected error for a funding address without a key")
+	}
+
+	// Unsigned transactions do not need the keys
+	if _, err := builder.BuildUnsignedTransaction(params); err != nil {
+		t.Errorf("Failed to build unsigned transaction: %v", err)
+	}
+}
+
+func TestFundFromWIFKeys(t *testing.T) {
+	node := newMockNode(t)
+	from, mnemonicPhrase := newTestWallet(t)
+	to, _ := newTestWallet(t)
+
+	var wifs []types.Secret
+	for i, seed := range []byte{0x61, 0x62} {
+		keyPair, _ := p2pkhTestKey(t, seed)
+		wif, _ := btcutil.NewWIF(keyPair.PrivateKey, &chaincfg.TestNet3Params, true)
+		wifs = append(wifs, types.NewSecret(wif.String()))
+
+		address, _ := keyPair.Address()
+		node.addUTXO(address, fmt.Sprintf("%064x", i+1), 0, 20000, "")
+	}
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	if _, err := builder.SignAndSendTransaction(&types.TransactionParams{
+		From:        from.Address,
+		To:          to.Address,
+		Amount:      35000,
+		FeeRate:     1,
+		PrivateKey:  types.NewSecret(mnemonicPhrase),
+		FundingKeys: wifs,
+	}); err != nil {
+		t.Fatalf("Failed to send from WIF keys: %v", err)
+	}
+
+	if spent := spentOutPoints(t, node.broadcast[0]); len(spent) != 2 {
+		t.Errorf("Expected both WIF addresses to be spent, got %d inputs", len(spent))
+	}
+
+	if _, err := builder.BuildTransaction(&types.TransactionParams{
+		From:        from.Address,
+		To:          to.Address,
+		Amount:      1000,
+		PrivateKey:  types.NewSecret(mnemonicPhrase),
+		FundingKeys: []types.Secret{types.NewSecret("not-a-key")},
+	}); err == nil {
+		t.Error("Expected error for an invalid funding key")
+	}
+}
+
+func TestFundFromHDPaths(t *testing.T) {
+	node := newMockNode(t)
+	from, mnemonicPhrase := newTestWallet(t)
+	to, _ := newTestWallet(t)
+
+	account, _ := wallet.NewHDAccount(mnemonicPhrase, 0, true)
+	paths := []*types.HDPath{{Chain: wallet.ExternalChain, Index: 4}, {Chain: wallet.InternalChain, Index: 0}}
+	for i, path := range paths {
+		keyPair, _ := account.KeyPair(path.Chain, path.Index)
+		address, _ := keyPair.Address()
+		node.addUTXO(address, fmt.Sprintf("%064x", i+1), 0, 20000, "")
+	}
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	if _, err := builder.SignAndSendTransaction(&types.TransactionParams{
+		From:         from.Address,
+		To:           to.Address,
+		Amount:       35000,
+		FeeRate:      1,
+		PrivateKey:   types.NewSecret(mnemonicPhrase),
+		FundingPaths: paths,
+	}); err != nil {
+		t.Fatalf("Failed to send from HD paths: %v", err)
+	}
+
+	if spent := spentOutPoints(t, node.broadcast[0]); len(spent) != 2 {
+		t.Errorf("Expected both derived addresses to be spent, got %d inputs", len(spent))
+	}
+
+	// Paths need an HD sender to derive from
+	keyPair, _ := p2pkhTestKey(t, 0x63)
+	sender, _ := keyPair.Address()
+	wif, _ := btcutil.NewWIF(keyPair.PrivateKey, &chaincfg.TestNet3Params, true)
+	if _, err := builder.BuildTransaction(&types.TransactionParams{
+		From:         sender,
+		To:           to.Address,
+		Amount:       1000,
+		PrivateKey:   types.NewSecret(wif.String()),
+		FundingPaths: paths,
+	}); err == nil {
+		t.Error("Expected error for funding paths with a WIF sender")
+	}
+}
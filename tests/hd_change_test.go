@@ -0,0 +1,318 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/tyler-smith/go-bip32"
+	"github.com/tyler-smith/go-bip39"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// changeAddress returns the address paid by the last output of a broadcast
+// transaction
+func changeAddress(t *testing.T, raw []byte) string {
+	t.Helper()
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		t.Fatalf("Failed to decode broadcast transaction: %v", err)
+	}
+
+	_, addresses, _, err := txscript.ExtractPkScriptAddrs(tx.TxOut[len(tx.TxOut)-1].PkScript, &chaincfg.TestNet3Params)
+	if err != nil || len(addresses) != 1 {
+		t.Fatalf("Failed to decode change script: %v", err)
+	}
+	return addresses[0].EncodeAddress()
+}
+
+func TestHDChangeAddresses(t *testing.T) {
+	node := newMockNode(t)
+	from, mnemonicPhrase := newTestWallet(t)
+	to, _ := newTestWallet(t)
+	for i := 0; i < 3; i++ {
+		node.addUTXO(from.Address, fmt.Sprintf("%064x", i+1), 0, 50000, "")
+	}
+
+	account, err := wallet.NewHDAccount(mnemonicPhrase, 0, true)
+	if err != nil {
+		t.Fatalf("Failed to derive account: %v", err)
+	}
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	builder.SetChangeIndexStore(wallet.NewFileChangeIndexStore(filepath.Join(t.TempDir(), "change.json")))
+
+	// Each send pays change to the next address on the internal chain
+	for index := uint32(0); index < 2; index++ {
+		result, err := builder.SignAndSendTransaction(&types.TransactionParams{
+			From:       from.Address,
+			To:         to.Address,
+			Amount:     10000,
+			FeeRate:    1,
+			PrivateKey: types.NewSecret(mnemonicPhrase),
+		})
+		if err != nil {
+			t.Fatalf("Send %d: %v", index, err)
+		}
+
+		keyPair, _ := account.KeyPair(wallet.InternalChain, index)
+		expected, _ := keyPair.Address()
+		if address := changeAddress(t, node.broadcast[index]); address != expected {
+			t.Errorf("Send %d: expected change to %s, got %s", index, expected, address)
+		}
+
+		change := result.OutputsCreated[len(result.OutputsCreated)-1]
+		if path := fmt.Sprintf("m/44'/1'/0'/1/%d", index); change.DerivationPath != path || result.Change != change.Amount {
+			t.Errorf("Send %d: expected change at %s, got %q", index, path, change.DerivationPath)
+		}
+	}
+}
+
+func TestHDChangeFromExtendedKey(t *testing.T) {
+	node := newMockNode(t)
+	from, mnemonicPhrase := newTestWallet(t)
+	to, _ := newTestWallet(t)
+	node.addUTXO(from.Address, fmt.Sprintf("%064x", 1), 0, 50000, "")
+
+	masterKey, err := bip32.NewMasterKey(bip39.NewSeed(mnemonicPhrase, ""))
+	if err != nil {
+		t.Fatalf("Failed to create master key: %v", err)
+	}
+
+	store := wallet.NewMemoryChangeIndexStore()
+	account, _ := wallet.NewHDAccount(mnemonicPhrase, 0, true)
+	store.CommitChangeIndex(account.ID(), 0)
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	builder.SetChangeIndexStore(store)
+
+	// The extended key shares the mnemonic's account and index
+	result, err := builder.SignAndSendTransaction(&types.TransactionParams{
+		From:       from.Address,
+		To:         to.Address,
+		Amount:     10000,
+		FeeRate:    1,
+		PrivateKey: types.NewSecret(masterKey.B58Serialize()),
+	})
+	if err != nil {
+		t.Fatalf("Failed to send from extended key: %v", err)
+	}
+
+	keyPair, _ := account.KeyPair(wallet.InternalChain, 1)
+	expected, _ := keyPair.Address()
+	if address := changeAddress(t, node.broadcast[0]); address != expected {
+		t.Errorf("Expected change to %s, got %s", expected, address)
+	}
+	if path := result.OutputsCreated[len(result.OutputsCreated)-1].DerivationPath; path != "m/44'/1'/0'/1/1" {
+		t.Errorf("Unexpected change path %q", path)
+	}
+}
+
+func TestWIFChangeReturnsToSender(t *testing.T) {
+	node := newMockNode(t)
+	keyPair, _ := p2pkhTestKey(t, 0x45)
+	to, _ := newTestWallet(t)
+
+	from, _ := keyPair.Address()
+	wif, _ := btcutil.NewWIF(keyPair.PrivateKey, &chaincfg.TestNet3Params, true)
+	node.addUTXO(from, fmt.Sprintf("%064x", 1), 0, 50000, "")
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	result, err := builder.SignAndSendTransaction(&types.TransactionParams{
+		From:       from,
+		To:         to.Address,
+		Amount:     10000,
+		FeeRate:    1,
+		PrivateKey: types.NewSecret(wif.String()),
+	})
+	if err != nil {
+		t.Fatalf("Failed to send from WIF: %v", err)
+	}
+
+	if address := changeAddress(t, node.broadcast[0]); address != from || result.Change == 0 {
+		t.Errorf("Expected change back to %s, got %s", from, address)
+	}
+	if path := result.OutputsCreated[len(result.OutputsCreated)-1].DerivationPath; path != "" {
+		t.Errorf("Expected no change path, got %q", path)
+	}
+}
+
+// failingBroadcaster rejects every transaction
+type failingBroadcaster struct{}
+
+func (failingBroadcaster) Broadcast([]byte) error       { return errors.New("rejected") }
+func (failingBroadcaster) SupportsExtendedFormat() bool { return false }
+
+// lastOutputAddress returns the address paid by the last output of tx
+func lastOutputAddress(t *testing.T, tx *wire.MsgTx) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tx.Serialize(&buf)
+	return changeAddress(t, buf.Bytes())
+}
+
+func TestHDChangeIndexReservedPerBuild(t *testing.T) {
+	node := newMockNode(t)
+	from, mnemonicPhrase := newTestWallet(t)
+	to, _ := newTestWallet(t)
+	node.addUTXO(from.Address, fmt.Sprintf("%064x", 1), 0, 50000, "")
+
+	store := wallet.NewMemoryChangeIndexStore()
+	builder := transaction.NewBuilder(node.configManager(t))
+	builder.SetChangeIndexStore(store)
+
+	params := &types.TransactionParams{
+		From:       from.Address,
+		To:         to.Address,
+		Amount:     10000,
+		FeeRate:    1,
+		PrivateKey: types.NewSecret(mnemonicPhrase),
+	}
+
+	// Concurrent builds never share a change address
+	var wg sync.WaitGroup
+	txs := make([]*wire.MsgTx, 2)
+	errs := make([]error, 2)
+	for i := range txs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			txs[i], errs[i] = builder.BuildTransaction(params)
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Build %d: %v", i, err)
+		}
+	}
+	if first, second := lastOutputAddress(t, txs[0]), lastOutputAddress(t, txs[1]); first == second {
+		t.Errorf("Expected concurrent builds to get different change addresses, both got %s", first)
+	}
+
+	// Neither do pending unsigned transactions
+	first, err := builder.BuildUnsignedTransaction(params)
+	if err != nil {
+		t.Fatalf("Failed to build unsigned transaction: %v", err)
+	}
+	second, err := builder.BuildUnsignedTransaction(params)
+	if err != nil {
+		t.Fatalf("Failed to build unsigned transaction: %v", err)
+	}
+	if first.ChangePath != "m/44'/1'/0'/1/2" || second.ChangePath != "m/44'/1'/0'/1/3" {
+		t.Errorf("Expected change at indexes 2 and 3, got %q and %q", first.ChangePath, second.ChangePath)
+	}
+	if lastOutputAddress(t, first.Tx) == lastOutputAddress(t, second.Tx) {
+		t.Error("Expected pending builds to get different change addresses")
+	}
+
+	// A discarded transaction gives its index back
+	if err := builder.DiscardTransaction(second); err != nil {
+		t.Fatalf("Failed to discard transaction: %v", err)
+	}
+	third, err := builder.BuildUnsignedTransaction(params)
+	if err != nil {
+		t.Fatalf("Failed to build unsigned transaction: %v", err)
+	}
+	if third.ChangePath != second.ChangePath {
+		t.Errorf("Expected the discarded change path %q to be reused, got %q", second.ChangePath, third.ChangePath)
+	}
+
+	if _, err := transaction.SignOffline(first, types.NewSecret(mnemonicPhrase)); err != nil {
+		t.Fatalf("Failed to sign offline: %v", err)
+	}
+	result, err := builder.FinalizeTransaction(first)
+	if err != nil {
+		t.Fatalf("Failed to finalize transaction: %v", err)
+	}
+	if path := result.OutputsCreated[len(result.OutputsCreated)-1].DerivationPath; path != first.ChangePath {
+		t.Errorf("Expected finalized change at %q, got %q", first.ChangePath, path)
+	}
+}
+
+func TestHDChangeIndexReleasedOnFailedBroadcast(t *testing.T) {
+	node := newMockNode(t)
+	from, mnemonicPhrase := newTestWallet(t)
+	to, _ := newTestWallet(t)
+	node.addUTXO(from.Address, fmt.Sprintf("%064x", 1), 0, 50000, "")
+
+	account, _ := wallet.NewHDAccount(mnemonicPhrase, 0, true)
+	store := wallet.NewMemoryChangeIndexStore()
+	builder := transaction.NewBuilder(node.configManager(t))
+	builder.SetChangeIndexStore(store)
+	builder.SetBroadcaster(failingBroadcaster{})
+
+	_, err := builder.SignAndSendTransaction(&types.TransactionParams{
+		From:       from.Address,
+		To:         to.Address,
+		Amount:     10000,
+		FeeRate:    1,
+		PrivateKey: types.NewSecret(mnemonicPhrase),
+	})
+	if err == nil {
+		t.Fatal("Expected the broadcast to fail")
+	}
+
+	if index, _ := store.ReserveChangeIndex(account.ID()); index != 0 {
+		t.Errorf("Expected change index 0 to be released, got %d", index)
+	}
+}
+
+func TestHDChangeForPayoutsAndOrdinals(t *testing.T) {
+	node := newMockNode(t)
+	from, mnemonicPhrase := newTestWallet(t)
+	to, _ := newTestWallet(t)
+	for i := 0; i < 3; i++ {
+		node.addUTXO(from.Address, fmt.Sprintf("%064x", i+1), 0, 50000, "")
+	}
+
+	account, _ := wallet.NewHDAccount(mnemonicPhrase, 0, true)
+	builder := transaction.NewBuilder(node.configManager(t))
+
+	// Chained payouts spend each change output with its internal key
+	results, err := builder.SignAndSendPayout(&types.PayoutParams{
+		From:       from.Address,
+		FeeRate:    1,
+		PrivateKey: types.NewSecret(mnemonicPhrase),
+		SplitMode:  types.SplitChained,
+		Outputs:    []*types.PayoutOutput{{To: to.Address, Amount: 1000}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to send payout: %v", err)
+	}
+
+	inscription, err := builder.SignAndSendInscription(&types.InscriptionParams{
+		From:         from.Address,
+		FeeRate:      1,
+		PrivateKey:   types.NewSecret(mnemonicPhrase),
+		Inscriptions: []*types.Inscription{{ContentType: "text/plain", Body: []byte("hello")}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to send inscription: %v", err)
+	}
+
+	for i, result := range append(results, inscription) {
+		keyPair, _ := account.KeyPair(wallet.InternalChain, uint32(i))
+		expected, _ := keyPair.Address()
+		if address := changeAddress(t, node.broadcast[i]); address != expected {
+			t.Errorf("Transaction %d: expected change to %s, got %s", i, expected, address)
+		}
+
+		change := result.OutputsCreated[len(result.OutputsCreated)-1]
+		if path := fmt.Sprintf("m/44'/1'/0'/1/%d", i); change.DerivationPath != path || result.Change != change.Amount {
+			t.Errorf("Transaction %d: expected change at %s, got %q", i, path, change.DerivationPath)
+		}
+	}
+}
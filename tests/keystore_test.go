@@ -0,0 +1,239 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/tyler-smith/go-bip32"
+	"github.com/tyler-smith/go-bip39"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/keystore"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// newTestKeystore creates an unlocked keystore in a temporary directory
+func newTestKeystore(t *testing.T, password string) (*keystore.Keystore, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+	ks, err := keystore.Create(path, password)
+	if err != nil {
+		t.Fatalf("Failed to create keystore: %v", err)
+	}
+	if err := ks.Unlock(password, 0); err != nil {
+		t.Fatalf("Failed to unlock keystore: %v", err)
+	}
+	return ks, path
+}
+
+func TestKeystoreEntries(t *testing.T) {
+	ks, path := newTestKeystore(t, "correct horse")
+	from, mnemonicPhrase := newTestWallet(t)
+	keyPair, _ := p2pkhTestKey(t, 0x91)
+	wif, _ := btcutil.NewWIF(keyPair.PrivateKey, &chaincfg.TestNet3Params, true)
+	master, _ := bip32.NewMasterKey(bip39.NewSeed(mnemonicPhrase, ""))
+
+	if err := ks.AddMnemonic("savings", mnemonicPhrase, true); err != nil {
+		t.Fatalf("Failed to add mnemonic: %v", err)
+	}
+	if err := ks.AddWIF("hot", wif.String(), true); err != nil {
+		t.Fatalf("Failed to add WIF: %v", err)
+	}
+	if err := ks.AddExtendedKey("backup", master.String(), true); err != nil {
+		t.Fatalf("Failed to add extended key: %v", err)
+	}
+	if err := ks.AddWIF("hot", wif.String(), true); !errors.Is(err, keystore.ErrExists) {
+		t.Errorf("Expected ErrExists for a duplicate label, got %v", err)
+	}
+	if err := ks.AddWIF("mainnet", wif.String(), false); err == nil {
+		t.Error("Expected error for a WIF key of another network")
+	}
+
+	// Nothing secret is written in the clear
+	raw, _ := os.ReadFile(path)
+	if bytes.Contains(raw, []byte(wif.String())) || bytes.Contains(raw, []byte(strings.Fields(mnemonicPhrase)[0]+" ")) {
+		t.Error("Expected secrets to be encrypted on disk")
+	}
+
+	// Entries are listed while locked
+	ks.Lock()
+	reopened, err := keystore.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open keystore: %v", err)
+	}
+	if labels := strings.Join(reopened.Labels(), ","); labels != "backup,hot,savings" {
+		t.Errorf("Expected sorted labels, got %s", labels)
+	}
+	if info, _ := reopened.Entry("savings"); info.Address != from.Address || info.Type != keystore.KeyTypeMnemonic {
+		t.Errorf("Expected the mnemonic's first address %s, got %s", from.Address, info.Address)
+	}
+	if _, err := reopened.Secret("hot"); !errors.Is(err, keystore.ErrLocked) {
+		t.Errorf("Expected ErrLocked, got %v", err)
+	}
+	if err := reopened.Unlock("wrong", 0); !errors.Is(err, keystore.ErrWrongPassword) {
+		t.Errorf("Expected ErrWrongPassword, got %v", err)
+	}
+
+	if err := reopened.Unlock("correct horse", 0); err != nil {
+		t.Fatalf("Failed to unlock: %v", err)
+	}
+	if secret, _ := reopened.Secret("hot"); secret.Reveal() != wif.String() {
+		t.Error("Expected the WIF key back")
+	}
+	signer, err := reopened.Signer("backup")
+	if err != nil {
+		t.Fatalf("Failed to get signer: %v", err)
+	}
+	if address, _ := wallet.SignerAddress(signer, &chaincfg.TestNet3Params); address != from.Address {
+		t.Errorf("Expected the extended key to sign for %s, got %s", from.Address, address)
+	}
+	if _, err := reopened.HDAccount("hot", 0); err == nil {
+		t.Error("Expected error for the HD account of a WIF entry")
+	}
+
+	if err := reopened.Remove("hot"); err != nil {
+		t.Fatalf("Failed to remove entry: %v", err)
+	}
+	if _, err := reopened.Entry("hot"); !errors.Is(err, keystore.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after removal, got %v", err)
+	}
+}
+
+func TestKeystoreUnlockTimeout(t *testing.T) {
+	ks, _ := newTestKeystore(t, "pw")
+	if err := ks.Unlock("pw", 20*time.Millisecond); err != nil {
+		t.Fatalf("Failed to unlock: %v", err)
+	}
+	if !ks.IsUnlocked() {
+		t.Fatal("Expected the keystore to be unlocked")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for ks.IsUnlocked() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if ks.IsUnlocked() {
+		t.Error("Expected the keystore to lock itself after the timeout")
+	}
+
+	// A later unlock is not cut short by an earlier unlock's timeout
+	for i := 0; i < 5; i++ {
+		if err := ks.Unlock("pw", time.Millisecond); err != nil {
+			t.Fatalf("Failed to unlock: %v", err)
+		}
+	}
+	if err := ks.Unlock("pw", time.Hour); err != nil {
+		t.Fatalf("Failed to unlock: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if !ks.IsUnlocked() {
+		t.Error("Expected the keystore to stay unlocked until its latest timeout")
+	}
+	ks.Lock()
+}
+
+func TestKeystoreRejectsCostlyScryptParameters(t *testing.T) {
+	_, path := newTestKeystore(t, "pw")
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read keystore: %v", err)
+	}
+
+	for _, params := range []string{`"n": 2097152`, `"n": 1000`, `"r": 64`, `"p": 17`, `"p": 0`} {
+		field := strings.SplitN(params, ":", 2)[0]
+		tampered := regexp.MustCompile(field+`: \d+`).ReplaceAllString(string(raw), params)
+		tamperedPath := filepath.Join(t.TempDir(), "keys.json")
+		os.WriteFile(tamperedPath, []byte(tampered), 0600)
+
+		if _, err := keystore.Open(tamperedPath); err == nil || !strings.Contains(err.Error(), "scrypt parameters") {
+			t.Errorf("%s: expected the scrypt parameters to be rejected, got %v", params, err)
+		}
+	}
+}
+
+func TestKeystorePasswordChangeAndImport(t *testing.T) {
+	ks, path := newTestKeystore(t, "old")
+	_, mnemonicPhrase := newTestWallet(t)
+	if err := ks.AddMnemonic("savings", mnemonicPhrase, true); err != nil {
+		t.Fatalf("Failed to add mnemonic: %v", err)
+	}
+
+	if err := ks.ChangePassword("wrong", "new"); !errors.Is(err, keystore.ErrWrongPassword) {
+		t.Errorf("Expected ErrWrongPassword, got %v", err)
+	}
+	if err := ks.ChangePassword("old", "new"); err != nil {
+		t.Fatalf("Failed to change password: %v", err)
+	}
+
+	reopened, _ := keystore.Open(path)
+	if err := reopened.Unlock("old", 0); err == nil {
+		t.Error("Expected the old password to be rejected")
+	}
+	if err := reopened.Unlock("new", 0); err != nil {
+		t.Fatalf("Failed to unlock with the new password: %v", err)
+	}
+	if secret, _ := reopened.Secret("savings"); secret.Reveal() != mnemonicPhrase {
+		t.Error("Expected the mnemonic to survive the password change")
+	}
+
+	var exported bytes.Buffer
+	if err := reopened.Export(&exported); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	other, _ := newTestKeystore(t, "other")
+	if _, err := other.Import(bytes.NewReader(exported.Bytes()), "old"); !errors.Is(err, keystore.ErrWrongPassword) {
+		t.Errorf("Expected ErrWrongPassword importing with the old password, got %v", err)
+	}
+	if n, err := other.Import(bytes.NewReader(exported.Bytes()), "new"); err != nil || n != 1 {
+		t.Fatalf("Expected one imported entry, got %d: %v", n, err)
+	}
+	if secret, _ := other.Secret("savings"); secret.Reveal() != mnemonicPhrase {
+		t.Error("Expected the imported mnemonic under the other keystore's password")
+	}
+	if _, err := other.Import(bytes.NewReader(exported.Bytes()), "new"); !errors.Is(err, keystore.ErrExists) {
+		t.Errorf("Expected ErrExists importing twice, got %v", err)
+	}
+
+	// Ciphertexts are bound to their labels
+	tampered := strings.Replace(exported.String(), `"label": "savings"`, `"label": "renamed"`, 1)
+	if _, err := other.Import(strings.NewReader(tampered), "new"); err == nil {
+		t.Error("Expected error importing a relabelled entry")
+	}
+}
+
+func TestKeystoreSignerSendsTransaction(t *testing.T) {
+	node := newMockNode(t)
+	ks, _ := newTestKeystore(t, "pw")
+	from, mnemonicPhrase := newTestWallet(t)
+	to, _ := newTestWallet(t)
+	node.addUTXO(from.Address, fmt.Sprintf("%064x", 1), 0, 50000, "")
+
+	if err := ks.AddMnemonic("savings", mnemonicPhrase, true); err != nil {
+		t.Fatalf("Failed to add mnemonic: %v", err)
+	}
+	signer, err := ks.Signer("savings")
+	if err != nil {
+		t.Fatalf("Failed to get signer: %v", err)
+	}
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	result, err := builder.SignAndSendTransactionWithSigner(&types.TransactionParams{From: from.Address, To: to.Address, Amount: 10000, FeeRate: 1}, signer)
+	if err != nil {
+		t.Fatalf("Failed to send with a keystore signer: %v", err)
+	}
+	if path := result.OutputsCreated[len(result.OutputsCreated)-1].DerivationPath; path != "m/44'/1'/0'/1/0" {
+		t.Errorf("Expected HD change from the keystore mnemonic, got %q", path)
+	}
+}
@@ -0,0 +1,164 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/spv"
+)
+
+// Mainnet block 100000
+var (
+	block100000Hash  = "000000000003ba27aa200b1cecaad478d2b00432346c3f1f3986da1afd33e506"
+	block100000TxIDs = []string{
+		"8c14f0db3df150123e6f3dbbf30f8b955a8249b62ac1d1ff16284aefa3d06d87",
+		"fff2525b8931402dd09222c50775608f75787bd2b87e56995a7bdd30f79702c4",
+		"6359f0868171b1d194cbee1af2f16ea598ae8fad666d9b012c8ed2b79a236ec4",
+		"e9a66845e05d5abc0ad04ec80f774a7e585c6e8db975962d069a522137b80c1d",
+	}
+)
+
+func block100000Header(t *testing.T) *wire.BlockHeader {
+	t.Helper()
+
+	prevBlock, _ := chainhash.NewHashFromStr("000000000002d01c1fccc21636b607dfd930d31d01c3a62104612a1719011250")
+	merkleRoot, _ := chainhash.NewHashFromStr("f3e94742aca4b5ef85488dc37c06c3282295ffec960994b2c0d5ac2a25a95766")
+
+	header := &wire.BlockHeader{
+		Version:    1,
+		PrevBlock:  *prevBlock,
+		MerkleRoot: *merkleRoot,
+		Timestamp:  time.Unix(1293623863, 0),
+		Bits:       0x1b04864c,
+		Nonce:      274148111,
+	}
+
+	if header.BlockHash().String() != block100000Hash {
+		t.Fatalf("Block 100000 header hashes to %s", header.BlockHash())
+	}
+
+	return header
+}
+
+func block100000TxHashes(t *testing.T) []chainhash.Hash {
+	t.Helper()
+
+	hashes := make([]chainhash.Hash, len(block100000TxIDs))
+	for i, txid := range block100000TxIDs {
+		hash, err := chainhash.NewHashFromStr(txid)
+		if err != nil {
+			t.Fatalf("Invalid txid %s: %v", txid, err)
+		}
+		hashes[i] = *hash
+	}
+	return hashes
+}
+
+func TestMerklePathBlock100000(t *testing.T) {
+	header := block100000Header(t)
+	txids := block100000TxHashes(t)
+
+	for i := range txids {
+		path, err := spv.NewMerklePath(100000, txids, i)
+		if err != nil {
+			t.Fatalf("Failed to build merkle path for tx %d: %v", i, err)
+		}
+
+		decoded, err := spv.ParseMerklePathHex(path.Hex())
+		if err != nil {
+			t.Fatalf("Failed to parse merkle path for tx %d: %v", i, err)
+		}
+
+		if decoded.Hex() != path.Hex() {
+			t.Errorf("Tx %d: merkle path hex does not round trip", i)
+		}
+
+		valid, err := decoded.VerifyHeader(&txids[i], header)
+		if err != nil {
+			t.Fatalf("Failed to verify tx %d: %v", i, err)
+		}
+		if !valid {
+			t.Errorf("Tx %d does not verify against block 100000", i)
+		}
+
+		// Another block's transaction is not in the path
+		if _, err := decoded.ComputeRoot(&header.PrevBlock); err == nil {
+			t.Errorf("Tx %d: expected error for a txid outside the path", i)
+		}
+	}
+}
+
+func TestMerklePathParseVector(t *testing.T) {
+	// BUMP for the last transaction of block 100000: height, tree height 2,
+	// level 0 with its sibling and the txid, level 1 with the hash of the
+	// first two transactions (ccdafb73...cb815). Hashes are in internal byte order.
+	const vector = "fea0860100" + "02" +
+		"02" + "02" + "00" + "c46e239ab7d28e2c019b6d66ad8fae98a56ef1f21aeecb94d1b1718186f05963" +
+		"03" + "02" + "1d0cb83721529a062d9675b98d6e5c587e4a770fc84ed00abc5a5de04568a6e9" +
+		"01" + "00" + "00" + "15b88c5107195bf09eb9da89b83d95b3d070079a3c5c5d3d17d0dcd873fbdacc"
+
+	path, err := spv.ParseMerklePathHex(vector)
+	if err != nil {
+		t.Fatalf("Failed to parse BUMP vector: %v", err)
+	}
+
+	if path.BlockHeight != 100000 || len(path.Path) != 2 {
+		t.Fatalf("Unexpected block height %d or tree height %d", path.BlockHeight, len(path.Path))
+	}
+
+	if path.Hex() != vector {
+		t.Error("BUMP vector does not round trip")
+	}
+
+	txids := block100000TxHashes(t)
+	valid, err := path.VerifyHeader(&txids[3], block100000Header(t))
+	if err != nil {
+		t.Fatalf("Failed to verify BUMP vector: %v", err)
+	}
+	if !valid {
+		t.Error("BUMP vector does not verify against block 100000")
+	}
+
+	for _, invalid := range []string{"", "fea086010000", "fea0860100010100ff", "zz"} {
+		if _, err := spv.ParseMerklePathHex(invalid); err == nil {
+			t.Errorf("Expected error parsing %q", invalid)
+		}
+	}
+}
+
+func TestMerklePathCombine(t *testing.T) {
+	header := block100000Header(t)
+	txids := block100000TxHashes(t)
+
+	first, _ := spv.NewMerklePath(100000, txids, 0)
+	second, _ := spv.NewMerklePath(100000, txids, 2)
+
+	if err := first.Combine(second); err != nil {
+		t.Fatalf("Failed to combine merkle paths: %v", err)
+	}
+
+	for _, i := range []int{0, 2} {
+		if !first.Contains(&txids[i]) {
+			t.Errorf("Combined path does not prove tx %d", i)
+		}
+
+		valid, err := first.VerifyHeader(&txids[i], header)
+		if err != nil || !valid {
+			t.Errorf("Combined path does not verify tx %d: %v", i, err)
+		}
+	}
+
+	other, _ := spv.NewMerklePath(100001, txids, 1)
+	if err := first.Combine(other); err == nil {
+		t.Error("Expected error combining paths for different blocks")
+	}
+
+	// Same height, different tree
+	forged, _ := spv.NewMerklePath(100000, []chainhash.Hash{txids[1], txids[0], txids[2], txids[3]}, 0)
+	if err := first.Combine(forged); err == nil {
+		t.Error("Expected error combining paths with different roots")
+	}
+}
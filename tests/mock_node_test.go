@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/utxo"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+	"github.com/muhammadamman/BSV-Go/pkg/config"
+	"github.com/muhammadamman/BSV-Go/pkg/mnemonic"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// mockNode is a local stand-in for the What's On Chain API
+type mockNode struct {
+	server    *httptest.Server
+	mutex     sync.Mutex
+	utxos     map[string][]utxo.EnhancedUTXOResponse
+	broadcast [][]byte
+}
+
+func newMockNode(t *testing.T) *mockNode {
+	t.Helper()
+
+	node := &mockNode{
+		utxos: make(map[string][]utxo.EnhancedUTXOResponse),
+	}
+
+	node.server = httptest.NewServer(http.HandlerFunc(node.handle))
+	t.Cleanup(node.server.Close)
+
+	return node
+}
+
+func (n *mockNode) handle(w http.ResponseWriter, r *http.Request) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/tx/raw":
+		body, _ := io.ReadAll(r.Body)
+		n.broadcast = append(n.broadcast, body)
+		w.WriteHeader(http.StatusOK)
+	case len(parts) == 3 && (parts[0] == "address" || parts[0] == "script") && parts[2] == "unspent":
+		utxos := n.utxos[parts[1]]
+		if utxos == nil {
+			utxos = []utxo.EnhancedUTXOResponse{}
+		}
+		json.NewEncoder(w).Encode(utxos)
+	case len(parts) == 3 && parts[0] == "address" && parts[2] == "balance":
+		var confirmed int64
+		for _, u := range n.utxos[parts[1]] {
+			confirmed += u.Value
+		}
+		json.NewEncoder(w).Encode(utxo.EnhancedBalanceResponse{Confirmed: confirmed})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// addUTXO registers a confirmed UTXO for an address or script hash
+func (n *mockNode) addUTXO(address, txID string, vout uint32, value int64, scriptPubKey string) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.utxos[address] = append(n.utxos[address], utxo.EnhancedUTXOResponse{
+		TxID:          txID,
+		Vout:          vout,
+		Value:         value,
+		ScriptPubKey:  scriptPubKey,
+		Address:       address,
+		Confirmations: 6,
+		Height:        800000,
+	})
+}
+
+// configManager returns a testnet configuration pointing at the mock node
+func (n *mockNode) configManager(t *testing.T) *config.Manager {
+	t.Helper()
+
+	configManager := config.NewManager()
+	network := configManager.GetNetworkConfig()
+	network.RPCURL = n.server.URL
+	if err := configManager.UpdateNetworkConfig(network); err != nil {
+		t.Fatalf("Failed to point network config at mock node: %v", err)
+	}
+
+	return configManager
+}
+
+// newTestWallet generates a random testnet wallet and returns it with its mnemonic
+func newTestWallet(t *testing.T) (*types.WalletResult, string) {
+	t.Helper()
+
+	mnemonicPhrase, err := mnemonic.Generate(mnemonic.Strength128)
+	if err != nil {
+		t.Fatalf("Failed to generate mnemonic: %v", err)
+	}
+
+	result, err := wallet.GenerateWallet(mnemonicPhrase, true)
+	if err != nil {
+		t.Fatalf("Failed to generate wallet: %v", err)
+	}
+
+	return result, mnemonicPhrase
+}
@@ -0,0 +1,206 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// newCosigners returns the mnemonics of three cosigners and their 2-of-3 multisig
+func newCosigners(t *testing.T) ([]string, *transaction.MultiSigTemplate) {
+	t.Helper()
+
+	var mnemonics []string
+	var publicKeys [][]byte
+	for i := 0; i < 3; i++ {
+		_, mnemonicPhrase := newTestWallet(t)
+		_, keyPair, err := wallet.GenerateWalletWithKeypair(mnemonicPhrase, true)
+		if err != nil {
+			t.Fatalf("Failed to generate cosigner key: %v", err)
+		}
+		mnemonics = append(mnemonics, mnemonicPhrase)
+		publicKeys = append(publicKeys, keyPair.PublicKey.SerializeCompressed())
+	}
+
+	multisig, err := transaction.NewMultiSigTemplate(2, publicKeys)
+	if err != nil {
+		t.Fatalf("Failed to create multisig: %v", err)
+	}
+
+	return mnemonics, multisig
+}
+
+// roundTrip passes an unsigned transaction through its portable encoding
+func roundTrip(t *testing.T, unsigned *transaction.UnsignedTransaction) *transaction.UnsignedTransaction {
+	t.Helper()
+
+	data, err := unsigned.Serialize()
+	if err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+
+	parsed, err := transaction.ParseUnsignedTransaction(data)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	return parsed
+}
+
+func TestMultiSigCosigningFlow(t *testing.T) {
+	node := newMockNode(t)
+	mnemonics, multisig := newCosigners(t)
+	to, _ := newTestWallet(t)
+
+	scriptHash, err := multisig.ScriptHash()
+	if err != nil {
+		t.Fatalf("Failed to get script hash: %v", err)
+	}
+	node.addUTXO(scriptHash, "c1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90", 0, 50000, "")
+
+	builder := transaction.NewBuilder(node.configManager(t))
+
+	watched, err := builder.GetScriptUTXOs(scriptHash)
+	if err != nil || len(watched) != 1 {
+		t.Fatalf("Expected to watch one multisig UTXO, got %d: %v", len(watched), err)
+	}
+
+	unsigned, err := builder.BuildMultiSigTransaction(multisig, &types.TransactionParams{
+		To:      to.Address,
+		Amount:  20000,
+		FeeRate: 1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to build multisig transaction: %v", err)
+	}
+
+	lockingScript, _ := multisig.Lock()
+	if len(unsigned.Tx.TxOut) != 2 || !bytes.Equal(unsigned.Tx.TxOut[1].PkScript, lockingScript) {
+		t.Fatal("Expected change back to the multisig script")
+	}
+
+	// The third and first cosigners sign their own copies
+	copies := []*transaction.UnsignedTransaction{roundTrip(t, unsigned), roundTrip(t, unsigned)}
+	for i, mnemonicPhrase := range []string{mnemonics[2], mnemonics[0]} {
+		signed, err := transaction.CosignOffline(copies[i], types.NewSecret(mnemonicPhrase))
+		if err != nil || signed != 1 {
+			t.Fatalf("Cosigner %d: expected one signature, got %d: %v", i, signed, err)
+		}
+	}
+
+	// One signature is not enough
+	if _, err := transaction.AssembleMultiSig(copies[0]); err == nil {
+		t.Fatal("Expected error assembling with one of two signatures")
+	}
+	if len(copies[0].Tx.TxIn[0].SignatureScript) != 0 {
+		t.Fatal("Expected failed assembly to leave the input unsigned")
+	}
+
+	if err := copies[0].MergeSignatures(roundTrip(t, copies[1])); err != nil {
+		t.Fatalf("Failed to merge signatures: %v", err)
+	}
+
+	assembled, err := transaction.AssembleMultiSig(copies[0])
+	if err != nil || assembled != 1 {
+		t.Fatalf("Expected one assembled input, got %d: %v", assembled, err)
+	}
+
+	unlockingScript := copies[0].Tx.TxIn[0].SignatureScript
+	if unlockingScript[0] != txscript.OP_0 {
+		t.Errorf("Expected OP_0 dummy, got %x", unlockingScript[0])
+	}
+
+	// The fee covers the assembled size at the requested rate
+	var outputs int64
+	for _, txOut := range copies[0].Tx.TxOut {
+		outputs += txOut.Value
+	}
+	if fee := 50000 - outputs; fee < int64(copies[0].Tx.SerializeSize()) {
+		t.Errorf("Fee %d is below 1 sat/byte for %d bytes", fee, copies[0].Tx.SerializeSize())
+	}
+
+	if _, err := builder.FinalizeTransaction(copies[0]); err != nil {
+		t.Fatalf("Failed to finalize: %v", err)
+	}
+
+	if len(node.broadcast) != 1 {
+		t.Fatalf("Expected one broadcast, got %d", len(node.broadcast))
+	}
+}
+
+func TestMultiSigRejectsBadCosigning(t *testing.T) {
+	node := newMockNode(t)
+	mnemonics, multisig := newCosigners(t)
+	to, outsider := newTestWallet(t)
+
+	scriptHash, _ := multisig.ScriptHash()
+	node.addUTXO(scriptHash, "d1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90", 3, 50000, "")
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	unsigned, err := builder.BuildMultiSigTransaction(multisig, &types.TransactionParams{
+		To:      to.Address,
+		Amount:  20000,
+		FeeRate: 1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to build multisig transaction: %v", err)
+	}
+
+	if _, err := transaction.CosignOffline(unsigned, types.NewSecret(outsider)); err == nil {
+		t.Error("Expected error signing with a key that is not a cosigner")
+	}
+
+	cosigned := roundTrip(t, unsigned)
+	if _, err := transaction.CosignOffline(cosigned, types.NewSecret(mnemonics[1])); err != nil {
+		t.Fatalf("Failed to cosign: %v", err)
+	}
+
+	// A tampered signature is refused
+	tampered := roundTrip(t, cosigned)
+	tampered.Signatures[0].Signature = strings.Repeat("0", 8) + tampered.Signatures[0].Signature[8:]
+	if err := unsigned.MergeSignatures(tampered); err == nil {
+		t.Error("Expected error merging a tampered signature")
+	}
+
+	// Signatures for another transaction are refused
+	other := roundTrip(t, cosigned)
+	other.Tx.TxOut[0].Value--
+	if err := unsigned.MergeSignatures(other); err == nil {
+		t.Error("Expected error merging signatures for another transaction")
+	}
+
+	if len(unsigned.Signatures) != 0 {
+		t.Errorf("Expected no merged signatures, got %d", len(unsigned.Signatures))
+	}
+}
+
+func TestParseMultiSigScript(t *testing.T) {
+	_, multisig := newCosigners(t)
+	lockingScript, _ := multisig.Lock()
+
+	parsed, err := transaction.ParseMultiSigScript(lockingScript)
+	if err != nil {
+		t.Fatalf("Failed to parse multisig script: %v", err)
+	}
+
+	if parsed.Required != 2 || len(parsed.PublicKeys) != 3 || !bytes.Equal(parsed.PublicKeys[1], multisig.PublicKeys[1]) {
+		t.Errorf("Parsed %d-of-%d multisig does not match", parsed.Required, len(parsed.PublicKeys))
+	}
+
+	_, p2pkhScript := p2pkhTestKey(t, 0x61)
+	if _, err := transaction.ParseMultiSigScript(p2pkhScript); err == nil {
+		t.Error("Expected error parsing a P2PKH script")
+	}
+
+	// Required count above the key count
+	invalid := append([]byte{txscript.OP_4}, lockingScript[1:]...)
+	if _, err := transaction.ParseMultiSigScript(invalid); err == nil {
+		t.Error("Expected error parsing 4-of-3 multisig")
+	}
+}
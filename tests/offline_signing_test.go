@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+func TestOfflineSigningWorkflow(t *testing.T) {
+	node := newMockNode(t)
+
+	sender, mnemonicPhrase := newTestWallet(t)
+	recipient, _ := newTestWallet(t)
+
+	node.addUTXO(sender.Address, "b1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90", 1, 50000, "")
+
+	builder := transaction.NewBuilder(node.configManager(t))
+
+	// 1. Build online without key material
+	unsigned, err := builder.BuildUnsignedTransaction(&types.TransactionParams{
+		From:    sender.Address,
+		To:      recipient.Address,
+		Amount:  10000,
+		FeeRate: 1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to build unsigned transaction: %v", err)
+	}
+
+	if len(unsigned.Inputs) != 1 || unsigned.Inputs[0].ScriptPubKey == "" || unsigned.Inputs[0].Value != 50000 {
+		t.Fatalf("Expected prevout value and script on input, got %+v", unsigned.Inputs)
+	}
+
+	if _, err := builder.FinalizeTransaction(unsigned); err == nil {
+		t.Error("Expected finalize to reject unsigned inputs")
+	}
+
+	path := filepath.Join(t.TempDir(), "unsigned.json")
+	if err := unsigned.WriteFile(path); err != nil {
+		t.Fatalf("Failed to write unsigned transaction: %v", err)
+	}
+
+	// 2. Sign from the file using only the key
+	offline, err := transaction.ReadUnsignedTransactionFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read unsigned transaction: %v", err)
+	}
+
+	other, otherMnemonic := newTestWallet(t)
+	if _, err := transaction.SignOffline(offline, types.NewSecret(otherMnemonic)); err == nil {
+		t.Errorf("Expected %s to have no inputs to sign", other.Address)
+	}
+
+	signed, err := transaction.SignOffline(offline, types.NewSecret(mnemonicPhrase))
+	if err != nil {
+		t.Fatalf("Failed to sign offline: %v", err)
+	}
+
+	if signed != 1 {
+		t.Errorf("Expected 1 signed input, got %d", signed)
+	}
+
+	data, err := offline.Serialize()
+	if err != nil {
+		t.Fatalf("Failed to serialize signed transaction: %v", err)
+	}
+
+	// 3. Finalize and broadcast online
+	finalized, err := transaction.ParseUnsignedTransaction(data)
+	if err != nil {
+		t.Fatalf("Failed to parse signed transaction: %v", err)
+	}
+
+	result, err := builder.FinalizeTransaction(finalized)
+	if err != nil {
+		t.Fatalf("Failed to finalize transaction: %v", err)
+	}
+
+	if len(node.broadcast) != 1 || hex.EncodeToString(node.broadcast[0]) != result.SignedTx {
+		t.Fatal("Expected the finalized transaction to be broadcast")
+	}
+
+	var buf bytes.Buffer
+	finalized.Tx.Serialize(&buf)
+	if result.TxID != finalized.Tx.TxHash().String() || !bytes.Equal(buf.Bytes(), node.broadcast[0]) {
+		t.Error("Broadcast transaction does not match the finalized transaction")
+	}
+}
+
+func TestParseUnsignedTransactionRejectsMissingPrevouts(t *testing.T) {
+	tests := []string{
+		`{"version":2,"network":"testnet","tx":"","inputs":[]}`,
+		`{"version":1,"network":"regtest","tx":"","inputs":[]}`,
+		`{"version":1,"network":"testnet","tx":"zz","inputs":[]}`,
+		// One input with no prevout data
+		`{"version":1,"network":"testnet","tx":"0100000001f08f7e6d5c4b3a291807f6e5d4c3b2a1f08f7e6d5c4b3a291807f6e5d4c3b2b1010000000000ffffffff0000000000","inputs":[]}`,
+	}
+
+	for _, data := range tests {
+		if _, err := transaction.ParseUnsignedTransaction([]byte(data)); err == nil {
+			t.Errorf("Expected error parsing %s", data)
+		}
+	}
+}
+
+func TestSignOfflineDerivesEachInputKey(t *testing.T) {
+	node := newMockNode(t)
+	from, mnemonicPhrase := newTestWallet(t)
+	to, _ := newTestWallet(t)
+
+	account, _ := wallet.NewHDAccount(mnemonicPhrase, 0, true)
+	paths := []*types.HDPath{{Chain: wallet.ExternalChain, Index: 4}, {Chain: wallet.InternalChain, Index: 25}}
+	for i, path := range paths {
+		keyPair, _ := account.KeyPair(path.Chain, path.Index)
+		address, _ := keyPair.Address()
+		node.addUTXO(address, fmt.Sprintf("%064x", i+1), 0, 20000, "")
+	}
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	unsigned, err := builder.BuildUnsignedTransaction(&types.TransactionParams{
+		From:         from.Address,
+		To:           to.Address,
+		Amount:       35000,
+		FeeRate:      1,
+		PrivateKey:   types.NewSecret(mnemonicPhrase),
+		FundingPaths: paths,
+	})
+	if err != nil {
+		t.Fatalf("Failed to build unsigned transaction: %v", err)
+	}
+
+	for i, input := range unsigned.Inputs {
+		for _, path := range paths {
+			keyPair, _ := account.KeyPair(path.Chain, path.Index)
+			if address, _ := keyPair.Address(); address == input.Address && unsigned.InputPaths[i] != account.Path(path.Chain, path.Index) {
+				t.Errorf("Expected input %d path %s, got %q", i, account.Path(path.Chain, path.Index), unsigned.InputPaths[i])
+			}
+		}
+	}
+
+	signed, err := transaction.SignOffline(unsigned, types.NewSecret(mnemonicPhrase))
+	if err != nil {
+		t.Fatalf("Failed to sign offline: %v", err)
+	}
+	if signed != 2 {
+		t.Errorf("Expected both inputs signed from their paths, got %d", signed)
+	}
+
+	// Without paths only addresses within the scanned range are found
+	for _, txIn := range unsigned.Tx.TxIn {
+		txIn.SignatureScript = nil
+	}
+	unsigned.InputPaths = nil
+
+	signed, err = transaction.SignOffline(unsigned, types.NewSecret(mnemonicPhrase))
+	if err != nil {
+		t.Fatalf("Failed to sign offline without paths: %v", err)
+	}
+	if signed != 1 {
+		t.Errorf("Expected only the m/44'/1'/0'/0/4 input signed by scanning, got %d", signed)
+	}
+}
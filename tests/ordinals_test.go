@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/ordinals"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+func TestInscribeFiles(t *testing.T) {
+	node := newMockNode(t)
+	from, mnemonicPhrase := newTestWallet(t)
+	to, _ := newTestWallet(t)
+	node.addUTXO(from.Address, "f1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90", 0, 100000, "")
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	result, err := builder.SignAndSendInscription(&types.InscriptionParams{
+		From:       from.Address,
+		PrivateKey: types.NewSecret(mnemonicPhrase),
+		FeeRate:    1,
+		Inscriptions: []*types.Inscription{
+			{ContentType: "text/plain;charset=utf-8", Body: []byte("Hello, ordinals")},
+			{ContentType: "image/png", Body: bytes.Repeat([]byte{0x89}, 600), To: to.Address},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to inscribe: %v", err)
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(node.broadcast[0])); err != nil {
+		t.Fatalf("Failed to decode broadcast transaction: %v", err)
+	}
+
+	inscriptions := builder.ParseInscriptions(tx)
+	if len(inscriptions) != 2 {
+		t.Fatalf("Expected two inscriptions, got %d", len(inscriptions))
+	}
+
+	expected := []struct {
+		contentType string
+		owner       string
+	}{{"text/plain;charset=utf-8", from.Address}, {"image/png", to.Address}}
+	for i, e := range expected {
+		inscription := inscriptions[i]
+		if inscription.ID != fmt.Sprintf("%s_%d", result.TxID, i) || inscription.Vout != uint32(i) || inscription.Value != 1 {
+			t.Errorf("Inscription %d: expected 1 satoshi at %s_%d, got %d at %s", i, result.TxID, i, inscription.Value, inscription.ID)
+		}
+		if inscription.ContentType != e.contentType || inscription.Owner != e.owner {
+			t.Errorf("Inscription %d: expected %s owned by %s, got %s owned by %s", i, e.contentType, e.owner, inscription.ContentType, inscription.Owner)
+		}
+	}
+	if string(inscriptions[0].Body) != "Hello, ordinals" {
+		t.Errorf("Unexpected body %q", inscriptions[0].Body)
+	}
+
+	// Change follows the inscriptions
+	if len(tx.TxOut) != 3 || tx.TxOut[2].Value <= 1 {
+		t.Errorf("Expected change after the inscriptions, got %d outputs", len(tx.TxOut))
+	}
+
+	parsed, err := builder.ParseInscriptionsHex(result.SignedTx)
+	if err != nil || len(parsed) != 2 {
+		t.Errorf("Expected two inscriptions from hex, got %d: %v", len(parsed), err)
+	}
+
+	if _, err := builder.BuildInscription(&types.InscriptionParams{From: from.Address, PrivateKey: types.NewSecret(mnemonicPhrase), Inscriptions: []*types.Inscription{{Body: []byte("x")}}}); err == nil {
+		t.Error("Expected error without a content type")
+	}
+}
+
+func TestTransferOrdinalsPreservesOrder(t *testing.T) {
+	node := newMockNode(t)
+	from, mnemonicPhrase := newTestWallet(t)
+	to, _ := newTestWallet(t)
+	other, _ := newTestWallet(t)
+
+	owner, _ := transaction.P2PKHFromAddress(from.Address, &chaincfg.TestNet3Params)
+	ownerScript, _ := owner.Lock()
+	inscribed, _ := (&ordinals.Inscription{ContentType: "text/plain", Body: []byte("origin")}).LockingScript(ownerScript)
+
+	plainID := "a7b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90"
+	inscribedID := "b7b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90"
+	node.addUTXO(from.Address, "c7b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90", 0, 50000, "")
+	node.addUTXO(from.Address, inscribedID, 0, 1, hex.EncodeToString(inscribed))
+	node.addUTXO(from.Address, plainID, 3, 1, hex.EncodeToString(ownerScript))
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	params := &types.OrdinalTransferParams{
+		From:       from.Address,
+		PrivateKey: types.NewSecret(mnemonicPhrase),
+		FeeRate:    1,
+		Transfers: []*types.OrdinalTransfer{
+			{TxID: plainID, Vout: 3, To: to.Address},
+			{TxID: inscribedID, Vout: 0, To: other.Address},
+		},
+	}
+	if _, err := builder.SignAndSendOrdinalTransfer(params); err != nil {
+		t.Fatalf("Failed to transfer ordinals: %v", err)
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(node.broadcast[0])); err != nil {
+		t.Fatalf("Failed to decode broadcast transaction: %v", err)
+	}
+
+	// Each ordinal is spent by the input and lands in the output at its index
+	for i, transfer := range params.Transfers {
+		outPoint := tx.TxIn[i].PreviousOutPoint
+		if outPoint.Hash.String() != transfer.TxID || outPoint.Index != transfer.Vout {
+			t.Errorf("Input %d: expected %s:%d, got %s", i, transfer.TxID, transfer.Vout, outPoint)
+		}
+
+		recipient, _ := transaction.P2PKHFromAddress(transfer.To, &chaincfg.TestNet3Params)
+		script, _ := recipient.Lock()
+		if tx.TxOut[i].Value != 1 || !bytes.Equal(tx.TxOut[i].PkScript, script) {
+			t.Errorf("Output %d: expected 1 satoshi to %s", i, transfer.To)
+		}
+	}
+
+	if len(tx.TxIn) != 3 || len(tx.TxOut) != 3 || tx.TxOut[2].Value <= 1 {
+		t.Errorf("Expected a funding input and change after the ordinals, got %d inputs and %d outputs", len(tx.TxIn), len(tx.TxOut))
+	}
+
+	invalid := []*types.OrdinalTransfer{
+		{TxID: "c7b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90", Vout: 0, To: to.Address},
+		{TxID: plainID, Vout: 4, To: to.Address},
+	}
+	for i, transfer := range invalid {
+		params.Transfers = []*types.OrdinalTransfer{transfer}
+		if _, err := builder.BuildOrdinalTransfer(params); err == nil {
+			t.Errorf("Invalid transfer %d: expected error", i)
+		}
+	}
+
+	params.Transfers = []*types.OrdinalTransfer{{TxID: plainID, Vout: 3, To: to.Address}, {TxID: plainID, Vout: 3, To: other.Address}}
+	if _, err := builder.BuildOrdinalTransfer(params); err == nil {
+		t.Error("Expected error transferring an ordinal twice")
+	}
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+func newPayout(sender *types.WalletResult, mnemonicPhrase string, recipients []*types.WalletResult, mode types.SplitMode) *types.PayoutParams {
+	params := &types.PayoutParams{
+		From:       sender.Address,
+		PrivateKey: types.NewSecret(mnemonicPhrase),
+		FeeRate:    1,
+		SplitMode:  mode,
+	}
+
+	for _, recipient := range recipients {
+		params.Outputs = append(params.Outputs, &types.PayoutOutput{To: recipient.Address, Amount: 1000})
+	}
+
+	return params
+}
+
+func TestMaxTransactionSizeEnforced(t *testing.T) {
+	node := newMockNode(t)
+	sender, mnemonicPhrase := newTestWallet(t)
+	recipient, _ := newTestWallet(t)
+	node.addUTXO(sender.Address, fmt.Sprintf("%064x", 1), 0, 100000, "")
+
+	configManager := node.configManager(t)
+	txConfig := configManager.GetTransactionConfig()
+	txConfig.MaxTransactionSize = 200
+	if err := configManager.UpdateTransactionConfig(txConfig); err != nil {
+		t.Fatalf("Failed to update transaction config: %v", err)
+	}
+
+	builder := transaction.NewBuilder(configManager)
+	_, err := builder.BuildTransaction(&types.TransactionParams{
+		From:       sender.Address,
+		To:         recipient.Address,
+		Amount:     1000,
+		FeeRate:    1,
+		PrivateKey: types.NewSecret(mnemonicPhrase),
+	})
+
+	var sizeErr *transaction.TransactionSizeError
+	if !errors.As(err, &sizeErr) {
+		t.Fatalf("Expected TransactionSizeError, got %v", err)
+	}
+
+	if !errors.Is(err, types.ErrTransactionTooLarge) || sizeErr.MaxSize != 200 || sizeErr.Size <= 200 {
+		t.Errorf("Unexpected size error: %+v", sizeErr)
+	}
+}
+
+func TestPayoutSplitting(t *testing.T) {
+	node := newMockNode(t)
+	sender, mnemonicPhrase := newTestWallet(t)
+	for i := 0; i < 5; i++ {
+		node.addUTXO(sender.Address, fmt.Sprintf("%064x", i+1), 0, 20000, "")
+	}
+
+	configManager := node.configManager(t)
+	txConfig := configManager.GetTransactionConfig()
+	txConfig.MaxTransactionSize = 600
+	if err := configManager.UpdateTransactionConfig(txConfig); err != nil {
+		t.Fatalf("Failed to update transaction config: %v", err)
+	}
+
+	builder := transaction.NewBuilder(configManager)
+
+	recipients := make([]*types.WalletResult, 30)
+	for i := range recipients {
+		recipients[i], _ = newTestWallet(t)
+	}
+
+	// Thirty outputs do not fit in 600 bytes
+	_, err := builder.BuildPayout(newPayout(sender, mnemonicPhrase, recipients, types.SplitNone))
+	if !errors.Is(err, types.ErrTransactionTooLarge) {
+		t.Fatalf("Expected ErrTransactionTooLarge without splitting, got %v", err)
+	}
+
+	for _, mode := range []types.SplitMode{types.SplitIndependent, types.SplitChained} {
+		params := newPayout(sender, mnemonicPhrase, recipients, mode)
+
+		txs, err := builder.BuildPayout(params)
+		if err != nil {
+			t.Fatalf("%s payout failed: %v", mode, err)
+		}
+
+		if len(txs) < 2 {
+			t.Errorf("%s: expected the payout to be split, got %d transaction", mode, len(txs))
+		}
+
+		paid := make(map[string]bool)
+		for i, tx := range txs {
+			if size := tx.SerializeSize(); size > 600 {
+				t.Errorf("%s: transaction %d is %d bytes", mode, i, size)
+			}
+			for _, txOut := range tx.TxOut {
+				if txOut.Value == 1000 {
+					paid[fmt.Sprintf("%x", txOut.PkScript)] = true
+				}
+			}
+			if mode == types.SplitChained && i > 0 {
+				prev := txs[i-1]
+				in := tx.TxIn[0].PreviousOutPoint
+				if len(tx.TxIn) != 1 || in.Hash != prev.TxHash() || int(in.Index) != len(prev.TxOut)-1 {
+					t.Errorf("%s: transaction %d does not spend the previous change output", mode, i)
+				}
+			}
+		}
+
+		if len(paid) != 30 {
+			t.Errorf("%s: expected 30 payments, got %d", mode, len(paid))
+		}
+	}
+}
+
+func TestTruncatedUTXOSetReported(t *testing.T) {
+	node := newMockNode(t)
+	sender, mnemonicPhrase := newTestWallet(t)
+	recipient, _ := newTestWallet(t)
+	node.addUTXO(sender.Address, fmt.Sprintf("%064x", 1), 0, 5000, "")
+	node.addUTXO(sender.Address, fmt.Sprintf("%064x", 2), 0, 5000, "")
+
+	configManager := node.configManager(t)
+	utxoConfig := configManager.GetUTXOConfig()
+	utxoConfig.MaxUTXOsPerQuery = 1
+	if err := configManager.UpdateUTXOConfig(utxoConfig); err != nil {
+		t.Fatalf("Failed to update UTXO config: %v", err)
+	}
+
+	_, err := transaction.NewBuilder(configManager).BuildTransaction(&types.TransactionParams{
+		From:       sender.Address,
+		To:         recipient.Address,
+		Amount:     8000,
+		FeeRate:    1,
+		PrivateKey: types.NewSecret(mnemonicPhrase),
+	})
+
+	if !errors.Is(err, types.ErrUTXOSetTruncated) || !errors.Is(err, types.ErrInsufficientFunds) {
+		t.Errorf("Expected truncation to be reported, got %v", err)
+	}
+}
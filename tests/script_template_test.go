@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/utxo"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// spendTemplate locks an output with a template, spends it with the same
+// template and verifies the result
+func spendTemplate(t *testing.T, template transaction.ScriptTemplate, amount int64) error {
+	t.Helper()
+
+	lockingScript, err := template.Lock()
+	if err != nil {
+		t.Fatalf("Failed to create locking script: %v", err)
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0x42}, 1), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(amount-200, []byte{txscript.OP_TRUE}))
+
+	unlockingScript, err := template.Unlock(tx, 0, lockingScript, amount)
+	if err != nil {
+		return err
+	}
+
+	if len(unlockingScript) > template.EstimateUnlockLength() {
+		t.Errorf("Unlocking script of %d bytes exceeds the estimate of %d", len(unlockingScript), template.EstimateUnlockLength())
+	}
+
+	return transaction.VerifyScript(unlockingScript, lockingScript, tx, 0, amount, transaction.StandardScriptFlags)
+}
+
+func TestStandardTemplatesSpend(t *testing.T) {
+	key1, _ := p2pkhTestKey(t, 0x31)
+	key2, _ := p2pkhTestKey(t, 0x32)
+	key3, _ := p2pkhTestKey(t, 0x33)
+
+	multisig, err := transaction.NewMultiSigTemplate(2, [][]byte{
+		key1.PublicKey.SerializeCompressed(),
+		key2.PublicKey.SerializeCompressed(),
+		key3.PublicKey.SerializeCompressed(),
+	}, key3, key1)
+	if err != nil {
+		t.Fatalf("Failed to create multisig template: %v", err)
+	}
+
+	rPuzzle, err := transaction.NewRPuzzleTemplate(bytes.Repeat([]byte{0x07}, 32), key2)
+	if err != nil {
+		t.Fatalf("Failed to create R-puzzle template: %v", err)
+	}
+
+	templates := map[string]transaction.ScriptTemplate{
+		"P2PKH":       transaction.NewP2PKHTemplate(key1),
+		"P2PK":        transaction.NewP2PKTemplate(key2),
+		"multisig":    multisig,
+		"hash puzzle": transaction.NewHashPuzzleTemplate([]byte("open sesame")),
+		"R-puzzle":    rPuzzle,
+	}
+
+	for name, template := range templates {
+		if err := spendTemplate(t, template, 10000); err != nil {
+			t.Errorf("%s: expected spend to verify: %v", name, err)
+		}
+	}
+}
+
+func TestP2PKHTemplateMatchesAddress(t *testing.T) {
+	wallet, _ := newTestWallet(t)
+
+	template, err := transaction.P2PKHFromAddress(wallet.Address, &chaincfg.TestNet3Params)
+	if err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+
+	script, _ := template.Lock()
+	addr, _ := btcutil.DecodeAddress(wallet.Address, &chaincfg.TestNet3Params)
+	expected, _ := txscript.PayToAddrScript(addr)
+	if !bytes.Equal(script, expected) {
+		t.Errorf("Expected %x, got %x", expected, script)
+	}
+
+	// Without a key the template can only lock
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, 0), nil, nil))
+	if _, err := template.Unlock(tx, 0, script, 1000); err == nil {
+		t.Error("Expected error unlocking without a key")
+	}
+
+	// The estimate matches the size coin selection assumes
+	key, _ := p2pkhTestKey(t, 0x34)
+	if size := transaction.EstimateInputSize(transaction.NewP2PKHTemplate(key)); size != utxo.P2PKHInputSize {
+		t.Errorf("Expected P2PKH input size %d, got %d", utxo.P2PKHInputSize, size)
+	}
+}
+
+func TestTemplatesRejectWrongSpends(t *testing.T) {
+	key1, lockingScript1 := p2pkhTestKey(t, 0x41)
+	key2, _ := p2pkhTestKey(t, 0x42)
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, 0), nil, nil))
+
+	// A key cannot unlock an output locked to another key
+	if _, err := transaction.NewP2PKHTemplate(key2).Unlock(tx, 0, lockingScript1, 1000); err == nil {
+		t.Error("Expected error unlocking another key's output")
+	}
+
+	// Data outputs are unspendable
+	opReturn := &transaction.OpReturnTemplate{Data: [][]byte{[]byte("hello"), bytes.Repeat([]byte{0xab}, 600)}}
+	script, _ := opReturn.Lock()
+	if script[0] != txscript.OP_FALSE || script[1] != txscript.OP_RETURN {
+		t.Errorf("Expected OP_FALSE OP_RETURN prefix, got %x", script[:2])
+	}
+	if _, err := opReturn.Unlock(tx, 0, script, 0); err == nil {
+		t.Error("Expected error unlocking an OP_RETURN output")
+	}
+
+	// Not enough cosigners
+	multisig, _ := transaction.NewMultiSigTemplate(2, [][]byte{
+		key1.PublicKey.SerializeCompressed(),
+		key2.PublicKey.SerializeCompressed(),
+	}, key1)
+	if err := spendTemplate(t, multisig, 1000); err == nil {
+		t.Error("Expected error with one of two required signers")
+	}
+
+	if _, err := transaction.NewMultiSigTemplate(3, [][]byte{key1.PublicKey.SerializeCompressed()}); err == nil {
+		t.Error("Expected error requiring more signatures than keys")
+	}
+
+	// A hash puzzle with the wrong preimage
+	puzzle := transaction.NewHashPuzzleTemplate([]byte("secret"))
+	puzzle.Preimage = []byte("guess")
+	if err := spendTemplate(t, puzzle, 1000); err == nil {
+		t.Error("Expected error with the wrong preimage")
+	}
+}
+
+func TestSignWithTemplates(t *testing.T) {
+	node := newMockNode(t)
+	key, _ := p2pkhTestKey(t, 0x51)
+
+	p2pk := transaction.NewP2PKTemplate(key)
+	lockingScript, _ := p2pk.Lock()
+
+	input := types.UTXO{
+		TxID:         "f1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90",
+		Vout:         2,
+		Value:        25000,
+		ScriptPubKey: hex.EncodeToString(lockingScript),
+	}
+
+	hash, _ := chainhash.NewHashFromStr(input.TxID)
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(hash, input.Vout), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(24800, lockingScript))
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	if err := builder.SignWithTemplates(tx, []types.UTXO{input}, []transaction.ScriptTemplate{p2pk}); err != nil {
+		t.Fatalf("Failed to sign with templates: %v", err)
+	}
+
+	if err := builder.Verify(tx, []*wire.TxOut{wire.NewTxOut(input.Value, lockingScript)}); err != nil {
+		t.Errorf("Expected P2PK spend to verify: %v", err)
+	}
+
+	if err := builder.SignWithTemplates(tx, []types.UTXO{input}, nil); err == nil {
+		t.Error("Expected error when unlockers do not match the inputs")
+	}
+}
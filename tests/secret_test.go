@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+func TestSecretRedaction(t *testing.T) {
+	from, mnemonicPhrase := newTestWallet(t)
+	wif := from.PrivateKey.Reveal()
+	if !strings.HasPrefix(wif, "c") {
+		t.Fatalf("Expected a testnet WIF key, got %d characters", len(wif))
+	}
+
+	// No formatting verb or encoding shows the key
+	for _, format := range []string{"%s", "%v", "%+v", "%#v", "%x", "%q"} {
+		if printed := fmt.Sprintf(format, from); strings.Contains(printed, wif) {
+			t.Errorf("%s revealed the private key", format)
+		}
+	}
+	encoded, _ := json.Marshal(from)
+	if strings.Contains(string(encoded), wif) || !strings.Contains(string(encoded), "[REDACTED]") {
+		t.Errorf("Expected a redacted private key in JSON, got %s", encoded)
+	}
+
+	var decoded struct{ Key types.Secret }
+	if err := json.Unmarshal([]byte(`{"Key":"`+mnemonicPhrase+`"}`), &decoded); err != nil || decoded.Key.Reveal() != mnemonicPhrase {
+		t.Errorf("Expected the secret to be read from JSON: %v", err)
+	}
+	if err := json.Unmarshal(encoded, &types.WalletResult{}); err == nil {
+		t.Error("Expected error decoding a redacted secret")
+	}
+
+	// Keys in transaction params are secrets as well
+	params := types.TransactionParams{PrivateKey: types.NewSecret(mnemonicPhrase), FundingKeys: []types.Secret{from.PrivateKey}}
+	if printed := fmt.Sprintf("%+v", params); strings.Contains(printed, mnemonicPhrase) || strings.Contains(printed, wif) {
+		t.Errorf("Printing params revealed a private key: %s", printed)
+	}
+	if err := json.Unmarshal([]byte(`{"privateKey":"`+wif+`"}`), &params); err != nil || params.PrivateKey.Reveal() != wif {
+		t.Errorf("Expected the private key to be read from JSON: %v", err)
+	}
+}
+
+func TestSecretZero(t *testing.T) {
+	secret := types.NewSecret("xprv-like secret")
+	copied := secret
+	secret.Zero()
+
+	if !copied.IsEmpty() || strings.Trim(copied.Reveal(), "\x00") != "" {
+		t.Error("Expected Zero to wipe every copy of the secret")
+	}
+
+	keyPair, _ := p2pkhTestKey(t, 0xa1)
+	keyPair.Zero()
+	if keyPair.PrivateKey != nil {
+		t.Error("Expected the key pair's private key to be wiped")
+	}
+	if _, err := keyPair.SignMessage([]byte("hello")); err == nil {
+		t.Error("Expected a wiped key pair to refuse to sign")
+	}
+
+	// Wiping derivation buffers does not change the derived keys
+	_, mnemonicPhrase := newTestWallet(t)
+	first, _ := wallet.GenerateWallet(mnemonicPhrase, true)
+	second, _ := wallet.GenerateWallet(mnemonicPhrase, true)
+	if first.PrivateKey.Reveal() != second.PrivateKey.Reveal() || first.Address != second.Address {
+		t.Error("Expected the same wallet from the same mnemonic")
+	}
+}
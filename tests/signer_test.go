@@ -0,0 +1,281 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// signingService signs with in-memory keys, standing in for an HSM
+type signingService struct {
+	keys  map[string]wallet.Signer
+	calls int
+}
+
+func (s *signingService) SignHash(keyID string, sighash []byte) ([]byte, error) {
+	s.calls++
+	signer, ok := s.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %s", keyID)
+	}
+	return signer.Sign(sighash)
+}
+
+func TestSignerImplementations(t *testing.T) {
+	from, mnemonicPhrase := newTestWallet(t)
+	keyPair, _ := p2pkhTestKey(t, 0x71)
+	other, _ := p2pkhTestKey(t, 0x72)
+	network := &chaincfg.TestNet3Params
+
+	wif, _ := btcutil.NewWIF(keyPair.PrivateKey, network, true)
+	wifSigner, err := wallet.NewWIFSigner(wif.String(), network)
+	if err != nil {
+		t.Fatalf("Failed to create WIF signer: %v", err)
+	}
+	if _, err := wallet.NewWIFSigner(wif.String(), &chaincfg.MainNetParams); err == nil {
+		t.Error("Expected error for a WIF key of another network")
+	}
+
+	hdSigner, err := wallet.NewMnemonicSigner(mnemonicPhrase, true)
+	if err != nil {
+		t.Fatalf("Failed to create mnemonic signer: %v", err)
+	}
+	if address, _ := wallet.SignerAddress(hdSigner, network); address != from.Address || hdSigner.Path() != "m/44'/1'/0'/0/0" {
+		t.Errorf("Expected %s at m/44'/1'/0'/0/0, got %s at %s", from.Address, address, hdSigner.Path())
+	}
+
+	service := &signingService{keys: map[string]wallet.Signer{"hot-1": wifSigner, "hot-2": wallet.NewKeySigner(other)}}
+	remote := wallet.NewRemoteSigner(service, "hot-1", keyPair.PublicKey)
+
+	hash := sha256.Sum256([]byte("sighash"))
+	for name, signer := range map[string]wallet.Signer{"wif": wifSigner, "hd": hdSigner, "remote": remote} {
+		der, err := signer.Sign(hash[:])
+		if err != nil {
+			t.Fatalf("%s: failed to sign: %v", name, err)
+		}
+		signature, err := ecdsa.ParseDERSignature(der)
+		if err != nil || !signature.Verify(hash[:], signer.PublicKey()) {
+			t.Errorf("%s: signature does not verify: %v", name, err)
+		}
+		if _, err := signer.Sign(hash[:31]); err == nil {
+			t.Errorf("%s: expected error signing a short hash", name)
+		}
+	}
+
+	// A service answering with the wrong key is caught
+	if _, err := wallet.NewRemoteSigner(service, "hot-2", keyPair.PublicKey).Sign(hash[:]); err == nil {
+		t.Error("Expected error for a signature by another key")
+	}
+	if _, err := wallet.NewRemoteSigner(service, "missing", keyPair.PublicKey).Sign(hash[:]); err == nil {
+		t.Error("Expected error for an unknown remote key")
+	}
+}
+
+func TestSendWithSigner(t *testing.T) {
+	node := newMockNode(t)
+	keyPair, _ := p2pkhTestKey(t, 0x73)
+	to, _ := newTestWallet(t)
+	from, _ := keyPair.Address()
+	node.addUTXO(from, fmt.Sprintf("%064x", 1), 0, 50000, "")
+
+	service := &signingService{keys: map[string]wallet.Signer{"treasury": wallet.NewKeySigner(keyPair)}}
+	signer := wallet.NewRemoteSigner(service, "treasury", keyPair.PublicKey)
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	params := &types.TransactionParams{From: from, To: to.Address, Amount: 10000, FeeRate: 1}
+	result, err := builder.SignAndSendTransactionWithSigner(params, signer)
+	if err != nil {
+		t.Fatalf("Failed to send with a remote signer: %v", err)
+	}
+	if service.calls != 1 || len(node.broadcast) != 1 || result.Change == 0 {
+		t.Errorf("Expected one remote signature and a broadcast with change, got %d signatures", service.calls)
+	}
+
+	// The params never carry the key
+	params.PrivateKey = types.NewSecret("also-a-key")
+	if _, err := builder.BuildTransactionWithSigner(params, signer); err == nil {
+		t.Error("Expected error when both a private key and a signer are given")
+	}
+	params.PrivateKey = types.NewSecret("")
+
+	other, _ := p2pkhTestKey(t, 0x74)
+	if _, err := builder.BuildTransactionWithSigner(params, wallet.NewKeySigner(other)); err == nil {
+		t.Error("Expected error for a signer that does not own the sender address")
+	}
+}
+
+func TestHDSignerReceivesHDChange(t *testing.T) {
+	node := newMockNode(t)
+	from, mnemonicPhrase := newTestWallet(t)
+	to, _ := newTestWallet(t)
+	node.addUTXO(from.Address, fmt.Sprintf("%064x", 1), 0, 50000, "")
+
+	account, _ := wallet.NewHDAccount(mnemonicPhrase, 0, true)
+	signer, err := wallet.NewHDSigner(account, wallet.ExternalChain, 0)
+	if err != nil {
+		t.Fatalf("Failed to create HD signer: %v", err)
+	}
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	result, err := builder.SignAndSendTransactionWithSigner(&types.TransactionParams{From: from.Address, To: to.Address, Amount: 10000, FeeRate: 1}, signer)
+	if err != nil {
+		t.Fatalf("Failed to send with an HD signer: %v", err)
+	}
+
+	if path := result.OutputsCreated[len(result.OutputsCreated)-1].DerivationPath; path != "m/44'/1'/0'/1/0" {
+		t.Errorf("Expected HD change at m/44'/1'/0'/1/0, got %q", path)
+	}
+}
+
+func TestKeyringRemoteSignerFundsTransaction(t *testing.T) {
+	node := newMockNode(t)
+	from, mnemonicPhrase := newTestWallet(t)
+	to, _ := newTestWallet(t)
+	keyPair, _ := p2pkhTestKey(t, 0x75)
+
+	service := &signingService{keys: map[string]wallet.Signer{"cold": wallet.NewKeySigner(keyPair)}}
+	keyring := wallet.NewKeyring()
+	address, err := keyring.AddSigner(wallet.NewRemoteSigner(service, "cold", keyPair.PublicKey), &chaincfg.TestNet3Params)
+	if err != nil {
+		t.Fatalf("Failed to add remote signer: %v", err)
+	}
+	node.addUTXO(address, fmt.Sprintf("%064x", 1), 0, 50000, "")
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	builder.SetKeyring(keyring)
+	if _, err := builder.SignAndSendTransaction(&types.TransactionParams{
+		From:             from.Address,
+		To:               to.Address,
+		Amount:           10000,
+		FeeRate:          1,
+		PrivateKey:       types.NewSecret(mnemonicPhrase),
+		FundingAddresses: []string{address},
+	}); err != nil {
+		t.Fatalf("Failed to spend a remotely held UTXO: %v", err)
+	}
+
+	if service.calls != 1 {
+		t.Errorf("Expected the remote key to sign once, got %d", service.calls)
+	}
+}
+
+func TestSignOfflineWithSigner(t *testing.T) {
+	node := newMockNode(t)
+	keyPair, _ := p2pkhTestKey(t, 0x76)
+	to, _ := newTestWallet(t)
+	from, _ := keyPair.Address()
+	node.addUTXO(from, fmt.Sprintf("%064x", 1), 0, 50000, "")
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	unsigned, err := builder.BuildUnsignedTransaction(&types.TransactionParams{From: from, To: to.Address, Amount: 10000, FeeRate: 1})
+	if err != nil {
+		t.Fatalf("Failed to build unsigned transaction: %v", err)
+	}
+
+	signed, err := transaction.SignOfflineWithSigner(unsigned, wallet.NewKeySigner(keyPair))
+	if err != nil || signed != 1 {
+		t.Fatalf("Expected one signed input, got %d: %v", signed, err)
+	}
+
+	if _, err := builder.FinalizeTransaction(unsigned); err != nil {
+		t.Fatalf("Failed to finalize: %v", err)
+	}
+	if len(node.broadcast) != 1 || !bytes.Contains(node.broadcast[0], keyPair.PublicKey.SerializeCompressed()) {
+		t.Error("Expected the signed transaction to be broadcast")
+	}
+}
+
+func TestPayoutAndOrdinalsWithSigner(t *testing.T) {
+	node := newMockNode(t)
+	keyPair, _ := p2pkhTestKey(t, 0x77)
+	to1, _ := newTestWallet(t)
+	to2, _ := newTestWallet(t)
+	from, _ := keyPair.Address()
+	node.addUTXO(from, fmt.Sprintf("%064x", 1), 0, 50000, "")
+	node.addUTXO(from, fmt.Sprintf("%064x", 2), 0, 50000, "")
+	node.addUTXO(from, fmt.Sprintf("%064x", 3), 0, 1, "")
+
+	service := &signingService{keys: map[string]wallet.Signer{"treasury": wallet.NewKeySigner(keyPair)}}
+	signer := wallet.NewRemoteSigner(service, "treasury", keyPair.PublicKey)
+	builder := transaction.NewBuilder(node.configManager(t))
+
+	payout := &types.PayoutParams{From: from, FeeRate: 1, Outputs: []*types.PayoutOutput{{To: to1.Address, Amount: 1000}, {To: to2.Address, Amount: 2000}}}
+	if _, err := builder.SignAndSendPayoutWithSigner(payout, signer); err != nil {
+		t.Fatalf("Failed to send payout with a signer: %v", err)
+	}
+
+	inscription := &types.InscriptionParams{From: from, FeeRate: 1, Inscriptions: []*types.Inscription{{ContentType: "text/plain", Body: []byte("signed remotely")}}}
+	if _, err := builder.BuildInscriptionWithSigner(inscription, signer); err != nil {
+		t.Fatalf("Failed to build inscription with a signer: %v", err)
+	}
+
+	transfer := &types.OrdinalTransferParams{From: from, FeeRate: 1, Transfers: []*types.OrdinalTransfer{{TxID: fmt.Sprintf("%064x", 3), Vout: 0, To: to1.Address}}}
+	if _, err := builder.BuildOrdinalTransferWithSigner(transfer, signer); err != nil {
+		t.Fatalf("Failed to build ordinal transfer with a signer: %v", err)
+	}
+
+	if service.calls == 0 || len(node.broadcast) != 1 {
+		t.Errorf("Expected remote signatures and one broadcast payout, got %d signatures and %d broadcasts", service.calls, len(node.broadcast))
+	}
+
+	payout.PrivateKey = types.NewSecret("also-a-key")
+	if _, err := builder.BuildPayoutWithSigner(payout, signer); err == nil {
+		t.Error("Expected error when both a private key and a signer are given")
+	}
+}
+
+func TestMultiSigWithSigners(t *testing.T) {
+	key1, _ := p2pkhTestKey(t, 0x78)
+	key2, _ := p2pkhTestKey(t, 0x79)
+	publicKeys := [][]byte{key1.PublicKey.SerializeCompressed(), key2.PublicKey.SerializeCompressed()}
+
+	service := &signingService{keys: map[string]wallet.Signer{"hsm-1": wallet.NewKeySigner(key1), "hsm-2": wallet.NewKeySigner(key2)}}
+	remote1 := wallet.NewRemoteSigner(service, "hsm-1", key1.PublicKey)
+	remote2 := wallet.NewRemoteSigner(service, "hsm-2", key2.PublicKey)
+
+	multisig, err := transaction.NewMultiSigSignerTemplate(2, publicKeys, remote2, remote1)
+	if err != nil {
+		t.Fatalf("Failed to create multisig template: %v", err)
+	}
+	if err := spendTemplate(t, multisig, 10000); err != nil {
+		t.Errorf("Expected remote cosigners to unlock the multisig: %v", err)
+	}
+
+	// Cosigning an unsigned transaction offline
+	node := newMockNode(t)
+	to, _ := newTestWallet(t)
+	scriptHash, _ := multisig.ScriptHash()
+	node.addUTXO(scriptHash, fmt.Sprintf("%064x", 1), 0, 50000, "")
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	unsigned, err := builder.BuildMultiSigTransaction(multisig, &types.TransactionParams{To: to.Address, Amount: 20000, FeeRate: 1})
+	if err != nil {
+		t.Fatalf("Failed to build multisig transaction: %v", err)
+	}
+
+	calls := service.calls
+	for _, signer := range []wallet.Signer{remote1, remote2} {
+		if signed, err := transaction.CosignOfflineWithSigner(unsigned, signer); err != nil || signed != 1 {
+			t.Fatalf("Expected one signature, got %d: %v", signed, err)
+		}
+	}
+	if service.calls != calls+2 {
+		t.Errorf("Expected two remote signatures, got %d", service.calls-calls)
+	}
+
+	if _, err := transaction.AssembleMultiSig(unsigned); err != nil {
+		t.Fatalf("Failed to assemble multisig: %v", err)
+	}
+	if _, err := builder.FinalizeTransaction(unsigned); err != nil {
+		t.Fatalf("Failed to finalize: %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/signing"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/signing/signingtest"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/wallet"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// recordingApprover keeps every request it is asked to approve
+type recordingApprover struct {
+	mutex    sync.Mutex
+	requests []*signing.SignRequest
+	refuse   bool
+}
+
+func (a *recordingApprover) Approve(request *signing.SignRequest) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.requests = append(a.requests, request)
+	if a.refuse {
+		return fmt.Errorf("refused by test")
+	}
+	return nil
+}
+
+func TestRemoteSigningServer(t *testing.T) {
+	node := newMockNode(t)
+	from, mnemonicPhrase := newTestWallet(t)
+	to, _ := newTestWallet(t)
+	node.addUTXO(from.Address, fmt.Sprintf("%064x", 1), 0, 50000, "")
+
+	account, _ := wallet.NewHDAccount(mnemonicPhrase, 0, true)
+	approver := &recordingApprover{}
+	harness, err := signingtest.NewHarness(signing.ServerConfig{
+		Keys:         signing.HDKeys{Account: account},
+		AllowedPaths: []string{"m/44'/1'/0'/0/*"},
+		Approver:     approver,
+		Network:      &chaincfg.TestNet3Params,
+	})
+	if err != nil {
+		t.Fatalf("Failed to start signing server: %v", err)
+	}
+	defer harness.Close()
+
+	signer, err := harness.Client.Signer("m/44'/1'/0'/0/0")
+	if err != nil {
+		t.Fatalf("Failed to get remote signer: %v", err)
+	}
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	if _, err := builder.SignAndSendTransactionWithSigner(&types.TransactionParams{From: from.Address, To: to.Address, Amount: 10000, FeeRate: 1}, signer); err != nil {
+		t.Fatalf("Failed to send with the signing server: %v", err)
+	}
+	if len(node.broadcast) != 1 {
+		t.Fatal("Expected the transaction to be broadcast")
+	}
+
+	// The approver is shown what the signature commits to
+	if len(approver.requests) != 1 || approver.requests[0].Preimage == nil {
+		t.Fatalf("Expected one request with a preimage, got %d", len(approver.requests))
+	}
+	preimage := approver.requests[0].Preimage
+	if preimage.OutPoint.String() != fmt.Sprintf("%064x:0", 1) || preimage.Amount != 50000 {
+		t.Errorf("Expected to approve spending %064x:0 of 50000, got %s of %d", 1, preimage.OutPoint, preimage.Amount)
+	}
+	if !strings.Contains(preimage.String(), "amount:        50000 satoshis") {
+		t.Errorf("Expected the amount in the preimage display, got:\n%s", preimage)
+	}
+
+	// And where the outputs pay
+	outputs := approver.requests[0].Outputs
+	if len(outputs) != 2 || outputs[0].Address != to.Address || outputs[0].Value != 10000 || outputs[1].Address != from.Address {
+		t.Errorf("Expected 10000 to %s and change to %s, got %v", to.Address, from.Address, outputs)
+	}
+
+	// Outputs that do not match the preimage's hashOutputs are refused
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(node.broadcast[0])); err != nil {
+		t.Fatalf("Failed to decode broadcast transaction: %v", err)
+	}
+	hashType := transaction.SigHashAll | transaction.SigHashForkID
+	// The change output pays the script the input spends
+	signed, _ := transaction.SignaturePreimage(tx, 0, tx.TxOut[1].PkScript, hashType, 50000)
+	tx.TxOut[0].Value++
+	tampered, _ := transaction.SignatureOutputs(tx, 0, hashType)
+	if _, err := harness.Client.SignPreimage("m/44'/1'/0'/0/0", signed, tampered); err == nil || !strings.Contains(err.Error(), "hashOutputs") {
+		t.Errorf("Expected outputs not matching hashOutputs to be refused, got %v", err)
+	}
+	if _, err := harness.Client.SignPreimage("m/44'/1'/0'/0/0", signed, nil); err == nil {
+		t.Error("Expected a preimage without its outputs to be refused")
+	}
+
+	// Paths outside the allow-list are refused
+	if _, err := harness.Client.Signer("m/44'/1'/0'/1/0"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Expected a 403 for a path outside the allow-list, got %v", err)
+	}
+
+	// Bare sighashes are refused by default
+	hash := sha256.Sum256([]byte("sighash"))
+	if _, err := signer.Sign(hash[:]); err == nil {
+		t.Error("Expected error signing a bare sighash")
+	}
+
+	// So are signatures the approver refuses
+	approver.mutex.Lock()
+	approver.refuse = true
+	approver.mutex.Unlock()
+	if _, err := builder.BuildTransactionWithSigner(&types.TransactionParams{From: from.Address, To: to.Address, Amount: 10000, FeeRate: 1}, signer); err == nil {
+		t.Error("Expected error when the approver refuses")
+	}
+}
+
+func TestSigningServerRequiresClientCertificate(t *testing.T) {
+	keyPair, _ := p2pkhTestKey(t, 0x81)
+	harness, err := signingtest.NewHarness(signing.ServerConfig{
+		Keys:             signing.StaticKeys{"hot": wallet.NewKeySigner(keyPair)},
+		AllowedPaths:     []string{"hot"},
+		AllowSighashOnly: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to start signing server: %v", err)
+	}
+	defer harness.Close()
+
+	signer, err := harness.Client.Signer("hot")
+	if err != nil {
+		t.Fatalf("Failed to get remote signer: %v", err)
+	}
+	if !signer.PublicKey().IsEqual(keyPair.PublicKey) {
+		t.Error("Expected the server's public key")
+	}
+	hash := sha256.Sum256([]byte("sighash"))
+	if _, err := signer.Sign(hash[:]); err != nil {
+		t.Errorf("Expected a bare sighash to be signed when allowed: %v", err)
+	}
+
+	untrusted, err := harness.UntrustedClient()
+	if err != nil {
+		t.Fatalf("Failed to create untrusted client: %v", err)
+	}
+	for name, client := range map[string]*signing.Client{"untrusted": untrusted, "anonymous": harness.AnonymousClient()} {
+		if _, err := client.PublicKey("hot"); err == nil {
+			t.Errorf("%s: expected the server to reject the client", name)
+		}
+	}
+
+	if _, err := harness.Client.PublicKey("cold"); err == nil {
+		t.Error("Expected error for a path outside the allow-list")
+	}
+}
+
+func TestPromptApprover(t *testing.T) {
+	var out bytes.Buffer
+	approver := signing.PromptApprover(strings.NewReader("y\nn\n"), &out)
+	request := &signing.SignRequest{KeyPath: "hot", Sighash: make([]byte, 32)}
+
+	if err := approver.Approve(request); err != nil {
+		t.Errorf("Expected approval for y: %v", err)
+	}
+	if err := approver.Approve(request); err == nil {
+		t.Error("Expected refusal for n")
+	}
+	if err := approver.Approve(request); err == nil {
+		t.Error("Expected refusal without an answer")
+	}
+	if !strings.Contains(out.String(), "bare sighash") {
+		t.Errorf("Expected a warning for a bare sighash, got %q", out.String())
+	}
+
+	out.Reset()
+	approver = signing.PromptApprover(strings.NewReader("y\n"), &out)
+	request = &signing.SignRequest{
+		KeyPath:  "hot",
+		Preimage: &signing.Preimage{SighashType: 0x41},
+		Outputs:  []signing.Output{{Value: 1500, Address: "1BoatSLRHtKNngkdXEeobR76b53LETtpyT"}},
+		Sighash:  make([]byte, 32),
+	}
+	if err := approver.Approve(request); err != nil {
+		t.Errorf("Expected approval for y: %v", err)
+	}
+	if !strings.Contains(out.String(), "output 0:      1500 satoshis to 1BoatSLRHtKNngkdXEeobR76b53LETtpyT") {
+		t.Errorf("Expected each output's address and value, got %q", out.String())
+	}
+}
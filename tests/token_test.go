@@ -0,0 +1,260 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/ordinals"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/token"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+const testTokenID = "a1a2a3a4a5a6a7a8a9b0b1b2b3b4b5b6b7b8b9c0c1c2c3c4c5c6c7c8c9d0d1d2_0"
+
+func TestBSV21Scripts(t *testing.T) {
+	owner, _ := newTestWallet(t)
+	protocol := token.NewBSV21()
+
+	deploy, err := protocol.DeployMintScript("GOLD", 21000000, 8, owner.Address, &chaincfg.TestNet3Params)
+	if err != nil {
+		t.Fatalf("Failed to create deploy script: %v", err)
+	}
+
+	txID := "b1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90"
+	tokenID, amount, ok := protocol.Detect(deploy, txID, 2)
+	if !ok || tokenID != txID+"_2" || amount != 21000000 {
+		t.Errorf("Expected deployment %s_2 of 21000000, got %s of %d", txID, tokenID, amount)
+	}
+
+	transfer, err := protocol.TransferScript(testTokenID, 250, owner.Address, &chaincfg.TestNet3Params)
+	if err != nil {
+		t.Fatalf("Failed to create transfer script: %v", err)
+	}
+
+	tokenID, amount, ok = protocol.Detect(transfer, txID, 0)
+	if !ok || tokenID != testTokenID || amount != 250 {
+		t.Errorf("Expected %s of 250, got %s of %d", testTokenID, tokenID, amount)
+	}
+
+	// The envelope precedes the owner's P2PKH script
+	inscription, ownerScript, ok := ordinals.Parse(transfer)
+	if !ok || inscription.ContentType != token.BSV20ContentType {
+		t.Fatalf("Expected a BSV-20 inscription, got %+v", inscription)
+	}
+	expectedOwner, _ := transaction.P2PKHFromAddress(owner.Address, &chaincfg.TestNet3Params)
+	expectedScript, _ := expectedOwner.Lock()
+	if !bytes.Equal(ownerScript, expectedScript) {
+		t.Errorf("Expected owner script %x, got %x", expectedScript, ownerScript)
+	}
+
+	if _, _, ok := protocol.Detect(expectedScript, txID, 0); ok {
+		t.Error("Expected a plain P2PKH script to hold no token")
+	}
+
+	if _, err := protocol.TransferScript("not-a-token", 1, owner.Address, &chaincfg.TestNet3Params); err == nil {
+		t.Error("Expected error with an invalid token ID")
+	}
+}
+
+func TestBuilderSendsBSV21Tokens(t *testing.T) {
+	node := newMockNode(t)
+	from, mnemonicPhrase := newTestWallet(t)
+	to, _ := newTestWallet(t)
+	protocol := token.NewBSV21()
+
+	tokenScript, _ := protocol.TransferScript(testTokenID, 1000, from.Address, &chaincfg.TestNet3Params)
+	node.addUTXO(from.Address, "c1c2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90", 1, 1, hex.EncodeToString(tokenScript))
+	node.addUTXO(from.Address, "c3c2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90", 0, 100000, "")
+
+	builder := transaction.NewBuilder(node.configManager(t))
+
+	// Token UTXOs are detected when fetched
+	utxos, err := builder.GetUTXOs(from.Address)
+	if err != nil {
+		t.Fatalf("Failed to get UTXOs: %v", err)
+	}
+	for _, u := range utxos {
+		if u.Value == 1 && (u.IsNative || u.TokenID != testTokenID || u.TokenAmount != 1000) {
+			t.Errorf("Expected token UTXO of 1000 %s, got %+v", testTokenID, u)
+		}
+	}
+
+	balance, err := builder.GetNonNativeBalance(from.Address)
+	if err != nil || balance.Tokens[testTokenID] == nil || balance.Tokens[testTokenID].Total != 1000 {
+		t.Fatalf("Expected token balance of 1000: %v", err)
+	}
+
+	params := &types.TransactionParams{
+		From:           from.Address,
+		To:             to.Address,
+		Amount:         1000,
+		FeeRate:        1,
+		PrivateKey:     types.NewSecret(mnemonicPhrase),
+		TokenTransfers: []*types.TokenTransfer{{TokenID: testTokenID, To: to.Address, Amount: 300}},
+	}
+	if _, err := builder.SignAndSendTransaction(params); err != nil {
+		t.Fatalf("Failed to send tokens: %v", err)
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(node.broadcast[0])); err != nil {
+		t.Fatalf("Failed to decode broadcast transaction: %v", err)
+	}
+
+	if len(tx.TxOut) != 4 {
+		t.Fatalf("Expected payment, token transfer, token change and change outputs, got %d", len(tx.TxOut))
+	}
+
+	expected := []struct {
+		owner  string
+		amount int64
+	}{{to.Address, 300}, {from.Address, 700}}
+	for i, e := range expected {
+		txOut := tx.TxOut[i+1]
+		tokenID, amount, ok := protocol.Detect(txOut.PkScript, tx.TxHash().String(), uint32(i+1))
+		if !ok || tokenID != testTokenID || amount != e.amount || txOut.Value != 1 {
+			t.Errorf("Output %d: expected 1-sat output of %d tokens, got %d of %s (value %d)", i+1, e.amount, amount, tokenID, txOut.Value)
+		}
+
+		ownerScript, _ := transaction.P2PKHFromAddress(e.owner, &chaincfg.TestNet3Params)
+		script, _ := ownerScript.Lock()
+		if _, owner, _ := ordinals.Parse(txOut.PkScript); !bytes.Equal(owner, script) {
+			t.Errorf("Output %d: expected tokens owned by %s", i+1, e.owner)
+		}
+	}
+
+	// Asking for more tokens than the address holds fails
+	params.TokenTransfers[0].Amount = 5000
+	if _, err := builder.SignAndSendTransaction(params); err == nil {
+		t.Error("Expected error transferring more tokens than held")
+	}
+}
+
+func TestBuilderSendsSeveralTokens(t *testing.T) {
+	node := newMockNode(t)
+	from, mnemonicPhrase := newTestWallet(t)
+	alice, _ := newTestWallet(t)
+	bob, _ := newTestWallet(t)
+	protocol := token.NewBSV21()
+	otherTokenID := "e1e2e3e4e5e6e7e8e9f0f1f2f3f4f5f6f7f8f9a0a1a2a3a4a5a6a7a8a9b0b1b2_1"
+
+	holdings := []struct {
+		tokenID string
+		amount  int64
+	}{{testTokenID, 600}, {testTokenID, 400}, {otherTokenID, 50}}
+	for i, h := range holdings {
+		script, _ := protocol.TransferScript(h.tokenID, h.amount, from.Address, &chaincfg.TestNet3Params)
+		node.addUTXO(from.Address, fmt.Sprintf("%064x", i+1), 0, 1, hex.EncodeToString(script))
+	}
+	node.addUTXO(from.Address, fmt.Sprintf("%064x", 9), 0, 100000, "")
+
+	builder := transaction.NewBuilder(node.configManager(t))
+	params := &types.TransactionParams{
+		From:       from.Address,
+		To:         alice.Address,
+		Amount:     1000,
+		FeeRate:    1,
+		PrivateKey: types.NewSecret(mnemonicPhrase),
+		TokenTransfers: []*types.TokenTransfer{
+			{TokenID: testTokenID, To: alice.Address, Amount: 700},
+			{TokenID: otherTokenID, To: bob.Address, Amount: 50},
+			{TokenID: testTokenID, To: bob.Address, Amount: 100},
+		},
+	}
+	if _, err := builder.SignAndSendTransaction(params); err != nil {
+		t.Fatalf("Failed to send tokens: %v", err)
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(node.broadcast[0])); err != nil {
+		t.Fatalf("Failed to decode broadcast transaction: %v", err)
+	}
+
+	// The transfers in order, then change of the token with a surplus
+	expected := []struct {
+		tokenID string
+		amount  int64
+	}{{testTokenID, 700}, {otherTokenID, 50}, {testTokenID, 100}, {testTokenID, 200}}
+	if len(tx.TxIn) != 4 || len(tx.TxOut) != len(expected)+2 {
+		t.Fatalf("Expected 4 inputs and %d outputs, got %d and %d", len(expected)+2, len(tx.TxIn), len(tx.TxOut))
+	}
+	for i, e := range expected {
+		tokenID, amount, ok := protocol.Detect(tx.TxOut[i+1].PkScript, "", 0)
+		if !ok || tokenID != e.tokenID || amount != e.amount {
+			t.Errorf("Output %d: expected %d of %s, got %d of %s", i+1, e.amount, e.tokenID, amount, tokenID)
+		}
+	}
+
+	// A shortfall in any token fails the whole transaction
+	params.TokenTransfers[1].Amount = 60
+	params.TokenTransfers[2].Amount = 400
+	_, err := builder.SignAndSendTransaction(params)
+	if !errors.Is(err, types.ErrInsufficientTokens) {
+		t.Fatalf("Expected ErrInsufficientTokens, got %v", err)
+	}
+	if !strings.Contains(err.Error(), testTokenID) || !strings.Contains(err.Error(), otherTokenID) {
+		t.Errorf("Expected both shortfalls to be reported, got %v", err)
+	}
+	if len(node.broadcast) != 1 {
+		t.Errorf("Expected nothing more to be broadcast, got %d transactions", len(node.broadcast))
+	}
+}
+
+func TestTokenChangeAndConservation(t *testing.T) {
+	node := newMockNode(t)
+	from, mnemonicPhrase := newTestWallet(t)
+	to, _ := newTestWallet(t)
+	vault, _ := newTestWallet(t)
+	protocol := token.NewBSV21()
+
+	tokenScript, _ := protocol.TransferScript(testTokenID, 1000, from.Address, &chaincfg.TestNet3Params)
+	tokenTxID := fmt.Sprintf("%064x", 1)
+	node.addUTXO(from.Address, tokenTxID, 0, 1, hex.EncodeToString(tokenScript))
+	node.addUTXO(from.Address, fmt.Sprintf("%064x", 2), 0, 100000, "")
+
+	builder := transaction.NewBuilder(node.configManager(t))
+
+	// Token change goes to the configured address
+	tx, err := builder.BuildTransaction(&types.TransactionParams{
+		From:               from.Address,
+		To:                 to.Address,
+		Amount:             1000,
+		FeeRate:            1,
+		PrivateKey:         types.NewSecret(mnemonicPhrase),
+		TokenTransfers:     []*types.TokenTransfer{{TokenID: testTokenID, To: to.Address, Amount: 250}},
+		TokenChangeAddress: vault.Address,
+	})
+	if err != nil {
+		t.Fatalf("Failed to build token transfer: %v", err)
+	}
+
+	vaultP2PKH, _ := transaction.P2PKHFromAddress(vault.Address, &chaincfg.TestNet3Params)
+	vaultScript, _ := vaultP2PKH.Lock()
+	_, amount, ok := protocol.Detect(tx.TxOut[2].PkScript, "", 0)
+	if _, owner, _ := ordinals.Parse(tx.TxOut[2].PkScript); !ok || amount != 750 || !bytes.Equal(owner, vaultScript) {
+		t.Errorf("Expected token change of 750 to %s, got %d", vault.Address, amount)
+	}
+
+	// Moving a token UTXO as a plain ordinal would burn its tokens
+	_, err = builder.BuildOrdinalTransfer(&types.OrdinalTransferParams{
+		From:       from.Address,
+		PrivateKey: types.NewSecret(mnemonicPhrase),
+		FeeRate:    1,
+		Transfers:  []*types.OrdinalTransfer{{TxID: tokenTxID, Vout: 0, To: to.Address}},
+	})
+	var balanceErr *transaction.TokenBalanceError
+	if !errors.Is(err, types.ErrTokensNotConserved) || !errors.As(err, &balanceErr) {
+		t.Fatalf("Expected a token balance error, got %v", err)
+	}
+	if balanceErr.TokenID != testTokenID || balanceErr.Inputs != 1000 || balanceErr.Outputs != 0 {
+		t.Errorf("Unexpected token balance error: %+v", balanceErr)
+	}
+}
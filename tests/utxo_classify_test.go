@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/ordinals"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/token"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/transaction"
+	"github.com/muhammadamman/BSV-Go/pkg/bsv/utxo"
+	"github.com/muhammadamman/BSV-Go/pkg/types"
+)
+
+// stasTestScript returns a STAS-shaped locking script owned by p2pkh
+func stasTestScript(t *testing.T, p2pkh, redemption []byte) []byte {
+	t.Helper()
+
+	body, _ := hex.DecodeString("6976aa607f5f7f7c5e7f7c5d7f7c5c7f7c5b7f7c5a7f7c597f7c587f7c577f7c567f7c557f7c547f7c537f7c527f7c517f7c7e7e7e7e")
+	script, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_RETURN).
+		AddData(redemption).
+		AddOp(txscript.OP_0).
+		AddData([]byte("TST")).
+		Script()
+	if err != nil {
+		t.Fatalf("Failed to build STAS script: %v", err)
+	}
+
+	return append(append(append([]byte{}, p2pkh...), body...), script...)
+}
+
+func TestUTXOClassification(t *testing.T) {
+	node := newMockNode(t)
+	from, _ := newTestWallet(t)
+	keyPair, _ := p2pkhTestKey(t, 0x81)
+	network := &chaincfg.TestNet3Params
+
+	owner, _ := transaction.P2PKHFromAddress(from.Address, network)
+	p2pkh, _ := owner.Lock()
+	p2pk, _ := transaction.NewP2PKTemplate(keyPair).Lock()
+	puzzle, _ := transaction.NewHashPuzzleTemplate([]byte("secret")).Lock()
+	inscription, _ := (&ordinals.Inscription{ContentType: "text/plain", Body: []byte("hi")}).LockingScript(p2pkh)
+	bsv21, _ := token.NewBSV21().TransferScript(testTokenID, 500, from.Address, network)
+	bsv20, _ := token.NewBSV20().TransferScript("ORDI", 42, from.Address, network)
+	redemption := bytes.Repeat([]byte{0x5e}, 20)
+	stas := stasTestScript(t, p2pkh, redemption)
+
+	cases := []struct {
+		script   []byte
+		value    int64
+		typ      types.UTXOType
+		tokenID  string
+		amount   int64
+		isNative bool
+	}{
+		{p2pkh, 5000, types.UTXOTypeP2PKH, "", 0, true},
+		{nil, 6000, types.UTXOTypeP2PKH, "", 0, true},
+		{p2pk, 7000, types.UTXOTypeP2PK, "", 0, true},
+		{p2pkh, 1, types.UTXOTypeOrdinal, "", 0, false},
+		{inscription, 1, types.UTXOTypeOrdinal, "", 0, false},
+		{bsv21, 1, types.UTXOTypeBSV21, testTokenID, 500, false},
+		{bsv20, 1, types.UTXOTypeBSV20, "ORDI", 42, false},
+		{stas, 800, types.UTXOTypeSTAS, hex.EncodeToString(redemption), 800, false},
+		{puzzle, 9000, types.UTXOTypeUnknown, "", 0, false},
+	}
+	for i, c := range cases {
+		node.addUTXO(from.Address, fmt.Sprintf("%064x", i+1), 0, c.value, hex.EncodeToString(c.script))
+	}
+
+	manager := utxo.NewManager(node.configManager(t))
+	utxos, err := manager.GetUTXOs(from.Address)
+	if err != nil || len(utxos) != len(cases) {
+		t.Fatalf("Expected %d UTXOs, got %d: %v", len(cases), len(utxos), err)
+	}
+
+	for i, c := range cases {
+		u := utxos[i]
+		if u.Type != c.typ || u.TokenID != c.tokenID || u.TokenAmount != c.amount || u.IsNative != c.isNative {
+			t.Errorf("UTXO %d: expected %s holding %d %q (native %v), got %s holding %d %q (native %v)",
+				i, c.typ, c.amount, c.tokenID, c.isNative, u.Type, u.TokenAmount, u.TokenID, u.IsNative)
+		}
+	}
+
+	balance, err := manager.GetNonNativeBalance(from.Address)
+	if err != nil {
+		t.Fatalf("Failed to get non-native balance: %v", err)
+	}
+	if len(balance.Tokens) != 3 || balance.UTXOCount != 6 {
+		t.Errorf("Expected 3 tokens in 6 non-native UTXOs, got %d in %d", len(balance.Tokens), balance.UTXOCount)
+	}
+
+	// Only plain satoshis are selected by default
+	selected, _, err := manager.SelectUTXOs(from.Address, 15000, 1)
+	if err != nil {
+		t.Fatalf("Failed to select UTXOs: %v", err)
+	}
+	for _, u := range selected {
+		if !u.IsNative {
+			t.Errorf("Selected a %s UTXO", u.Type)
+		}
+	}
+
+	if _, _, err := manager.SelectUTXOs(from.Address, 20000, 1); err == nil {
+		t.Error("Expected error spending more than the native UTXOs hold")
+	}
+
+	// Opting in to non-native UTXOs adds custom scripts, never ordinals or
+	// tokens
+	configManager := node.configManager(t)
+	txConfig := configManager.GetTransactionConfig()
+	txConfig.IncludeNonNativeUTXOs = true
+	if err := configManager.UpdateTransactionConfig(txConfig); err != nil {
+		t.Fatalf("Failed to update transaction config: %v", err)
+	}
+	manager = utxo.NewManager(configManager)
+
+	selected, _, err = manager.SelectUTXOs(from.Address, 25000, 1)
+	if err != nil {
+		t.Fatalf("Failed to select UTXOs: %v", err)
+	}
+	for _, u := range selected {
+		if !u.IsNative && u.Type != types.UTXOTypeUnknown {
+			t.Errorf("Selected a %s UTXO", u.Type)
+		}
+	}
+
+	if _, _, err := manager.SelectUTXOs(from.Address, 30000, 1); err == nil {
+		t.Error("Expected error spending more than the native and custom script UTXOs hold")
+	}
+}